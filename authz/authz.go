@@ -83,6 +83,11 @@ p, *, *, GET, /api/get-saml-login, *, *
 p, *, *, POST, /api/acs, *, *
 p, *, *, GET, /api/saml/metadata, *, *
 p, *, *, *, /api/saml/redirect, *, *
+p, *, *, GET, /api/saml/idp-initiated, *, *
+p, *, *, POST, /api/saml/artifact-resolve, *, *
+p, *, *, GET, /api/saml/sp-metadata, *, *
+p, *, *, *, /api/saml/slo, *, *
+p, *, *, *, /api/wsfed, *, *
 p, *, *, *, /cas, *, *
 p, *, *, *, /scim, *, *
 p, *, *, *, /api/webauthn, *, *
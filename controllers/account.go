@@ -342,6 +342,12 @@ func (c *ApiController) Logout() {
 			return
 		}
 
+		err = object.SendCasLogoutRequests([]string{c.Ctx.Input.CruSession.SessionID()})
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
 		util.LogInfo(c.Ctx, "API: [%s] logged out", user)
 
 		application := c.GetSessionApplication()
@@ -391,6 +397,12 @@ func (c *ApiController) Logout() {
 			return
 		}
 
+		err = object.SendCasLogoutRequests([]string{c.Ctx.Input.CruSession.SessionID()})
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
 		util.LogInfo(c.Ctx, "API: [%s] logged out", user)
 
 		if redirectUri == "" {
@@ -59,10 +59,16 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 		return
 	}
 
+	err := object.CheckLdapServiceAccount(user, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
 	userId := user.GetId()
 
 	clientIp := util.GetClientIpFromRequest(c.Ctx.Request)
-	err := object.CheckEntryIp(clientIp, user, application, application.OrganizationObj, c.GetAcceptLanguage())
+	err = object.CheckEntryIp(clientIp, user, application, application.OrganizationObj, c.GetAcceptLanguage())
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
@@ -169,12 +175,12 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 			resp.Data2 = user.NeedUpdatePassword
 		}
 	} else if form.Type == ResponseTypeSaml { // saml flow
-		res, redirectUrl, method, err := object.GetSamlResponse(application, user, form.SamlRequest, c.Ctx.Request.Host)
+		res, redirectUrl, method, samlParam, err := object.GetSamlResponse(application, user, form.SamlRequest, form.RelayState, form.SignedQuery, form.Signature, c.Ctx.Request.Host)
 		if err != nil {
 			c.ResponseError(err.Error(), nil)
 			return
 		}
-		resp = &Response{Status: "ok", Msg: "", Data: res, Data2: map[string]interface{}{"redirectUrl": redirectUrl, "method": method, "needUpdatePassword": user.NeedUpdatePassword}}
+		resp = &Response{Status: "ok", Msg: "", Data: res, Data2: map[string]interface{}{"redirectUrl": redirectUrl, "method": method, "samlParam": samlParam, "needUpdatePassword": user.NeedUpdatePassword}}
 
 		if application.EnableSigninSession || application.HasPromptPage() {
 			// The prompt page needs the user to be signed in
@@ -190,6 +196,13 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 				resp = wrapErrorResponse(err)
 			} else {
 				resp.Data = st
+
+				// the service will be notified by a CAS LogoutRequest when this session is logged out
+				_, err = object.AddCasSessionService(c.Ctx.Input.CruSession.SessionID(), application, userId, service, st)
+				if err != nil {
+					c.ResponseError(err.Error(), nil)
+					return
+				}
 			}
 		}
 
@@ -367,7 +380,55 @@ func (c *ApiController) Login() {
 
 	verificationType := ""
 
-	if authForm.Username != "" {
+	if authForm.SigninMethod == "Kerberos" {
+		var application *object.Application
+		application, err = object.GetApplication(fmt.Sprintf("admin/%s", authForm.Application))
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		if application == nil {
+			c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), authForm.Application))
+			return
+		}
+
+		if !application.IsKerberosEnabled() {
+			c.ResponseError(c.T("auth:The login method: login with Kerberos is not enabled for the application"))
+			return
+		}
+
+		// the browser sends the Kerberos ticket of the workstation after being challenged with Negotiate
+		token, ok := strings.CutPrefix(c.Ctx.Request.Header.Get("Authorization"), "Negotiate ")
+		if !ok {
+			c.Ctx.Output.Header("WWW-Authenticate", "Negotiate")
+			c.Ctx.Output.SetStatus(401)
+			c.ResponseError(c.T("auth:Kerberos authentication is required"))
+			return
+		}
+
+		var user *object.User
+		user, err = object.GetUserByKerberosToken(application.Organization, strings.TrimSpace(token))
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		var organization *object.Organization
+		organization, err = object.GetOrganizationByUser(user)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		if checkMfaEnable(c, user, organization, verificationType) {
+			return
+		}
+
+		resp = c.HandleLoggedIn(application, user, &authForm)
+
+		c.Ctx.Input.SetParam("recordUserId", user.GetId())
+	} else if authForm.Username != "" {
 		if authForm.Type == ResponseTypeLogin {
 			if c.GetSessionUsername() != "" {
 				c.ResponseError(c.T("account:Please sign out first"), c.GetSessionUsername())
@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
 )
 
 const (
@@ -48,7 +49,7 @@ func (c *RootController) CasValidate() {
 		c.Ctx.Output.Body([]byte("no\n"))
 		return
 	}
-	if ok, response, issuedService, _ := object.GetCasTokenByTicket(ticket); ok {
+	if ok, response, issuedService, _, err := object.GetCasTokenByServiceTicket(ticket); err == nil && ok {
 		// check whether service is the one for which we previously issued token
 		if issuedService == service {
 			c.Ctx.Output.Body([]byte(fmt.Sprintf("yes\n%s\n", response.User)))
@@ -64,6 +65,7 @@ func (c *RootController) CasServiceValidate() {
 	format := c.Input().Get("format")
 	if !strings.HasPrefix(ticket, "ST") {
 		c.sendCasAuthenticationResponseErr(InvalidTicket, fmt.Sprintf("Ticket %s not recognized", ticket), format)
+		return
 	}
 	c.CasP3ProxyValidate()
 }
@@ -79,6 +81,7 @@ func (c *RootController) CasP3ServiceValidate() {
 	format := c.Input().Get("format")
 	if !strings.HasPrefix(ticket, "ST") {
 		c.sendCasAuthenticationResponseErr(InvalidTicket, fmt.Sprintf("Ticket %s not recognized", ticket), format)
+		return
 	}
 	c.CasP3ProxyValidate()
 }
@@ -98,7 +101,11 @@ func (c *RootController) CasP3ProxyValidate() {
 		c.sendCasAuthenticationResponseErr(InvalidRequest, "service and ticket must exist", format)
 		return
 	}
-	ok, response, issuedService, userId := object.GetCasTokenByTicket(ticket)
+	ok, response, issuedService, userId, err := object.GetCasTokenByTicket(ticket)
+	if err != nil {
+		c.sendCasAuthenticationResponseErr(InternalError, err.Error(), format)
+		return
+	}
 	// find the token
 	if ok {
 		// check whether service is the one for which we previously issued token
@@ -117,7 +124,11 @@ func (c *RootController) CasP3ProxyValidate() {
 
 	if pgtUrl != "" && serviceResponse.Failure == nil {
 		// that means we are in proxy web flow
-		pgt := object.StoreCasTokenForPgt(serviceResponse.Success, service, userId)
+		pgt, err := object.StoreCasTokenForPgt(serviceResponse.Success, service, userId)
+		if err != nil {
+			c.sendCasAuthenticationResponseErr(InternalError, err.Error(), format)
+			return
+		}
 		pgtiou := serviceResponse.Success.ProxyGrantingTicket
 		// todo: check whether it is https
 		pgtUrlObj, err := url.Parse(pgtUrl)
@@ -169,7 +180,11 @@ func (c *RootController) CasProxy() {
 		return
 	}
 
-	ok, authenticationSuccess, issuedService, userId := object.GetCasTokenByPgt(pgt)
+	ok, authenticationSuccess, issuedService, userId, err := object.GetCasTokenByPgt(pgt)
+	if err != nil {
+		c.sendCasProxyResponseErr(InternalError, err.Error(), format)
+		return
+	}
 	if !ok {
 		c.sendCasProxyResponseErr(UnauthorizedService, "service not authorized", format)
 		return
@@ -180,7 +195,11 @@ func (c *RootController) CasProxy() {
 		newAuthenticationSuccess.Proxies = &object.CasProxies{}
 	}
 	newAuthenticationSuccess.Proxies.Proxies = append(newAuthenticationSuccess.Proxies.Proxies, issuedService)
-	proxyTicket := object.StoreCasTokenForProxyTicket(&newAuthenticationSuccess, targetService, userId)
+	proxyTicket, err := object.StoreCasTokenForProxyTicket(&newAuthenticationSuccess, targetService, userId)
+	if err != nil {
+		c.sendCasProxyResponseErr(InternalError, err.Error(), format)
+		return
+	}
 
 	serviceResponse := object.CasServiceResponse{
 		Xmlns: "http://www.yale.edu/tp/cas",
@@ -279,3 +298,176 @@ func (c *RootController) sendCasAuthenticationResponseErr(code, msg, format stri
 		c.ServeXML()
 	}
 }
+
+func (c *RootController) sendCasRestResponse(status int, body string) {
+	c.Ctx.Output.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Ctx.Output.SetStatus(status)
+	c.Ctx.Output.Body([]byte(body))
+}
+
+func (c *RootController) getCasRestApplication() *object.Application {
+	organization := c.Ctx.Input.Param(":organization")
+	applicationName := c.Ctx.Input.Param(":application")
+
+	application, err := object.GetApplication(util.GetId("admin", applicationName))
+	if err != nil {
+		c.sendCasRestResponse(http.StatusInternalServerError, err.Error())
+		return nil
+	}
+
+	if application == nil || application.Organization != organization {
+		c.sendCasRestResponse(http.StatusNotFound, fmt.Sprintf(c.T("auth:The application: %s does not exist"), applicationName))
+		return nil
+	}
+
+	return application
+}
+
+// CasRestLogin
+// @Title CasRestLogin
+// @Tag CAS API
+// @Description CAS REST protocol, get a ticket granting ticket (TGT) with the username and password
+// @Param   organization    path    string  true        "The organization of the application"
+// @Param   application    path    string  true        "The name of the application"
+// @Param   username    formData    string  true        "The username"
+// @Param   password    formData    string  true        "The password"
+// @Success 201 {string} string The TGT resource URL is in the Location header
+// @router /cas/:organization/:application/v1/tickets [post]
+func (c *RootController) CasRestLogin() {
+	username := c.Input().Get("username")
+	password := c.Input().Get("password")
+	if username == "" || password == "" {
+		c.sendCasRestResponse(http.StatusBadRequest, c.T("general:Missing parameter")+": username, password")
+		return
+	}
+
+	application := c.getCasRestApplication()
+	if application == nil {
+		return
+	}
+
+	user, err := object.CheckUserPassword(application.Organization, username, password, c.GetAcceptLanguage())
+	if err != nil {
+		c.sendCasRestResponse(http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	err = object.CheckLdapServiceAccount(user, c.GetAcceptLanguage())
+	if err != nil {
+		c.sendCasRestResponse(http.StatusForbidden, err.Error())
+		return
+	}
+
+	// the second factor can't be provided by the CAS REST protocol
+	if user.IsMfaEnabled() {
+		c.sendCasRestResponse(http.StatusUnauthorized, fmt.Sprintf("the user: %s has MFA enabled and can't sign in with the CAS REST protocol", user.GetId()))
+		return
+	}
+
+	allowed, err := object.CheckLoginPermission(user.GetId(), application)
+	if err != nil {
+		c.sendCasRestResponse(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !allowed {
+		c.sendCasRestResponse(http.StatusForbidden, c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	tgt, err := object.GenerateCasTgt(application, user.GetId())
+	if err != nil {
+		c.sendCasRestResponse(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.Ctx.Output.Header("Location", object.GetCasTgtUrl(c.Ctx.Request.Host, application, tgt))
+	c.sendCasRestResponse(http.StatusCreated, tgt)
+}
+
+// CasRestServiceTicket
+// @Title CasRestServiceTicket
+// @Tag CAS API
+// @Description CAS REST protocol, get a service ticket (ST) for the service with the ticket granting ticket (TGT)
+// @Param   organization    path    string  true        "The organization of the application"
+// @Param   application    path    string  true        "The name of the application"
+// @Param   tgt    path    string  true        "The ticket granting ticket"
+// @Param   service    formData    string  true        "The service URL"
+// @Success 200 {string} string The service ticket
+// @router /cas/:organization/:application/v1/tickets/:tgt [post]
+func (c *RootController) CasRestServiceTicket() {
+	tgt := c.Ctx.Input.Param(":tgt")
+	service := c.Input().Get("service")
+	if service == "" {
+		c.sendCasRestResponse(http.StatusBadRequest, c.T("general:Missing parameter")+": service")
+		return
+	}
+
+	application := c.getCasRestApplication()
+	if application == nil {
+		return
+	}
+
+	ticketGrantingTicket, err := object.GetCasTgt(application, tgt)
+	if err != nil {
+		c.sendCasRestResponse(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !strings.HasPrefix(tgt, "TGT-") || ticketGrantingTicket == nil {
+		c.sendCasRestResponse(http.StatusNotFound, fmt.Sprintf("Ticket %s not recognized", tgt))
+		return
+	}
+
+	err = object.CheckCasLogin(application, c.GetAcceptLanguage(), service)
+	if err != nil {
+		c.sendCasRestResponse(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	st, err := object.GenerateCasToken(ticketGrantingTicket.UserId, service)
+	if err != nil {
+		c.sendCasRestResponse(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.sendCasRestResponse(http.StatusOK, st)
+}
+
+// CasRestLogout
+// @Title CasRestLogout
+// @Tag CAS API
+// @Description CAS REST protocol, destroy the ticket granting ticket (TGT)
+// @Param   organization    path    string  true        "The organization of the application"
+// @Param   application    path    string  true        "The name of the application"
+// @Param   tgt    path    string  true        "The ticket granting ticket"
+// @Success 200 {string} string The destroyed ticket granting ticket
+// @router /cas/:organization/:application/v1/tickets/:tgt [delete]
+func (c *RootController) CasRestLogout() {
+	tgt := c.Ctx.Input.Param(":tgt")
+	if !strings.HasPrefix(tgt, "TGT-") {
+		c.sendCasRestResponse(http.StatusNotFound, fmt.Sprintf("Ticket %s not recognized", tgt))
+		return
+	}
+
+	application := c.getCasRestApplication()
+	if application == nil {
+		return
+	}
+
+	ticketGrantingTicket, err := object.GetCasTgt(application, tgt)
+	if err != nil {
+		c.sendCasRestResponse(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if ticketGrantingTicket == nil {
+		c.sendCasRestResponse(http.StatusNotFound, fmt.Sprintf("Ticket %s not recognized", tgt))
+		return
+	}
+
+	err = object.DeleteCasTgt(tgt)
+	if err != nil {
+		c.sendCasRestResponse(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.sendCasRestResponse(http.StatusOK, tgt)
+}
@@ -16,6 +16,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/casdoor/casdoor/object"
 	"github.com/casdoor/casdoor/util"
@@ -61,19 +62,6 @@ func (c *ApiController) GetLdapUsers() {
 	}
 	defer conn.Close()
 
-	//groupsMap, err := conn.GetLdapGroups(ldapServer.BaseDn)
-	//if err != nil {
-	//  c.ResponseError(err.Error())
-	//	return
-	//}
-
-	//for _, group := range groupsMap {
-	//	resp.Groups = append(resp.Groups, LdapRespGroup{
-	//		GroupId:   group.GidNumber,
-	//		GroupName: group.Cn,
-	//	})
-	//}
-
 	users, err := conn.GetLdapUsers(ldapServer)
 	if err != nil {
 		c.ResponseError(err.Error())
@@ -154,6 +142,16 @@ func (c *ApiController) AddLdap() {
 		return
 	}
 
+	if err = ldap.CheckAttributeMappings(); err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if err = ldap.CheckKerberos(); err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
 	if ok, err := object.CheckLdapExist(&ldap); err != nil {
 		c.ResponseError(err.Error())
 		return
@@ -192,6 +190,16 @@ func (c *ApiController) UpdateLdap() {
 		return
 	}
 
+	if err = ldap.CheckAttributeMappings(); err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if err = ldap.CheckKerberos(); err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
 	prevLdap, err := object.GetLdap(ldap.Id)
 	if err != nil {
 		c.ResponseError(err.Error())
@@ -276,3 +284,76 @@ func (c *ApiController) SyncLdapUsers() {
 		Failed: failed,
 	})
 }
+
+// SyncLdapGroups
+// @Title SyncLdapGroups
+// @Tag Account API
+// @Description sync ldap groups and the group membership of ldap users
+// @Param	id	query	string		true	"id"
+// @Success 200 {object} object.LdapGroupSyncResult The Response object
+// @router /sync-ldap-groups [post]
+func (c *ApiController) SyncLdapGroups() {
+	id := c.Input().Get("id")
+
+	_, ldapId := util.GetOwnerAndNameFromId(id)
+	ldapServer, err := object.GetLdap(ldapId)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+	if ldapServer == nil {
+		c.ResponseError(fmt.Sprintf(c.T("ldap:The LDAP: %s does not exist"), id))
+		return
+	}
+
+	conn, err := ldapServer.GetLdapConn()
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+	defer conn.Close()
+
+	users, err := conn.GetLdapUsers(ldapServer)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	groups, err := conn.GetLdapGroups(ldapServer)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	res, err := object.SyncLdapGroups(ldapServer, groups, users)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(res)
+}
+
+// GetLdapHealth
+// @Title GetLdapHealth
+// @Tag Account API
+// @Description check the connection to every host of the ldap server
+// @Param	id	query	string		true	"id"
+// @Success 200 {array} object.LdapHostHealth The Response object
+// @router /get-ldap-health [get]
+func (c *ApiController) GetLdapHealth() {
+	id := c.Input().Get("id")
+
+	_, ldapId := util.GetOwnerAndNameFromId(id)
+	ldapServer, err := object.GetLdap(ldapId)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+	if ldapServer == nil {
+		c.ResponseError(fmt.Sprintf(c.T("ldap:The LDAP: %s does not exist"), id))
+		return
+	}
+
+	c.ResponseOk(ldapServer.CheckLdapHealth())
+}
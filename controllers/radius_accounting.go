@@ -0,0 +1,156 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetRadiusAccountings
+// @Title GetRadiusAccountings
+// @Tag Radius Accounting API
+// @Description get RADIUS accounting sessions
+// @Param   owner     query    string  true        "The organization of the sessions"
+// @Param   user      query    string  false       "The username of the sessions"
+// @Param   isActive  query    string  false       "true for the sessions that aren't stopped, false for the stopped sessions"
+// @Success 200 {array} object.RadiusAccounting The Response object
+// @router /get-radius-accountings [get]
+func (c *ApiController) GetRadiusAccountings() {
+	owner := c.Input().Get("owner")
+	user := c.Input().Get("user")
+	isActive := c.Input().Get("isActive")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		ras, err := object.GetRadiusAccountings(owner, user, isActive)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(ras)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetRadiusAccountingCount(owner, field, value, user, isActive)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		ras, err := object.GetPaginationRadiusAccountings(owner, paginator.Offset(), limit, field, value, sortField, sortOrder, user, isActive)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(ras, paginator.Nums())
+	}
+}
+
+// GetRadiusAccounting
+// @Title GetRadiusAccounting
+// @Tag Radius Accounting API
+// @Description get RADIUS accounting session
+// @Param   id     query    string  true        "The id ( owner/name ) of the RADIUS accounting session"
+// @Success 200 {object} object.RadiusAccounting The Response object
+// @router /get-radius-accounting [get]
+func (c *ApiController) GetRadiusAccounting() {
+	id := c.Input().Get("id")
+
+	ra, err := object.GetRadiusAccounting(id)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(ra)
+}
+
+// getRadiusAccountingFromBody returns the stored session of the request body, the NAS of the stored session is the
+// target of the dynamic authorization rather than the one of the body
+func (c *ApiController) getRadiusAccountingFromBody() (*object.RadiusAccounting, bool) {
+	var ra object.RadiusAccounting
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &ra)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return nil, false
+	}
+
+	storedRa, err := object.GetRadiusAccounting(ra.GetId())
+	if err != nil {
+		c.ResponseError(err.Error())
+		return nil, false
+	}
+	if storedRa == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The RADIUS accounting session: %s doesn't exist"), ra.GetId()))
+		return nil, false
+	}
+	return storedRa, true
+}
+
+// DisconnectRadiusAccounting
+// @Title DisconnectRadiusAccounting
+// @Tag Radius Accounting API
+// @Description send a Disconnect-Request of RFC 5176 to the NAS of the RADIUS accounting session
+// @Param   body    body   object.RadiusAccounting  true        "The details of the RADIUS accounting session"
+// @Success 200 {object} controllers.Response The Response object
+// @router /disconnect-radius-accounting [post]
+func (c *ApiController) DisconnectRadiusAccounting() {
+	ra, ok := c.getRadiusAccountingFromBody()
+	if !ok {
+		return
+	}
+
+	err := object.DisconnectRadiusAccounting(ra)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk()
+}
+
+// ReauthorizeRadiusAccounting
+// @Title ReauthorizeRadiusAccounting
+// @Tag Radius Accounting API
+// @Description send a CoA-Request of RFC 5176 with the current reply attributes of the user to the NAS of the RADIUS accounting session
+// @Param   body    body   object.RadiusAccounting  true        "The details of the RADIUS accounting session"
+// @Success 200 {object} controllers.Response The Response object
+// @router /reauthorize-radius-accounting [post]
+func (c *ApiController) ReauthorizeRadiusAccounting() {
+	ra, ok := c.getRadiusAccountingFromBody()
+	if !ok {
+		return
+	}
+
+	err := object.ReauthorizeRadiusAccounting(ra)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk()
+}
@@ -15,12 +15,34 @@
 package controllers
 
 import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"html/template"
 	"net/http"
+	"net/url"
 
 	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
 )
 
+type SamlSpMetadataForm struct {
+	Metadata    string `json:"metadata"`
+	MetadataUrl string `json:"metadataUrl"`
+}
+
+var samlPostFormTemplate = template.Must(template.New("samlPostForm").Parse(`<!DOCTYPE html>
+<html>
+<body onload="document.forms[0].submit()">
+<form method="post" action="{{.Action}}">
+<input type="hidden" name="SAMLResponse" value="{{.SamlResponse}}"/>
+{{if .RelayState}}<input type="hidden" name="RelayState" value="{{.RelayState}}"/>{{end}}
+<noscript><input type="submit" value="Continue"/></noscript>
+</form>
+</body>
+</html>`))
+
 func (c *ApiController) GetSamlMeta() {
 	host := c.Ctx.Request.Host
 	paramApp := c.Input().Get("application")
@@ -64,3 +86,293 @@ func (c *ApiController) HandleSamlRedirect() {
 
 	c.Redirect(targetURL, http.StatusSeeOther)
 }
+
+// HandleSamlIdpInitiated
+// @Title HandleSamlIdpInitiated
+// @Tag Login API
+// @Description build an unsolicited SAML response for the signed-in user and post it to the application's ACS
+// @Param   owner    path    string  true        "The owner of the application"
+// @Param   application    path    string  true        "The name of the application"
+// @Param   RelayState    query    string  false        "The relay state passed to the service provider"
+// @Param   spEntityId    query    string  false        "The entity ID of the SAML service provider registered in the application"
+// @router /saml/idp-initiated/:owner/:application [get]
+func (c *ApiController) HandleSamlIdpInitiated() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	owner := c.Ctx.Input.Param(":owner")
+	applicationName := c.Ctx.Input.Param(":application")
+	relayState := c.Input().Get("RelayState")
+	spEntityId := c.Input().Get("spEntityId")
+
+	applicationId := util.GetId(owner, applicationName)
+	application, err := object.GetApplication(applicationId)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if application == nil {
+		c.ResponseError(fmt.Sprintf(c.T("saml:Application %s not found"), applicationId))
+		return
+	}
+
+	if user.IsForbidden {
+		c.ResponseError(c.T("check:The user is forbidden to sign in, please contact the administrator"))
+		return
+	}
+
+	allowed, err := object.CheckLoginPermission(user.GetId(), application)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+	if !allowed {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	samlResponse, acsUrl, samlParam, err := object.GetSamlIdpInitiatedResponse(application, user, spEntityId, c.Ctx.Request.Host)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if samlParam == object.SamlArtifactParam {
+		parameters := url.Values{}
+		parameters.Add(object.SamlArtifactParam, samlResponse)
+		if relayState != "" {
+			parameters.Add("RelayState", relayState)
+		}
+		c.Ctx.Redirect(http.StatusFound, fmt.Sprintf("%s?%s", acsUrl, parameters.Encode()))
+		return
+	}
+
+	var buf bytes.Buffer
+	err = samlPostFormTemplate.Execute(&buf, map[string]string{
+		"Action":       acsUrl,
+		"SamlResponse": samlResponse,
+		"RelayState":   relayState,
+	})
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Ctx.Output.Header("Content-Type", "text/html; charset=utf-8")
+	c.Ctx.Output.Body(buf.Bytes())
+}
+
+// HandleSamlArtifactResolve
+// @Title HandleSamlArtifactResolve
+// @Tag Login API
+// @Description resolve a SAML artifact into the SAML response it refers to, via the SOAP binding
+// @Param   body    body   string  true        "The SOAP envelope containing a samlp:ArtifactResolve"
+// @Success 200 {string} string The SOAP envelope containing a samlp:ArtifactResponse
+// @router /saml/artifact-resolve [post]
+func (c *ApiController) HandleSamlArtifactResolve() {
+	envelopRequest := struct {
+		XMLName xml.Name `xml:"Envelope"`
+		Body    struct {
+			XMLName xml.Name `xml:"Body"`
+			Content string   `xml:",innerxml"`
+		}
+	}{}
+
+	err := xml.Unmarshal(c.Ctx.Input.RequestBody, &envelopRequest)
+	if err != nil {
+		c.responseSoapFault("SOAP-ENV:Client", err.Error())
+		return
+	}
+
+	response, err := object.GetSamlArtifactResponse(envelopRequest.Body.Content, c.Ctx.Request.Host)
+	if err != nil {
+		c.responseSoapFault("SOAP-ENV:Client", err.Error())
+		return
+	}
+
+	c.responseSoapEnvelope(http.StatusOK, response)
+}
+
+// responseSoapFault responds a SOAP 1.1 Fault, the SOAP clients of the SAML SPs don't understand JSON errors
+func (c *ApiController) responseSoapFault(faultCode string, faultString string) {
+	fault := struct {
+		XMLName     xml.Name `xml:"SOAP-ENV:Fault"`
+		FaultCode   string   `xml:"faultcode"`
+		FaultString string   `xml:"faultstring"`
+	}{
+		FaultCode:   faultCode,
+		FaultString: faultString,
+	}
+
+	data, err := xml.Marshal(fault)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.responseSoapEnvelope(http.StatusInternalServerError, string(data))
+}
+
+func (c *ApiController) responseSoapEnvelope(status int, content string) {
+	envelopResponse := struct {
+		XMLName xml.Name `xml:"SOAP-ENV:Envelope"`
+		Xmlns   string   `xml:"xmlns:SOAP-ENV,attr"`
+		Body    struct {
+			XMLName xml.Name `xml:"SOAP-ENV:Body"`
+			Content string   `xml:",innerxml"`
+		}
+	}{}
+	envelopResponse.Xmlns = "http://schemas.xmlsoap.org/soap/envelope/"
+	envelopResponse.Body.Content = content
+
+	data, err := xml.Marshal(envelopResponse)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Ctx.Output.Header("Content-Type", "text/xml; charset=utf-8")
+	c.Ctx.Output.SetStatus(status)
+	c.Ctx.Output.Body(data)
+}
+
+// GetSamlSpMetadata
+// @Title GetSamlSpMetadata
+// @Tag Login API
+// @Description get the metadata of Casdoor as the SAML service provider of a SAML provider
+// @Param   provider    query    string  true        "The id ( owner/name ) of the SAML provider"
+// @Success 200 {string} string The SP metadata in XML
+// @router /saml/sp-metadata [get]
+func (c *ApiController) GetSamlSpMetadata() {
+	providerId := c.Input().Get("provider")
+	provider, err := object.GetProvider(providerId)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if provider == nil {
+		c.ResponseError(fmt.Sprintf(c.T("auth:The provider: %s does not exist"), providerId))
+		return
+	}
+
+	metadata, err := object.GetSamlSpMetadata(provider, c.Ctx.Request.Host)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Data["xml"] = metadata
+	c.ServeXML()
+}
+
+// HandleSamlSpLogout
+// @Title HandleSamlSpLogout
+// @Tag Login API
+// @Description the Single Logout endpoint of Casdoor as the SAML service provider, signs out the user on a LogoutRequest from the IdP
+// @Param   owner    path    string  true        "The owner of the provider"
+// @Param   provider    path    string  true        "The name of the provider"
+// @router /saml/slo/:owner/:provider [post]
+func (c *ApiController) HandleSamlSpLogout() {
+	owner := c.Ctx.Input.Param(":owner")
+	providerName := c.Ctx.Input.Param(":provider")
+	samlRequest := c.Input().Get("SAMLRequest")
+	relayState := c.Input().Get("RelayState")
+
+	if samlRequest == "" {
+		// a LogoutResponse of the IdP, nothing to do
+		c.Redirect("/", http.StatusFound)
+		return
+	}
+
+	providerId := util.GetId(owner, providerName)
+	provider, err := object.GetProvider(providerId)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if provider == nil {
+		c.ResponseError(fmt.Sprintf(c.T("auth:The provider: %s does not exist"), providerId))
+		return
+	}
+
+	nameId, form, err := object.HandleSamlLogoutRequest(provider, samlRequest, relayState, c.Ctx.Request.Host)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	// the IdP may send the LogoutRequest of another user through the browser, only the user of the NameID is signed out
+	user := c.GetSessionUsername()
+	if user != "" {
+		sessionUser, err := object.GetUser(user)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		if !object.IsSamlNameIdOfUser(sessionUser, provider, nameId) {
+			util.LogWarning(c.Ctx, "API: [%s] is not logged out by SAML IdP: %s, the NameID: %s doesn't match", user, provider.Name, nameId)
+			user = ""
+		}
+	}
+
+	if user != "" {
+		c.ClearUserSession()
+		c.ClearTokenSession()
+		userOwner, username := util.GetOwnerAndNameFromId(user)
+		_, err = object.DeleteSessionId(util.GetSessionId(userOwner, username, object.CasdoorApplication), c.Ctx.Input.CruSession.SessionID())
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		util.LogInfo(c.Ctx, "API: [%s] logged out by SAML IdP: %s, NameID: %s", user, provider.Name, nameId)
+	}
+
+	if form == nil {
+		c.Redirect("/", http.StatusFound)
+		return
+	}
+
+	c.Ctx.Output.Header("Content-Type", "text/html; charset=utf-8")
+	c.Ctx.Output.Body(form)
+}
+
+// ImportSamlSpMetadata
+// @Title ImportSamlSpMetadata
+// @Tag Application API
+// @Description parse the metadata XML or metadata URL of a SAML service provider into a SAML service provider registration
+// @Param   body    body   controllers.SamlSpMetadataForm  true        "The metadata XML or metadata URL"
+// @Success 200 {object} object.SamlServiceProvider The Response object
+// @router /import-saml-sp-metadata [post]
+func (c *ApiController) ImportSamlSpMetadata() {
+	var form SamlSpMetadataForm
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &form)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if form.Metadata == "" && form.MetadataUrl == "" {
+		c.ResponseError(c.T("general:Missing parameter") + ": metadata")
+		return
+	}
+
+	var sp *object.SamlServiceProvider
+	if form.Metadata != "" {
+		sp, err = object.ParseSamlSpMetadata([]byte(form.Metadata))
+	} else {
+		sp, err = object.GetSamlSpMetadataFromUrl(form.MetadataUrl)
+	}
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(sp)
+}
@@ -0,0 +1,195 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+var wsFedPostFormTemplate = template.Must(template.New("wsFedPostForm").Parse(`<!DOCTYPE html>
+<html>
+<body onload="document.forms[0].submit()">
+<form method="post" action="{{.Action}}">
+<input type="hidden" name="wa" value="wsignin1.0"/>
+<input type="hidden" name="wresult" value="{{.Result}}"/>
+{{if .Context}}<input type="hidden" name="wctx" value="{{.Context}}"/>{{end}}
+<noscript><input type="submit" value="Continue"/></noscript>
+</form>
+</body>
+</html>`))
+
+// HandleWsFed
+// @Title HandleWsFed
+// @Tag Login API
+// @Description the WS-Federation passive requestor endpoint of the application, handles wsignin1.0 and wsignout1.0
+// @Param   owner    path    string  true        "The owner of the application"
+// @Param   application    path    string  true        "The name of the application"
+// @Param   wa    query    string  true        "The action: wsignin1.0, wsignout1.0 or wsignoutcleanup1.0"
+// @Param   wtrealm    query    string  false        "The realm of the relying party"
+// @Param   wreply    query    string  false        "The URL to post the token or to redirect to after signing out"
+// @Param   wctx    query    string  false        "The context passed back to the relying party"
+// @router /wsfed/:owner/:application [get,post]
+func (c *ApiController) HandleWsFed() {
+	owner := c.Ctx.Input.Param(":owner")
+	applicationName := c.Ctx.Input.Param(":application")
+
+	applicationId := util.GetId(owner, applicationName)
+	application, err := object.GetApplication(applicationId)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if application == nil {
+		c.ResponseError(fmt.Sprintf(c.T("saml:Application %s not found"), applicationId))
+		return
+	}
+
+	switch wa := c.Input().Get("wa"); wa {
+	case object.WsFedSignIn:
+		c.handleWsFedSignIn(application)
+	case object.WsFedSignOut, object.WsFedSignOutCleanup:
+		c.handleWsFedSignOut(application)
+	default:
+		c.ResponseError(fmt.Sprintf("Unsupported WS-Federation action: %s", wa))
+	}
+}
+
+func (c *ApiController) handleWsFedSignIn(application *object.Application) {
+	realm := c.Input().Get("wtrealm")
+	reply := c.Input().Get("wreply")
+	wctx := c.Input().Get("wctx")
+
+	if c.GetSessionUsername() == "" {
+		parameters := url.Values{}
+		parameters.Add("wa", object.WsFedSignIn)
+		parameters.Add("wtrealm", realm)
+		if reply != "" {
+			parameters.Add("wreply", reply)
+		}
+		if wctx != "" {
+			parameters.Add("wctx", wctx)
+		}
+		c.Redirect(object.GetWsFedRedirectAddress(application, parameters.Encode(), c.Ctx.Request.Host), http.StatusFound)
+		return
+	}
+
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	if user.IsForbidden {
+		c.ResponseError(c.T("check:The user is forbidden to sign in, please contact the administrator"))
+		return
+	}
+
+	allowed, err := object.CheckLoginPermission(user.GetId(), application)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+	if !allowed {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	wresult, replyUrl, err := object.GetWsFedSignInResponse(application, user, realm, reply, c.Ctx.Request.Host)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	var buf bytes.Buffer
+	err = wsFedPostFormTemplate.Execute(&buf, map[string]string{
+		"Action":  replyUrl,
+		"Result":  wresult,
+		"Context": wctx,
+	})
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Ctx.Output.Header("Content-Type", "text/html; charset=utf-8")
+	c.Ctx.Output.Body(buf.Bytes())
+}
+
+func (c *ApiController) handleWsFedSignOut(application *object.Application) {
+	user := c.GetSessionUsername()
+	if user != "" {
+		c.ClearUserSession()
+		c.ClearTokenSession()
+		owner, username := util.GetOwnerAndNameFromId(user)
+		_, err := object.DeleteSessionId(util.GetSessionId(owner, username, object.CasdoorApplication), c.Ctx.Input.CruSession.SessionID())
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		err = object.SendCasLogoutRequests([]string{c.Ctx.Input.CruSession.SessionID()})
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		util.LogInfo(c.Ctx, "API: [%s] logged out by WS-Federation of application: %s", user, application.GetId())
+	}
+
+	replyUrl := object.GetWsFedSignOutReplyUrl(application, c.Input().Get("wreply"))
+	if replyUrl == "" {
+		c.ResponseOk()
+		return
+	}
+
+	c.Redirect(replyUrl, http.StatusFound)
+}
+
+// GetWsFedMetadata
+// @Title GetWsFedMetadata
+// @Tag Login API
+// @Description get the WS-Federation metadata of the application
+// @Param   application     query    string  true        "The id ( owner/name ) of the application"
+// @Success 200 {string} The federation metadata XML
+// @router /wsfed/metadata [get]
+func (c *ApiController) GetWsFedMetadata() {
+	applicationId := c.Input().Get("application")
+	application, err := object.GetApplication(applicationId)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if application == nil {
+		c.ResponseError(fmt.Sprintf(c.T("saml:Application %s not found"), applicationId))
+		return
+	}
+
+	metadata, err := object.GetWsFedMetadata(application, c.Ctx.Request.Host)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Ctx.Output.Header("Content-Type", "application/xml; charset=utf-8")
+	c.Ctx.Output.Body([]byte(metadata))
+}
@@ -0,0 +1,32 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cred
+
+import (
+	"encoding/hex"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/md4"
+)
+
+// GetNtHash returns the NT hash of the password, i.e. the MD4 digest of the UTF-16LE password. It's not a password
+// manager because the hash is unsalted, it's only stored in addition to the password for MS-CHAPv2 of RADIUS.
+func GetNtHash(password string) string {
+	hash := md4.New()
+	for _, c := range utf16.Encode([]rune(password)) {
+		hash.Write([]byte{byte(c), byte(c >> 8)})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,28 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cred
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNtHash(t *testing.T) {
+	// the test vector of RFC 2759, 9.2
+	assert.Equal(t, "44ebba8d5312b8d611474411f56989ae", GetNtHash("clientPass"))
+	assert.Equal(t, "8846f7eaee8fb117ad06bdd830b7586c", GetNtHash("password"))
+	assert.Equal(t, "31d6cfe0d16ae931b73c59d7e0c089c0", GetNtHash(""))
+}
@@ -56,6 +56,8 @@ type AuthForm struct {
 	RelayState   string `json:"relayState"`
 	SamlRequest  string `json:"samlRequest"`
 	SamlResponse string `json:"samlResponse"`
+	SignedQuery  string `json:"signedQuery"`
+	Signature    string `json:"signature"`
 
 	CaptchaType  string `json:"captchaType"`
 	CaptchaToken string `json:"captchaToken"`
@@ -0,0 +1,377 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+	ldap "github.com/casdoor/ldapserver"
+	"github.com/lor00x/goldap/message"
+)
+
+// groups of an organization are published under "ou=groups,ou=<organization>,..."
+const ldapGroupsOu = "groups"
+
+var (
+	ldapUserObjectClasses  = []string{"top", "person", "organizationalPerson", "inetOrgPerson", "posixAccount"}
+	ldapGroupObjectClasses = []string{"top", "groupOfNames", "posixGroup"}
+)
+
+// the attributes of group entries, in the order they are returned
+var ldapGroupAttributes = []string{"objectClass", "cn", "displayName", "gidNumber", "member", "memberUid"}
+
+// LdapGroup is a group entry of the LDAP server, attribute names are lowercase
+type LdapGroup struct {
+	Dn         string
+	Attributes map[string][]string
+}
+
+// getOrgBaseDn returns the part of the DN starting at the organization, e.g.
+// "cn=admins,ou=groups,ou=built-in,dc=example,dc=com" -> "ou=built-in,dc=example,dc=com"
+func getOrgBaseDn(dn string) string {
+	rdns := strings.Split(dn, ",")
+	for i, rdn := range rdns {
+		key, value, ok := strings.Cut(strings.TrimSpace(rdn), "=")
+		if ok && strings.EqualFold(key, "ou") && !strings.EqualFold(value, ldapGroupsOu) {
+			return strings.Join(rdns[i:], ",")
+		}
+	}
+	return dn
+}
+
+// getOrgFromDn returns the organization of the DN, "" if the DN has no organization
+func getOrgFromDn(dn string) string {
+	orgBaseDn := getOrgBaseDn(dn)
+	key, value, ok := strings.Cut(strings.TrimSpace(strings.Split(orgBaseDn, ",")[0]), "=")
+	if !ok || !strings.EqualFold(key, "ou") {
+		return ""
+	}
+	return value
+}
+
+func getUserDn(user *object.User, baseDn string) string {
+	return fmt.Sprintf("uid=%s,cn=%s,%s", user.Id, user.Name, baseDn)
+}
+
+func getGroupDn(groupName string, orgBaseDn string) string {
+	return fmt.Sprintf("cn=%s,ou=%s,%s", groupName, ldapGroupsOu, orgBaseDn)
+}
+
+// getMemberOfValue returns the memberOf value of a group of the user, the group ID ("org/name")
+// by default, or the group DN if "ldapMemberOfAsDn" is enabled
+func getMemberOfValue(groupId string, orgBaseDn string) string {
+	if !conf.GetConfigBool("ldapMemberOfAsDn") {
+		return groupId
+	}
+
+	_, groupName, err := util.GetOwnerAndNameFromIdWithError(groupId)
+	if err != nil {
+		groupName = groupId
+	}
+	return getGroupDn(groupName, orgBaseDn)
+}
+
+// getGroupIdFromMemberOf accepts a group DN as well as a group ID ("org/name") and returns the group ID
+func getGroupIdFromMemberOf(value string) string {
+	if !strings.Contains(value, "=") {
+		return value
+	}
+
+	key, name, _ := strings.Cut(strings.TrimSpace(strings.Split(value, ",")[0]), "=")
+	if !strings.EqualFold(key, "cn") {
+		return value
+	}
+	return util.GetId(getOrgFromDn(value), name)
+}
+
+// getLdapSearchTypes returns whether the search returns users and whether it returns groups. The entries under
+// "ou=groups" are groups, the other searches return the users and also the groups if the filter asks for group
+// object classes, e.g. (|(objectClass=person)(objectClass=groupOfNames)) returns both.
+func getLdapSearchTypes(r message.SearchRequest) (searchUsers bool, searchGroups bool) {
+	for _, rdn := range strings.Split(string(r.BaseObject()), ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(rdn), "=")
+		if ok && strings.EqualFold(key, "ou") && strings.EqualFold(value, ldapGroupsOu) {
+			return false, true
+		}
+	}
+
+	if !hasObjectClassInFilter(r.Filter(), ldapGroupObjectClasses) {
+		return true, false
+	}
+	return hasObjectClassInFilter(r.Filter(), ldapUserObjectClasses), true
+}
+
+func hasObjectClassInFilter(filter interface{}, objectClasses []string) bool {
+	switch f := filter.(type) {
+	case message.FilterAnd:
+		for _, v := range f {
+			if hasObjectClassInFilter(v, objectClasses) {
+				return true
+			}
+		}
+	case message.FilterOr:
+		for _, v := range f {
+			if hasObjectClassInFilter(v, objectClasses) {
+				return true
+			}
+		}
+	case message.FilterEqualityMatch:
+		if strings.EqualFold(string(f.AttributeDesc()), "objectClass") {
+			return containsFold(objectClasses, string(f.AssertionValue()))
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildLdapGroups returns the group entries of the groups of one organization. The member attribute lists
+// the direct members and the subgroups (nested groupOfNames), memberUid lists the users of the group
+// and all its subgroups since posixGroup doesn't support nesting.
+func buildLdapGroups(groups []*object.Group, getGroupUsers func(groupId string) ([]*object.User, error), orgBaseDn string) ([]*LdapGroup, error) {
+	groupUsers := map[string][]*object.User{}
+	children := map[string][]*object.Group{}
+	for _, group := range groups {
+		users, err := getGroupUsers(group.GetId())
+		if err != nil {
+			return nil, err
+		}
+		groupUsers[group.Name] = users
+
+		if !group.IsTopGroup {
+			children[group.ParentId] = append(children[group.ParentId], group)
+		}
+	}
+
+	var getNestedUserNames func(groupName string, visited map[string]bool) []string
+	getNestedUserNames = func(groupName string, visited map[string]bool) []string {
+		if visited[groupName] {
+			return nil
+		}
+		visited[groupName] = true
+
+		names := []string{}
+		for _, user := range groupUsers[groupName] {
+			names = append(names, user.Name)
+		}
+		for _, child := range children[groupName] {
+			names = append(names, getNestedUserNames(child.Name, visited)...)
+		}
+		return names
+	}
+
+	res := []*LdapGroup{}
+	for _, group := range groups {
+		members := []string{}
+		for _, user := range groupUsers[group.Name] {
+			members = append(members, getUserDn(user, orgBaseDn))
+		}
+		for _, child := range children[group.Name] {
+			members = append(members, getGroupDn(child.Name, orgBaseDn))
+		}
+
+		memberUids := []string{}
+		for _, name := range getNestedUserNames(group.Name, map[string]bool{}) {
+			if !util.InSlice(memberUids, name) {
+				memberUids = append(memberUids, name)
+			}
+		}
+
+		attributes := map[string][]string{
+			"objectclass": ldapGroupObjectClasses,
+			"cn":          {group.Name},
+			"gidnumber":   {strconv.FormatUint(uint64(hash(group.GetId())), 10)},
+			"member":      members,
+			"memberuid":   memberUids,
+		}
+		if group.DisplayName != "" {
+			attributes["displayname"] = []string{group.DisplayName}
+		}
+
+		res = append(res, &LdapGroup{
+			Dn:         getGroupDn(group.Name, orgBaseDn),
+			Attributes: attributes,
+		})
+	}
+	return res, nil
+}
+
+// matchLdapFilter evaluates the LDAP filter against the attributes of an entry, attribute names are lowercase
+func matchLdapFilter(filter interface{}, attributes map[string][]string) bool {
+	switch f := filter.(type) {
+	case message.FilterAnd:
+		for _, v := range f {
+			if !matchLdapFilter(v, attributes) {
+				return false
+			}
+		}
+		return true
+	case message.FilterOr:
+		for _, v := range f {
+			if matchLdapFilter(v, attributes) {
+				return true
+			}
+		}
+		return false
+	case message.FilterNot:
+		return !matchLdapFilter(f.Filter, attributes)
+	case message.FilterEqualityMatch:
+		return containsFold(attributes[strings.ToLower(string(f.AttributeDesc()))], string(f.AssertionValue()))
+	case message.FilterApproxMatch:
+		return containsFold(attributes[strings.ToLower(string(f.AttributeDesc()))], string(f.AssertionValue()))
+	case message.FilterPresent:
+		return len(attributes[strings.ToLower(string(f))]) != 0
+	case message.FilterGreaterOrEqual:
+		for _, value := range attributes[strings.ToLower(string(f.AttributeDesc()))] {
+			if compareLdapValues(value, string(f.AssertionValue())) >= 0 {
+				return true
+			}
+		}
+		return false
+	case message.FilterLessOrEqual:
+		for _, value := range attributes[strings.ToLower(string(f.AttributeDesc()))] {
+			if compareLdapValues(value, string(f.AssertionValue())) <= 0 {
+				return true
+			}
+		}
+		return false
+	case message.FilterSubstrings:
+		for _, value := range attributes[strings.ToLower(string(f.Type_()))] {
+			if matchLdapSubstrings(strings.ToLower(value), f.Substrings()) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// compareLdapValues compares the values as integers if both are integers, otherwise as strings
+func compareLdapValues(a string, b string) int {
+	x, err1 := strconv.ParseInt(a, 10, 64)
+	y, err2 := strconv.ParseInt(b, 10, 64)
+	if err1 == nil && err2 == nil {
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// matchLdapSubstrings matches the lowercased value with the substrings, each substring is lowercased once since
+// lowercasing can change its length, e.g. the Kelvin sign
+func matchLdapSubstrings(value string, substrings []message.Substring) bool {
+	for _, substring := range substrings {
+		switch s := substring.(type) {
+		case message.SubstringInitial:
+			ls := strings.ToLower(string(s))
+			if !strings.HasPrefix(value, ls) {
+				return false
+			}
+			value = value[len(ls):]
+		case message.SubstringAny:
+			ls := strings.ToLower(string(s))
+			i := strings.Index(value, ls)
+			if i == -1 {
+				return false
+			}
+			value = value[i+len(ls):]
+		case message.SubstringFinal:
+			if !strings.HasSuffix(value, strings.ToLower(string(s))) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func GetFilteredGroups(m *ldap.Message) ([]*LdapGroup, int) {
+	r := m.GetSearchRequest()
+	baseDn := string(r.BaseObject())
+
+	org := getOrgFromDn(baseDn)
+	if org == "" {
+		return nil, ldap.LDAPResultInvalidDNSyntax
+	}
+
+	if !m.Client.IsGlobalAdmin && org != m.Client.OrgName {
+		return nil, ldap.LDAPResultInsufficientAccessRights
+	}
+
+	groups, err := object.GetGroups(org)
+	if err != nil {
+		log.Printf("GetGroups() error: %s", err.Error())
+		return nil, ldap.LDAPResultOperationsError
+	}
+
+	ldapGroups, err := buildLdapGroups(groups, object.GetGroupUsers, getOrgBaseDn(baseDn))
+	if err != nil {
+		log.Printf("buildLdapGroups() error: %s", err.Error())
+		return nil, ldap.LDAPResultOperationsError
+	}
+
+	filteredGroups := []*LdapGroup{}
+	for _, group := range ldapGroups {
+		// a search based on a group entry only returns that group
+		if strings.HasPrefix(strings.ToLower(baseDn), "cn=") && !strings.EqualFold(group.Dn, baseDn) {
+			continue
+		}
+
+		if matchLdapFilter(r.Filter(), group.Attributes) {
+			filteredGroups = append(filteredGroups, group)
+		}
+	}
+
+	// the pages of groups are ordered by DN
+	sort.Slice(filteredGroups, func(i, j int) bool {
+		return filteredGroups[i].Dn < filteredGroups[j].Dn
+	})
+	return filteredGroups, ldap.LDAPResultSuccess
+}
+
+func (group *LdapGroup) getSearchResultEntry() message.SearchResultEntry {
+	e := ldap.NewSearchResultEntry(group.Dn)
+	for _, attribute := range ldapGroupAttributes {
+		values := group.Attributes[strings.ToLower(attribute)]
+		if len(values) == 0 {
+			continue
+		}
+
+		attributeValues := []message.AttributeValue{}
+		for _, value := range values {
+			attributeValues = append(attributeValues, message.AttributeValue(value))
+		}
+		e.AddAttribute(message.AttributeDescription(attribute), attributeValues...)
+	}
+	return e
+}
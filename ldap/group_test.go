@@ -0,0 +1,121 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"testing"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/lor00x/goldap/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupDn(t *testing.T) {
+	assert.Equal(t, "ou=built-in,dc=example,dc=com", getOrgBaseDn("cn=admins,ou=groups,ou=built-in,dc=example,dc=com"))
+	assert.Equal(t, "built-in", getOrgFromDn("ou=groups,ou=built-in,dc=example,dc=com"))
+	assert.Equal(t, "", getOrgFromDn("dc=example,dc=com"))
+
+	assert.Equal(t, "built-in/admins", getGroupIdFromMemberOf("cn=admins,ou=groups,ou=built-in,dc=example,dc=com"))
+	assert.Equal(t, "built-in/admins", getGroupIdFromMemberOf("built-in/admins"))
+
+	// memberOf keeps returning the group ID unless "ldapMemberOfAsDn" is enabled
+	assert.Equal(t, "built-in/admins", getMemberOfValue("built-in/admins", "ou=built-in,dc=example,dc=com"))
+}
+
+func TestGetLdapSearchTypes(t *testing.T) {
+	scenarios := []struct {
+		filter       string
+		searchUsers  bool
+		searchGroups bool
+	}{
+		{"(objectClass=*)", true, false},
+		{"(&(objectClass=person)(uid=alice))", true, false},
+		{"(objectClass=groupOfNames)", false, true},
+		{"(|(objectClass=person)(objectClass=groupOfNames))", true, true},
+	}
+
+	for _, scenario := range scenarios {
+		searchRequest, err := buildLdapSearchRequest(scenario.filter)
+		assert.Nil(t, err)
+		m, err := message.ReadLDAPMessage(message.NewBytes(0, searchRequest.Bytes()))
+		assert.Nil(t, err)
+
+		searchUsers, searchGroups := getLdapSearchTypes(m.ProtocolOp().(message.SearchRequest))
+		assert.Equal(t, scenario.searchUsers, searchUsers, scenario.filter)
+		assert.Equal(t, scenario.searchGroups, searchGroups, scenario.filter)
+	}
+}
+
+func TestBuildLdapGroups(t *testing.T) {
+	groups := []*object.Group{
+		{Owner: "built-in", Name: "engineering", DisplayName: "Engineering", ParentId: "built-in", IsTopGroup: true},
+		{Owner: "built-in", Name: "backend", ParentId: "engineering"},
+	}
+	groupUsers := map[string][]*object.User{
+		"built-in/engineering": {{Owner: "built-in", Name: "alice", Id: "1"}},
+		"built-in/backend":     {{Owner: "built-in", Name: "bob", Id: "2"}},
+	}
+	getGroupUsers := func(groupId string) ([]*object.User, error) {
+		return groupUsers[groupId], nil
+	}
+
+	ldapGroups, err := buildLdapGroups(groups, getGroupUsers, "ou=built-in,dc=example,dc=com")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(ldapGroups))
+
+	engineering := ldapGroups[0]
+	assert.Equal(t, "cn=engineering,ou=groups,ou=built-in,dc=example,dc=com", engineering.Dn)
+	assert.Equal(t, []string{
+		"uid=1,cn=alice,ou=built-in,dc=example,dc=com",
+		"cn=backend,ou=groups,ou=built-in,dc=example,dc=com",
+	}, engineering.Attributes["member"])
+	assert.Equal(t, []string{"alice", "bob"}, engineering.Attributes["memberuid"])
+	assert.Equal(t, []string{"bob"}, ldapGroups[1].Attributes["memberuid"])
+
+	scenarios := []struct {
+		filter   string
+		expected []bool
+	}{
+		{"(objectClass=posixGroup)", []bool{true, true}},
+		{"(&(objectClass=groupOfNames)(cn=backend))", []bool{false, true}},
+		{"(memberUid=alice)", []bool{true, false}},
+		{"(member=uid=2,cn=bob,ou=built-in,dc=example,dc=com)", []bool{false, true}},
+		{"(|(cn=eng*)(displayName=none))", []bool{true, false}},
+		{"(!(memberUid=bob))", []bool{false, false}},
+	}
+
+	for _, scenario := range scenarios {
+		searchRequest, err := buildLdapSearchRequest(scenario.filter)
+		assert.Nil(t, err)
+		m, err := message.ReadLDAPMessage(message.NewBytes(0, searchRequest.Bytes()))
+		assert.Nil(t, err)
+		req := m.ProtocolOp().(message.SearchRequest)
+
+		for i, ldapGroup := range ldapGroups {
+			assert.Equal(t, scenario.expected[i], matchLdapFilter(req.Filter(), ldapGroup.Attributes), scenario.filter)
+		}
+	}
+}
+
+func TestMatchLdapSubstrings(t *testing.T) {
+	substrings := []message.Substring{message.SubstringInitial("Dev"), message.SubstringAny("OP"), message.SubstringFinal("s")}
+	assert.True(t, matchLdapSubstrings("devops", substrings))
+	assert.False(t, matchLdapSubstrings("devices", substrings))
+
+	// the Kelvin sign "K" of 3 bytes is lowercased to "k" of 1 byte
+	assert.True(t, matchLdapSubstrings("k", []message.Substring{message.SubstringInitial("K")}))
+	assert.True(t, matchLdapSubstrings("kilo-k", []message.Substring{message.SubstringAny("K"), message.SubstringAny("K")}))
+	assert.False(t, matchLdapSubstrings("kilo", []message.Substring{message.SubstringInitial("K"), message.SubstringAny("K")}))
+}
@@ -0,0 +1,327 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+	ldap "github.com/casdoor/ldapserver"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/lor00x/goldap/message"
+)
+
+const ldapUserPasswordAttr = "userPassword"
+
+// the attributes that users (or their admins) can modify over LDAP, and the user fields they are stored in
+var ldapModifiableAttributes = map[string]string{
+	"displayname":       "display_name",
+	"givenname":         "first_name",
+	"sn":                "last_name",
+	"description":       "bio",
+	"preferredlanguage": "language",
+}
+
+// passwordModifyRequest is the request value of the Password Modify extended operation, see RFC 3062
+//
+//	PasswdModifyRequestValue ::= SEQUENCE {
+//	  userIdentity    [0]  OCTET STRING OPTIONAL
+//	  oldPasswd       [1]  OCTET STRING OPTIONAL
+//	  newPasswd       [2]  OCTET STRING OPTIONAL }
+type passwordModifyRequest struct {
+	UserIdentity string
+	OldPassword  string
+	NewPassword  string
+}
+
+func parsePasswordModifyRequest(value []byte) (*passwordModifyRequest, error) {
+	req := &passwordModifyRequest{}
+	if len(value) == 0 {
+		return req, nil
+	}
+
+	packet, err := ber.DecodePacketErr(value)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, child := range packet.Children {
+		if child.ClassType != ber.ClassContext {
+			return nil, fmt.Errorf("unexpected element in the password modify request: %v", child.Tag)
+		}
+
+		switch child.Tag {
+		case 0:
+			req.UserIdentity = child.Data.String()
+		case 1:
+			req.OldPassword = child.Data.String()
+		case 2:
+			req.NewPassword = child.Data.String()
+		default:
+			return nil, fmt.Errorf("unexpected element in the password modify request: %v", child.Tag)
+		}
+	}
+	return req, nil
+}
+
+// getTargetUser returns the user identified by the DN (the bound user if dn is empty),
+// the bound user must be the user itself or an admin of the user
+func getTargetUser(m *ldap.Message, dn string) (*object.User, bool, int, string) {
+	if isLdapServiceAccountOf(m, m.Client.OrgName) {
+		return nil, false, ldap.LDAPResultInsufficientAccessRights, "LDAP service accounts are bind-only"
+	}
+
+	requestUserId := util.GetId(m.Client.OrgName, m.Client.UserName)
+	userId := requestUserId
+	if dn != "" {
+		name, org, err := getNameAndOrgFromDN(dn)
+		if err != nil {
+			return nil, false, ldap.LDAPResultInvalidDNSyntax, err.Error()
+		}
+		userId = util.GetId(org, name)
+	}
+
+	user, err := object.GetUser(userId)
+	if err != nil {
+		return nil, false, ldap.LDAPResultOperationsError, err.Error()
+	}
+	if user == nil {
+		return nil, false, ldap.LDAPResultNoSuchObject, fmt.Sprintf("the user: %s doesn't exist", userId)
+	}
+
+	hasPermission, err := object.CheckUserPermission(requestUserId, userId, true, "en")
+	if !hasPermission {
+		msg := "insufficient access rights"
+		if err != nil {
+			msg = err.Error()
+		}
+		return nil, false, ldap.LDAPResultInsufficientAccessRights, msg
+	}
+
+	isSelf := requestUserId == userId
+	return user, isSelf, ldap.LDAPResultSuccess, ""
+}
+
+// isSecureConn returns true if the connection is LDAPS or was upgraded with StartTLS
+func isSecureConn(conn net.Conn) bool {
+	if c, ok := conn.(*controlConn); ok {
+		conn = c.Conn
+	}
+	_, ok := conn.(*tls.Conn)
+	return ok
+}
+
+// setUserPassword applies the organization's password policy and credential manager to the new password,
+// the old password is checked if checkOldPassword is true
+func setUserPassword(user *object.User, oldPassword string, newPassword string, checkOldPassword bool) (int, string) {
+	if strings.Contains(newPassword, " ") {
+		return ldap.LDAPResultConstraintViolation, "New password cannot contain blank space."
+	}
+
+	if checkOldPassword {
+		var err error
+		if user.Ldap == "" {
+			err = object.CheckPassword(user, oldPassword, "en")
+		} else {
+			err = object.CheckLdapUserPassword(user, oldPassword, "en")
+		}
+		if err != nil {
+			return ldap.LDAPResultInvalidCredentials, err.Error()
+		}
+	}
+
+	msg := object.CheckPasswordComplexity(user, newPassword)
+	if msg != "" {
+		return ldap.LDAPResultConstraintViolation, msg
+	}
+
+	if user.Ldap != "" {
+		err := object.ResetLdapPassword(user, oldPassword, newPassword, "en")
+		if err != nil {
+			return ldap.LDAPResultOperationsError, err.Error()
+		}
+		return ldap.LDAPResultSuccess, ""
+	}
+
+	user.Password = newPassword
+	_, err := object.SetUserField(user, "password", newPassword)
+	if err != nil {
+		return ldap.LDAPResultOperationsError, err.Error()
+	}
+
+	_, err = object.SetUserField(user, "last_change_password_time", util.GetCurrentTime())
+	if err != nil {
+		return ldap.LDAPResultOperationsError, err.Error()
+	}
+
+	if user.NeedUpdatePassword {
+		user.NeedUpdatePassword = false
+		_, err = object.UpdateUser(user.GetId(), user, []string{"need_update_password"}, false)
+		if err != nil {
+			return ldap.LDAPResultOperationsError, err.Error()
+		}
+	}
+
+	return ldap.LDAPResultSuccess, ""
+}
+
+func handlePasswordModify(w ldap.ResponseWriter, m *ldap.Message) {
+	res := ldap.NewExtendedResponse(ldap.LDAPResultSuccess)
+	if !m.Client.IsAuthenticated {
+		res.SetResultCode(ldap.LDAPResultUnwillingToPerform)
+		res.SetDiagnosticMessage("please bind before changing the password")
+		w.Write(res)
+		return
+	}
+
+	if !isSecureConn(m.Client.GetConn()) {
+		res.SetResultCode(ldap.LDAPResultConfidentialityRequired)
+		res.SetDiagnosticMessage("passwords can only be changed over LDAPS or StartTLS")
+		w.Write(res)
+		return
+	}
+
+	r := m.GetExtendedRequest()
+	var value []byte
+	if r.RequestValue() != nil {
+		value = r.RequestValue().Bytes()
+	}
+
+	req, err := parsePasswordModifyRequest(value)
+	if err != nil {
+		res.SetResultCode(ldap.LDAPResultProtocolError)
+		res.SetDiagnosticMessage(err.Error())
+		w.Write(res)
+		return
+	}
+
+	if req.NewPassword == "" {
+		res.SetResultCode(ldap.LDAPResultUnwillingToPerform)
+		res.SetDiagnosticMessage("the new password is required, generating passwords is not supported")
+		w.Write(res)
+		return
+	}
+
+	user, isSelf, code, msg := getTargetUser(m, req.UserIdentity)
+	if code != ldap.LDAPResultSuccess {
+		res.SetResultCode(code)
+		res.SetDiagnosticMessage(msg)
+		w.Write(res)
+		return
+	}
+
+	// users must provide their old password, admins can reset the passwords of their users
+	code, msg = setUserPassword(user, req.OldPassword, req.NewPassword, isSelf || req.OldPassword != "")
+	if code != ldap.LDAPResultSuccess {
+		log.Printf("Password modify failed User=%s, ErrMsg=%s", user.GetId(), msg)
+		res.SetResultCode(code)
+		res.SetDiagnosticMessage(msg)
+	}
+	w.Write(res)
+}
+
+// newModifyResponse returns a ModifyResponse with the diagnostic message, which ModifyResponse has no setter for
+func newModifyResponse(code int, diagnosticMessage string) message.ModifyResponse {
+	res := ldap.NewResponse(code)
+	res.SetDiagnosticMessage(diagnosticMessage)
+	return message.ModifyResponse(res)
+}
+
+func handleModify(w ldap.ResponseWriter, m *ldap.Message) {
+	if !m.Client.IsAuthenticated {
+		w.Write(newModifyResponse(ldap.LDAPResultUnwillingToPerform, ""))
+		return
+	}
+
+	r := m.GetModifyRequest()
+	user, isSelf, code, msg := getTargetUser(m, string(r.Object()))
+	if code != ldap.LDAPResultSuccess {
+		w.Write(newModifyResponse(code, msg))
+		return
+	}
+
+	// validate all the changes before applying any of them
+	fields := map[string]string{}
+	oldPassword, newPassword := "", ""
+	for _, change := range r.Changes() {
+		attribute := string(change.Modification().Type_())
+		values := change.Modification().Vals()
+		operation := int(change.Operation())
+
+		if strings.EqualFold(attribute, ldapUserPasswordAttr) {
+			// a password change is either a replace or the deletion of the old value followed by the new value
+			switch {
+			case operation == message.ModifyRequestChangeOperationDelete && len(values) == 1:
+				oldPassword = string(values[0])
+			case operation != message.ModifyRequestChangeOperationDelete && len(values) == 1:
+				newPassword = string(values[0])
+			default:
+				w.Write(newModifyResponse(ldap.LDAPResultConstraintViolation, "userPassword must have exactly one value"))
+				return
+			}
+			continue
+		}
+
+		field, ok := ldapModifiableAttributes[strings.ToLower(attribute)]
+		if !ok {
+			w.Write(newModifyResponse(ldap.LDAPResultUnwillingToPerform, fmt.Sprintf("the attribute: %s can't be modified", attribute)))
+			return
+		}
+
+		if operation == message.ModifyRequestChangeOperationDelete {
+			fields[field] = ""
+		} else if len(values) == 1 {
+			fields[field] = string(values[0])
+		} else {
+			w.Write(newModifyResponse(ldap.LDAPResultConstraintViolation, fmt.Sprintf("the attribute: %s must have exactly one value", attribute)))
+			return
+		}
+	}
+
+	if oldPassword != "" && newPassword == "" {
+		w.Write(newModifyResponse(ldap.LDAPResultConstraintViolation, "the new userPassword is missing"))
+		return
+	}
+
+	if newPassword != "" {
+		if !isSecureConn(m.Client.GetConn()) {
+			w.Write(newModifyResponse(ldap.LDAPResultConfidentialityRequired, "userPassword can only be modified over LDAPS or StartTLS"))
+			return
+		}
+
+		// users must provide their old password by deleting the old value, admins can reset the passwords of their users
+		code, msg = setUserPassword(user, oldPassword, newPassword, isSelf || oldPassword != "")
+		if code != ldap.LDAPResultSuccess {
+			log.Printf("Modify userPassword failed User=%s, ErrMsg=%s", user.GetId(), msg)
+			w.Write(newModifyResponse(code, msg))
+			return
+		}
+	}
+
+	for field, value := range fields {
+		_, err := object.SetUserField(user, field, value)
+		if err != nil {
+			w.Write(newModifyResponse(ldap.LDAPResultOperationsError, err.Error()))
+			return
+		}
+	}
+
+	w.Write(newModifyResponse(ldap.LDAPResultSuccess, ""))
+}
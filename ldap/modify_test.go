@@ -0,0 +1,62 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"crypto/tls"
+	"net"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePasswordModifyRequest(t *testing.T) {
+	// encode the request value the same way go-ldap clients do
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Password Modify Request")
+	packet.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 0, "uid=1,cn=alice,ou=built-in,dc=example,dc=com", "User Identity"))
+	packet.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 1, "old", "Old Password"))
+	packet.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 2, "new", "New Password"))
+
+	req, err := parsePasswordModifyRequest(packet.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, "uid=1,cn=alice,ou=built-in,dc=example,dc=com", req.UserIdentity)
+	assert.Equal(t, "old", req.OldPassword)
+	assert.Equal(t, "new", req.NewPassword)
+
+	name, org, err := getNameAndOrgFromDN(req.UserIdentity)
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", name)
+	assert.Equal(t, "built-in", org)
+
+	// a request without value changes the password of the bound user
+	req, err = parsePasswordModifyRequest(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "", req.UserIdentity)
+
+	_, err = parsePasswordModifyRequest([]byte{0x30, 0x03, 0x04, 0x01, 0x61})
+	assert.NotNil(t, err)
+}
+
+func TestIsSecureConn(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	assert.False(t, isSecureConn(server))
+	assert.False(t, isSecureConn(newControlConn(server)))
+	assert.True(t, isSecureConn(tls.Server(server, &tls.Config{})))
+	assert.True(t, isSecureConn(newControlConn(tls.Server(server, &tls.Config{}))))
+}
@@ -0,0 +1,327 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	ldap "github.com/casdoor/ldapserver"
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// the Simple Paged Results control, see RFC 2696
+const ldapPagedResultsControlOid = "1.2.840.113556.1.4.319"
+
+const ldapSearchResultDoneTag = 5
+
+// searchPage is the range of entries returned by a search, limited by the sizeLimit of the request
+// and by the page requested with the Simple Paged Results control
+type searchPage struct {
+	isPaged  bool
+	cookie   searchCookie // the position after the previous page
+	next     searchCookie // the position after the entries returned so far
+	limit    int          // -1 means no limit
+	isCapped bool         // the page was shortened to honor the sizeLimit
+	deadline time.Time
+}
+
+// searchCookie is the cookie of the Simple Paged Results control. It refers to the last entry returned
+// instead of an offset, so that the entries added or deleted between the pages don't shift the pages.
+type searchCookie struct {
+	Count int    `json:"count"` // the number of entries returned by the previous pages, for the sizeLimit
+	Type  string `json:"type"`  // the type of the last entry, ldapEntryTypeUser or ldapEntryTypeGroup
+	Key   string `json:"key"`   // the ID of the last user, or the DN of the last group
+}
+
+const (
+	ldapEntryTypeUser  = "user"
+	ldapEntryTypeGroup = "group"
+)
+
+func (cookie searchCookie) encode() (string, error) {
+	data, err := json.Marshal(cookie)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeSearchCookie(s string) (searchCookie, error) {
+	cookie := searchCookie{}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cookie, err
+	}
+
+	err = json.Unmarshal(data, &cookie)
+	if err != nil {
+		return cookie, err
+	}
+	if cookie.Count < 0 || (cookie.Type != ldapEntryTypeUser && cookie.Type != ldapEntryTypeGroup) {
+		return cookie, fmt.Errorf("invalid cookie: %s", s)
+	}
+	return cookie, nil
+}
+
+// parsePagedResultsControlValue parses the value of the Simple Paged Results control
+//
+//	realSearchControlValue ::= SEQUENCE {
+//	  size            INTEGER (0..maxInt),
+//	  cookie          OCTET STRING }
+func parsePagedResultsControlValue(value []byte) (int, string, error) {
+	packet, err := ber.DecodePacketErr(value)
+	if err != nil {
+		return 0, "", err
+	}
+	if len(packet.Children) != 2 {
+		return 0, "", fmt.Errorf("the paged results control value must have 2 elements")
+	}
+
+	size, ok := packet.Children[0].Value.(int64)
+	if !ok || size < 0 {
+		return 0, "", fmt.Errorf("invalid page size: %v", packet.Children[0].Value)
+	}
+	return int(size), packet.Children[1].Data.String(), nil
+}
+
+func newPagedResultsControl(cookie string) *ber.Packet {
+	value := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Search Control Value")
+	// the size is an estimate of the total number of entries, 0 means unknown
+	value.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, 0, "Size"))
+	value.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, cookie, "Cookie"))
+
+	control := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Control")
+	control.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, ldapPagedResultsControlOid, "Control Type"))
+	control.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, string(value.Bytes()), "Control Value"))
+	return control
+}
+
+func newSearchPage(m *ldap.Message) (*searchPage, error) {
+	r := m.GetSearchRequest()
+	page := &searchPage{limit: -1}
+	if r.TimeLimit() > 0 {
+		page.deadline = time.Now().Add(time.Duration(r.TimeLimit()) * time.Second)
+	}
+
+	if m.Controls() != nil {
+		for _, control := range *m.Controls() {
+			if string(control.ControlType()) != ldapPagedResultsControlOid || control.ControlValue() == nil {
+				continue
+			}
+
+			size, cookie, err := parsePagedResultsControlValue(control.ControlValue().Bytes())
+			if err != nil {
+				return nil, err
+			}
+
+			// the cookie is empty for the first page
+			if cookie != "" {
+				page.cookie, err = decodeSearchCookie(cookie)
+				if err != nil {
+					return nil, fmt.Errorf("invalid paged results cookie: %s", cookie)
+				}
+				page.next = page.cookie
+			}
+			page.isPaged = true
+			page.limit = size
+		}
+	}
+
+	sizeLimit := int(r.SizeLimit())
+	if sizeLimit > 0 {
+		remaining := sizeLimit - page.cookie.Count
+		if remaining < 0 {
+			remaining = 0
+		}
+		if page.limit == -1 || page.limit > remaining {
+			page.limit = remaining
+			page.isCapped = true
+		}
+	}
+	return page, nil
+}
+
+// getFetchLimit returns the number of entries to fetch, one more than the page to know if there are more entries
+func (page *searchPage) getFetchLimit() int {
+	if page.limit == -1 {
+		return -1
+	}
+	return page.limit + 1
+}
+
+// truncate returns the number of the count fetched entries to return, and whether there are more entries
+func (page *searchPage) truncate(count int) (int, bool) {
+	if page.limit != -1 && count > page.limit {
+		return page.limit, true
+	}
+	return count, false
+}
+
+func (page *searchPage) isTimeLimitExceeded() bool {
+	return !page.deadline.IsZero() && time.Now().After(page.deadline)
+}
+
+// getResultCode returns the result code of the search, paged searches get the cookie of the next page
+// in the response control
+func (page *searchPage) getResultCode(m *ldap.Message, hasMore bool, isTimeLimitExceeded bool) int {
+	code := ldap.LDAPResultSuccess
+	cookie := ""
+	if isTimeLimitExceeded {
+		code = ldap.LDAPResultTimeLimitExceeded
+	} else if hasMore {
+		switch {
+		case page.isPaged && !page.isCapped && page.limit == 0:
+			// a page size of 0 abandons the paged search
+		case page.isPaged && !page.isCapped:
+			var err error
+			cookie, err = page.next.encode()
+			if err != nil {
+				log.Printf("encode() error: %s", err.Error())
+				code = ldap.LDAPResultOperationsError
+			}
+		default:
+			code = ldap.LDAPResultSizeLimitExceeded
+		}
+	}
+
+	if page.isPaged {
+		setResponseControls(m, newPagedResultsControl(cookie))
+	}
+	return code
+}
+
+// addEntry moves the position of the page after the entry, it's called for each entry returned
+func (page *searchPage) addEntry(entryType string, key string) {
+	page.next.Count++
+	page.next.Type = entryType
+	page.next.Key = key
+}
+
+// getUserAfterId returns the ID of the user to continue after, "" if the page starts at the first user
+func (page *searchPage) getUserAfterId() string {
+	if page.cookie.Type != ldapEntryTypeUser {
+		return ""
+	}
+	return page.cookie.Key
+}
+
+// getGroupsOfPage returns at most limit groups of the page, limit -1 means no limit, the groups must be sorted by DN
+func (page *searchPage) getGroupsOfPage(groups []*LdapGroup, limit int) []*LdapGroup {
+	start := 0
+	if page.cookie.Type == ldapEntryTypeGroup {
+		start = sort.Search(len(groups), func(i int) bool {
+			return groups[i].Dn > page.cookie.Key
+		})
+	}
+
+	end := len(groups)
+	if limit != -1 && start+limit < end {
+		end = start + limit
+	}
+	return groups[start:end]
+}
+
+// controlConn adds response controls to the SearchResultDone messages written to the client,
+// the ldapserver ResponseWriter only writes protocol ops without controls
+type controlConn struct {
+	net.Conn
+	mutex    sync.Mutex
+	controls map[int64][]*ber.Packet
+}
+
+func newControlConn(conn net.Conn) *controlConn {
+	return &controlConn{Conn: conn, controls: map[int64][]*ber.Packet{}}
+}
+
+// controlListener wraps the accepted connections with controlConn
+type controlListener struct {
+	net.Listener
+}
+
+func (l controlListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return newControlConn(conn), nil
+}
+
+func setResponseControls(m *ldap.Message, controls ...*ber.Packet) {
+	conn, ok := m.Client.GetConn().(*controlConn)
+	if !ok {
+		log.Printf("setResponseControls() error: the connection doesn't support response controls")
+		return
+	}
+
+	conn.mutex.Lock()
+	conn.controls[int64(m.MessageID().Int())] = controls
+	conn.mutex.Unlock()
+}
+
+// Write is called with one whole LDAP message at a time, since the client flushes its buffer after each message
+func (c *controlConn) Write(b []byte) (int, error) {
+	c.mutex.Lock()
+	if len(c.controls) == 0 {
+		c.mutex.Unlock()
+		return c.Conn.Write(b)
+	}
+
+	packet, rewritten := c.addResponseControls(b)
+	c.mutex.Unlock()
+	if !rewritten {
+		return c.Conn.Write(b)
+	}
+
+	_, err := c.Conn.Write(packet)
+	if err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (c *controlConn) addResponseControls(b []byte) ([]byte, bool) {
+	reader := bytes.NewReader(b)
+	packet, err := ber.ReadPacket(reader)
+	if err != nil || reader.Len() != 0 || len(packet.Children) != 2 {
+		return nil, false
+	}
+
+	messageId, ok := packet.Children[0].Value.(int64)
+	protocolOp := packet.Children[1]
+	if !ok || protocolOp.ClassType != ber.ClassApplication || protocolOp.Tag != ldapSearchResultDoneTag {
+		return nil, false
+	}
+
+	controls, ok := c.controls[messageId]
+	if !ok {
+		return nil, false
+	}
+	delete(c.controls, messageId)
+
+	controlsPacket := ber.Encode(ber.ClassContext, ber.TypeConstructed, 0, nil, "Controls")
+	for _, control := range controls {
+		controlsPacket.AppendChild(control)
+	}
+	packet.AppendChild(controlsPacket)
+	return packet.Bytes(), true
+}
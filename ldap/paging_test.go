@@ -0,0 +1,114 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"encoding/base64"
+	"testing"
+
+	ldap "github.com/casdoor/ldapserver"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/lor00x/goldap/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchPage(t *testing.T) {
+	page := &searchPage{isPaged: true, limit: 50}
+	assert.Equal(t, 51, page.getFetchLimit())
+
+	count, hasMore := page.truncate(51)
+	assert.Equal(t, 50, count)
+	assert.True(t, hasMore)
+
+	count, hasMore = page.truncate(20)
+	assert.Equal(t, 20, count)
+	assert.False(t, hasMore)
+
+	page = &searchPage{limit: -1}
+	assert.Equal(t, -1, page.getFetchLimit())
+	assert.Equal(t, "", page.getUserAfterId())
+}
+
+func TestSearchCookie(t *testing.T) {
+	page := &searchPage{isPaged: true, limit: 2}
+	page.addEntry(ldapEntryTypeUser, "built-in/alice")
+	page.addEntry(ldapEntryTypeUser, "built-in/bob")
+
+	cookie, err := page.next.encode()
+	assert.Nil(t, err)
+
+	page.cookie, err = decodeSearchCookie(cookie)
+	assert.Nil(t, err)
+	assert.Equal(t, searchCookie{Count: 2, Type: ldapEntryTypeUser, Key: "built-in/bob"}, page.cookie)
+	assert.Equal(t, "built-in/bob", page.getUserAfterId())
+
+	_, err = decodeSearchCookie("1000")
+	assert.NotNil(t, err)
+
+	_, err = decodeSearchCookie(base64.RawURLEncoding.EncodeToString([]byte(`{"count":-1,"type":"user"}`)))
+	assert.NotNil(t, err)
+}
+
+func TestGetGroupsOfPage(t *testing.T) {
+	groups := []*LdapGroup{{Dn: "cn=a"}, {Dn: "cn=b"}, {Dn: "cn=c"}, {Dn: "cn=d"}}
+
+	page := &searchPage{isPaged: true, limit: 2}
+	assert.Equal(t, groups[0:3], page.getGroupsOfPage(groups, page.getFetchLimit()))
+
+	// the groups after the last returned group, even if a group before it was deleted
+	page.cookie = searchCookie{Count: 2, Type: ldapEntryTypeGroup, Key: "cn=b"}
+	assert.Equal(t, groups[2:4], page.getGroupsOfPage(groups[1:], 3))
+
+	page.cookie = searchCookie{Count: 4, Type: ldapEntryTypeGroup, Key: "cn=d"}
+	assert.Equal(t, 0, len(page.getGroupsOfPage(groups, 3)))
+
+	page = &searchPage{limit: -1}
+	assert.Equal(t, groups, page.getGroupsOfPage(groups, page.getFetchLimit()))
+}
+
+func TestAddResponseControls(t *testing.T) {
+	value := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Search Control Value")
+	value.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, 500, "Size"))
+	value.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "1000", "Cookie"))
+	size, cookie, err := parsePagedResultsControlValue(value.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, 500, size)
+	assert.Equal(t, "1000", cookie)
+
+	done := message.NewLDAPMessageWithProtocolOp(ldap.NewSearchResultDoneResponse(ldap.LDAPResultSuccess))
+	done.SetMessageID(3)
+	doneBytes, err := done.Write()
+	assert.Nil(t, err)
+
+	conn := newControlConn(nil)
+	_, rewritten := conn.addResponseControls(doneBytes.Bytes())
+	assert.False(t, rewritten)
+
+	conn.controls[3] = []*ber.Packet{newPagedResultsControl("1500")}
+	b, rewritten := conn.addResponseControls(doneBytes.Bytes())
+	assert.True(t, rewritten)
+	assert.Equal(t, 0, len(conn.controls))
+
+	m, err := message.ReadLDAPMessage(message.NewBytes(0, b))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, m.MessageID().Int())
+	assert.NotNil(t, m.Controls())
+
+	control := (*m.Controls())[0]
+	assert.Equal(t, ldapPagedResultsControlOid, string(control.ControlType()))
+	_, cookie, err = parsePagedResultsControlValue(control.ControlValue().Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, "1500", cookie)
+}
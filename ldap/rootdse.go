@@ -0,0 +1,257 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+	ldap "github.com/casdoor/ldapserver"
+	"github.com/lor00x/goldap/message"
+)
+
+const (
+	ldapSubschemaDn   = "cn=subschema"
+	ldapDefaultBaseDn = "dc=example,dc=com"
+)
+
+var ldapRootDseAttributes = []string{"objectClass", "namingContexts", "subschemaSubentry", "supportedLDAPVersion", "supportedControl", "supportedExtension", "vendorName"}
+
+var ldapSubschemaAttributes = []string{"objectClass", "cn", "attributeTypes", "objectClasses"}
+
+var ldapOrganizationAttributes = []string{"objectClass", "ou"}
+
+// the schema of the attributes and object classes of the entries served by Casdoor, see RFC 4519, RFC 2798 and RFC 2307bis
+var ldapAttributeTypes = []string{
+	"( 2.5.4.0 NAME 'objectClass' EQUALITY objectIdentifierMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.38 )",
+	"( 2.5.4.41 NAME 'name' EQUALITY caseIgnoreMatch SUBSTR caseIgnoreSubstringsMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.15{32768} )",
+	"( 2.5.4.49 NAME 'distinguishedName' EQUALITY distinguishedNameMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.12 )",
+	"( 2.5.4.3 NAME ( 'cn' 'commonName' ) SUP name )",
+	"( 2.5.4.4 NAME ( 'sn' 'surname' ) SUP name )",
+	"( 2.5.4.42 NAME 'givenName' SUP name )",
+	"( 2.5.4.11 NAME ( 'ou' 'organizationalUnitName' ) SUP name )",
+	"( 2.5.4.12 NAME 'title' SUP name )",
+	"( 2.5.4.13 NAME 'description' EQUALITY caseIgnoreMatch SUBSTR caseIgnoreSubstringsMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.15{1024} )",
+	"( 2.5.4.35 NAME 'userPassword' EQUALITY octetStringMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.40{128} )",
+	"( 2.5.4.31 NAME 'member' SUP distinguishedName )",
+	"( 0.9.2342.19200300.100.1.1 NAME ( 'uid' 'userid' ) EQUALITY caseIgnoreMatch SUBSTR caseIgnoreSubstringsMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.15{256} )",
+	"( 0.9.2342.19200300.100.1.3 NAME ( 'mail' 'rfc822Mailbox' ) EQUALITY caseIgnoreIA5Match SUBSTR caseIgnoreIA5SubstringsMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.26{256} )",
+	"( 1.2.840.113549.1.9.1 NAME ( 'email' 'emailAddress' ) EQUALITY caseIgnoreIA5Match SUBSTR caseIgnoreIA5SubstringsMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.26{128} )",
+	"( 0.9.2342.19200300.100.1.41 NAME ( 'mobile' 'mobileTelephoneNumber' ) EQUALITY telephoneNumberMatch SUBSTR telephoneNumberSubstringsMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.50{32} )",
+	"( 2.16.840.1.113730.3.1.241 NAME 'displayName' EQUALITY caseIgnoreMatch SUBSTR caseIgnoreSubstringsMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.15 SINGLE-VALUE )",
+	"( 2.16.840.1.113730.3.1.39 NAME 'preferredLanguage' EQUALITY caseIgnoreMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.15 SINGLE-VALUE )",
+	"( 1.3.6.1.1.1.1.0 NAME 'uidNumber' EQUALITY integerMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.27 SINGLE-VALUE )",
+	"( 1.3.6.1.1.1.1.1 NAME 'gidNumber' EQUALITY integerMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.27 SINGLE-VALUE )",
+	"( 1.3.6.1.1.1.1.3 NAME 'homeDirectory' EQUALITY caseExactIA5Match SYNTAX 1.3.6.1.4.1.1466.115.121.1.26 SINGLE-VALUE )",
+	"( 1.3.6.1.1.1.1.12 NAME 'memberUid' EQUALITY caseExactIA5Match SUBSTR caseExactIA5SubstringsMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.26 )",
+	"( 1.2.840.113556.1.2.102 NAME 'memberOf' EQUALITY distinguishedNameMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.12 NO-USER-MODIFICATION USAGE dSAOperation )",
+}
+
+var ldapObjectClasses = []string{
+	"( 2.5.6.0 NAME 'top' ABSTRACT MUST objectClass )",
+	"( 2.5.6.5 NAME 'organizationalUnit' SUP top STRUCTURAL MUST ou MAY description )",
+	"( 2.5.6.6 NAME 'person' SUP top STRUCTURAL MUST ( sn $ cn ) MAY ( userPassword $ description ) )",
+	"( 2.5.6.7 NAME 'organizationalPerson' SUP person STRUCTURAL MAY ( title $ ou ) )",
+	"( 2.16.840.1.113730.3.2.2 NAME 'inetOrgPerson' SUP organizationalPerson STRUCTURAL MAY ( displayName $ givenName $ mail $ mobile $ preferredLanguage $ uid ) )",
+	"( 1.3.6.1.1.1.2.0 NAME 'posixAccount' SUP top AUXILIARY MUST ( cn $ uid $ uidNumber $ gidNumber $ homeDirectory ) MAY ( userPassword $ description ) )",
+	"( 2.5.6.9 NAME 'groupOfNames' SUP top STRUCTURAL MUST ( member $ cn ) MAY ( description $ ou ) )",
+	"( 1.3.6.1.1.1.2.2 NAME 'posixGroup' SUP top AUXILIARY MUST ( cn $ gidNumber ) MAY ( userPassword $ memberUid $ description ) )",
+	"( 2.5.20.1 NAME 'subschema' AUXILIARY MAY ( attributeTypes $ objectClasses ) )",
+}
+
+// getLdapBaseDn returns the DN under which the organizations are published
+func getLdapBaseDn() string {
+	baseDn := conf.GetConfigString("ldapServerBaseDn")
+	if baseDn == "" {
+		return ldapDefaultBaseDn
+	}
+	return baseDn
+}
+
+func isRootDseSearch(r message.SearchRequest) bool {
+	return r.BaseObject() == "" && r.Scope() == message.SearchRequestScopeBaseObject
+}
+
+func isSubschemaSearch(r message.SearchRequest) bool {
+	return strings.EqualFold(string(r.BaseObject()), ldapSubschemaDn) && r.Scope() == message.SearchRequestScopeBaseObject
+}
+
+// isOrganizationSearch returns true for base searches of an organization entry, e.g. "ou=built-in,dc=example,dc=com"
+func isOrganizationSearch(r message.SearchRequest) bool {
+	baseDn := string(r.BaseObject())
+	return r.Scope() == message.SearchRequestScopeBaseObject && getOrgFromDn(baseDn) != "" && getOrgBaseDn(baseDn) == baseDn
+}
+
+// getNamingContexts returns the organizations the client can search, anonymous clients only get the base DN
+func getNamingContexts(m *ldap.Message) ([]string, error) {
+	baseDn := getLdapBaseDn()
+	if !m.Client.IsAuthenticated {
+		return []string{baseDn}, nil
+	}
+
+	if !m.Client.IsGlobalAdmin {
+		return []string{fmt.Sprintf("ou=%s,%s", m.Client.OrgName, baseDn)}, nil
+	}
+
+	organizations, err := object.GetOrganizations("admin")
+	if err != nil {
+		return nil, err
+	}
+
+	res := []string{}
+	for _, organization := range organizations {
+		res = append(res, fmt.Sprintf("ou=%s,%s", organization.Name, baseDn))
+	}
+	return res, nil
+}
+
+func getRootDseAttributes(m *ldap.Message) (map[string][]string, error) {
+	namingContexts, err := getNamingContexts(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]string{
+		"objectclass":          {"top"},
+		"namingcontexts":       namingContexts,
+		"subschemasubentry":    {ldapSubschemaDn},
+		"supportedldapversion": {"3"},
+		"supportedcontrol":     {ldapPagedResultsControlOid},
+		"supportedextension":   {string(ldap.NoticeOfPasswordModify), string(ldap.NoticeOfStartTLS)},
+		"vendorname":           {"Casdoor"},
+	}, nil
+}
+
+func getSubschemaAttributes() map[string][]string {
+	return map[string][]string{
+		"objectclass":    {"top", "subschema"},
+		"cn":             {"subschema"},
+		"attributetypes": ldapAttributeTypes,
+		"objectclasses":  ldapObjectClasses,
+	}
+}
+
+// newSearchResultEntry returns the entry with the requested attributes, all the attributes are returned
+// if none is requested or for "*" and "+"
+func newSearchResultEntry(dn string, attributeNames []string, attributes map[string][]string, requested []message.LDAPString) message.SearchResultEntry {
+	isAll := len(requested) == 0
+	requestedNames := map[string]bool{}
+	for _, attribute := range requested {
+		if attribute == "*" || attribute == "+" {
+			isAll = true
+		}
+		requestedNames[strings.ToLower(string(attribute))] = true
+	}
+
+	e := ldap.NewSearchResultEntry(dn)
+	for _, attribute := range attributeNames {
+		values := attributes[strings.ToLower(attribute)]
+		if len(values) == 0 || (!isAll && !requestedNames[strings.ToLower(attribute)]) {
+			continue
+		}
+
+		attributeValues := []message.AttributeValue{}
+		for _, value := range values {
+			attributeValues = append(attributeValues, message.AttributeValue(value))
+		}
+		e.AddAttribute(message.AttributeDescription(attribute), attributeValues...)
+	}
+	return e
+}
+
+// handleRootDseSearch answers the searches of the rootDSE and the subschema, which are allowed before binding
+func handleRootDseSearch(w ldap.ResponseWriter, m *ldap.Message) {
+	r := m.GetSearchRequest()
+	res := ldap.NewSearchResultDoneResponse(ldap.LDAPResultSuccess)
+
+	dn := string(r.BaseObject())
+	attributeNames := ldapSubschemaAttributes
+	attributes := getSubschemaAttributes()
+	if isRootDseSearch(r) {
+		var err error
+		attributeNames = ldapRootDseAttributes
+		attributes, err = getRootDseAttributes(m)
+		if err != nil {
+			log.Printf("getRootDseAttributes() error: %s", err.Error())
+			res.SetResultCode(ldap.LDAPResultOperationsError)
+			w.Write(res)
+			return
+		}
+	}
+
+	if matchLdapFilter(r.Filter(), attributes) {
+		w.Write(newSearchResultEntry(dn, attributeNames, attributes, r.Attributes()))
+	}
+	w.Write(res)
+}
+
+// handleOrganizationSearch answers the base searches of an organization entry, which LDAP browsers do
+// before listing the entries of a naming context
+func handleOrganizationSearch(w ldap.ResponseWriter, m *ldap.Message) {
+	r := m.GetSearchRequest()
+	res := ldap.NewSearchResultDoneResponse(ldap.LDAPResultSuccess)
+
+	org := getOrgFromDn(string(r.BaseObject()))
+	if !m.Client.IsGlobalAdmin && org != m.Client.OrgName {
+		res.SetResultCode(ldap.LDAPResultInsufficientAccessRights)
+		w.Write(res)
+		return
+	}
+
+	organization, err := object.GetOrganization(util.GetId("admin", org))
+	if err != nil {
+		log.Printf("GetOrganization() error: %s", err.Error())
+		res.SetResultCode(ldap.LDAPResultOperationsError)
+		w.Write(res)
+		return
+	}
+	if organization == nil {
+		res.SetResultCode(ldap.LDAPResultNoSuchObject)
+		w.Write(res)
+		return
+	}
+
+	attributes := map[string][]string{
+		"objectclass": {"top", "organizationalUnit"},
+		"ou":          {organization.Name},
+	}
+	if matchLdapFilter(r.Filter(), attributes) {
+		w.Write(newSearchResultEntry(string(r.BaseObject()), ldapOrganizationAttributes, attributes, r.Attributes()))
+	}
+	w.Write(res)
+}
+
+// getBindUser returns the bound user of the connection
+func getBindUser(m *ldap.Message) (*object.User, error) {
+	return object.GetUser(util.GetId(m.Client.OrgName, m.Client.UserName))
+}
+
+// isLdapServiceAccountOf returns true if the client is bound as a service account of the organization
+func isLdapServiceAccountOf(m *ldap.Message, org string) bool {
+	if !m.Client.IsAuthenticated || m.Client.OrgName != org {
+		return false
+	}
+
+	user, err := getBindUser(m)
+	if err != nil {
+		log.Printf("getBindUser() error: %s", err.Error())
+		return false
+	}
+	return user.IsLdapServiceAccount()
+}
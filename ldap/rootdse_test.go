@@ -0,0 +1,60 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/lor00x/goldap/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func getEntryAttributeNames(t *testing.T, e message.SearchResultEntry) []string {
+	b, err := message.NewLDAPMessageWithProtocolOp(e).Write()
+	assert.Nil(t, err)
+
+	packet, err := ber.DecodePacketErr(b.Bytes())
+	assert.Nil(t, err)
+
+	names := []string{}
+	for _, attribute := range packet.Children[1].Children[1].Children {
+		names = append(names, attribute.Children[0].Data.String())
+	}
+	return names
+}
+
+func TestRootDse(t *testing.T) {
+	searchRequest, err := buildLdapSearchRequest("(objectClass=*)")
+	assert.Nil(t, err)
+	m, err := message.ReadLDAPMessage(message.NewBytes(0, searchRequest.Bytes()))
+	assert.Nil(t, err)
+	r := m.ProtocolOp().(message.SearchRequest)
+	assert.True(t, isRootDseSearch(r))
+	assert.False(t, isSubschemaSearch(r))
+	assert.False(t, isOrganizationSearch(r))
+
+	attributes := getSubschemaAttributes()
+	assert.True(t, matchLdapFilter(r.Filter(), attributes))
+
+	e := newSearchResultEntry(ldapSubschemaDn, ldapSubschemaAttributes, attributes, nil)
+	assert.Equal(t, ldapSubschemaAttributes, getEntryAttributeNames(t, e))
+
+	e = newSearchResultEntry(ldapSubschemaDn, ldapSubschemaAttributes, attributes, []message.LDAPString{"objectclasses"})
+	assert.Equal(t, []string{"objectClasses"}, getEntryAttributeNames(t, e))
+
+	e = newSearchResultEntry(ldapSubschemaDn, ldapSubschemaAttributes, attributes, []message.LDAPString{"1.1"})
+	assert.Equal(t, []string{}, getEntryAttributeNames(t, e))
+}
@@ -36,6 +36,9 @@ func StartLdapServer() {
 
 	routes.Bind(handleBind)
 	routes.Search(handleSearch).Label(" SEARCH****")
+	routes.Extended(handlePasswordModify).RequestName(ldap.NoticeOfPasswordModify).Label(" PASSWORD MODIFY****")
+	routes.Modify(handleModify).Label(" MODIFY****")
+	routes.Extended(handleStartTLS).RequestName(ldap.NoticeOfStartTLS).Label(" STARTTLS****")
 
 	server.Handle(routes)
 	serverSsl.Handle(routes)
@@ -43,7 +46,7 @@ func StartLdapServer() {
 		if ldapServerPort == "" || ldapServerPort == "0" {
 			return
 		}
-		err := server.ListenAndServe("0.0.0.0:" + ldapServerPort)
+		err := server.ListenAndServe("0.0.0.0:"+ldapServerPort, wrapControlConn)
 		if err != nil {
 			log.Printf("StartLdapServer() failed, err = %s", err.Error())
 		}
@@ -65,13 +68,17 @@ func StartLdapServer() {
 		secureConn := func(s *ldap.Server) {
 			s.Listener = tls.NewListener(s.Listener, config)
 		}
-		err = serverSsl.ListenAndServe("0.0.0.0:"+ldapsServerPort, secureConn)
+		err = serverSsl.ListenAndServe("0.0.0.0:"+ldapsServerPort, secureConn, wrapControlConn)
 		if err != nil {
 			log.Printf("StartLdapsServer() failed, err = %s", err.Error())
 		}
 	}()
 }
 
+func wrapControlConn(s *ldap.Server) {
+	s.Listener = controlListener{Listener: s.Listener}
+}
+
 func getTLSconfig(ldapsCertId string) (*tls.Config, error) {
 	rawCert, err := object.GetCert(ldapsCertId)
 	if err != nil {
@@ -92,6 +99,56 @@ func getTLSconfig(ldapsCertId string) (*tls.Config, error) {
 	}, nil
 }
 
+// handleStartTLS upgrades the connection on the plain port to TLS with the ldapsCertId certificate, see RFC 4511 section 4.14
+func handleStartTLS(w ldap.ResponseWriter, m *ldap.Message) {
+	res := ldap.NewExtendedResponse(ldap.LDAPResultSuccess)
+	res.SetResponseName(ldap.NoticeOfStartTLS)
+
+	conn, ok := m.Client.GetConn().(*controlConn)
+	if !ok {
+		res.SetResultCode(ldap.LDAPResultOperationsError)
+		res.SetDiagnosticMessage("StartTLS is not supported on this connection")
+		w.Write(res)
+		return
+	}
+	if _, ok = conn.Conn.(*tls.Conn); ok {
+		res.SetResultCode(ldap.LDAPResultOperationsError)
+		res.SetDiagnosticMessage("TLS is already established")
+		w.Write(res)
+		return
+	}
+
+	ldapsCertId := conf.GetConfigString("ldapsCertId")
+	if ldapsCertId == "" {
+		res.SetResultCode(ldap.LDAPResultProtocolError)
+		res.SetDiagnosticMessage("StartTLS is not enabled, ldapsCertId is empty")
+		w.Write(res)
+		return
+	}
+
+	config, err := getTLSconfig(ldapsCertId)
+	if err != nil {
+		log.Printf("StartTLS failed, err = %s", err.Error())
+		res.SetResultCode(ldap.LDAPResultUnavailable)
+		res.SetDiagnosticMessage(err.Error())
+		w.Write(res)
+		return
+	}
+
+	// the response is sent in clear text, the client starts the handshake after receiving it
+	tlsConn := tls.Server(conn.Conn, config)
+	w.Write(res)
+
+	err = tlsConn.Handshake()
+	if err != nil {
+		log.Printf("StartTLS handshake failed, err = %s", err.Error())
+		conn.Close()
+		return
+	}
+
+	m.Client.SetConn(newControlConn(tlsConn))
+}
+
 func handleBind(w ldap.ResponseWriter, m *ldap.Message) {
 	r := m.GetBindRequest()
 	res := ldap.NewBindResponse(ldap.LDAPResultSuccess)
@@ -124,11 +181,10 @@ func handleBind(w ldap.ResponseWriter, m *ldap.Message) {
 			return
 		}
 
-		if bindOrg == "built-in" || bindUser.IsGlobalAdmin() {
-			m.Client.IsGlobalAdmin, m.Client.IsOrgAdmin = true, true
-		} else if bindUser.IsAdmin {
-			m.Client.IsOrgAdmin = true
-		}
+		// service accounts can only search their own organization, even in the built-in organization
+		isServiceAccount := bindUser.IsLdapServiceAccount()
+		m.Client.IsGlobalAdmin = !isServiceAccount && (bindOrg == "built-in" || bindUser.IsGlobalAdmin())
+		m.Client.IsOrgAdmin = !isServiceAccount && (m.Client.IsGlobalAdmin || bindUser.IsAdmin)
 
 		m.Client.IsAuthenticated = true
 		m.Client.UserName = bindUsername
@@ -142,15 +198,20 @@ func handleBind(w ldap.ResponseWriter, m *ldap.Message) {
 
 func handleSearch(w ldap.ResponseWriter, m *ldap.Message) {
 	res := ldap.NewSearchResultDoneResponse(ldap.LDAPResultSuccess)
+	r := m.GetSearchRequest()
+	if isRootDseSearch(r) || isSubschemaSearch(r) {
+		handleRootDseSearch(w, m)
+		return
+	}
+
 	if !m.Client.IsAuthenticated {
 		res.SetResultCode(ldap.LDAPResultUnwillingToPerform)
 		w.Write(res)
 		return
 	}
 
-	r := m.GetSearchRequest()
-	if r.FilterString() == "(objectClass=*)" {
-		w.Write(res)
+	if isOrganizationSearch(r) {
+		handleOrganizationSearch(w, m)
 		return
 	}
 
@@ -162,44 +223,117 @@ func handleSearch(w ldap.ResponseWriter, m *ldap.Message) {
 	default:
 	}
 
-	users, code := GetFilteredUsers(m)
-	if code != ldap.LDAPResultSuccess {
-		res.SetResultCode(code)
-		w.Write(res)
+	page, err := newSearchPage(m)
+	if err != nil {
+		errorRes := ldap.NewResponse(ldap.LDAPResultProtocolError)
+		errorRes.SetDiagnosticMessage(err.Error())
+		w.Write(message.SearchResultDone(errorRes))
 		return
 	}
 
+	searchUsers, searchGroups := getLdapSearchTypes(r)
+
+	// the users are returned before the groups, so a page starting after a group has no users
+	var users []*object.User
+	code := ldap.LDAPResultSuccess
+	fetchLimit := page.getFetchLimit()
+	if searchUsers && page.cookie.Type != ldapEntryTypeGroup {
+		users, code = GetFilteredUsers(m, page.getUserAfterId(), fetchLimit)
+		if code != ldap.LDAPResultSuccess {
+			res.SetResultCode(code)
+			w.Write(res)
+			return
+		}
+	}
+
+	var groups []*LdapGroup
+	if searchGroups && (fetchLimit == -1 || len(users) < fetchLimit) {
+		groups, code = GetFilteredGroups(m)
+		// the groups belong to an organization, a search of users and groups based above the organizations only returns users
+		if searchUsers && code == ldap.LDAPResultInvalidDNSyntax {
+			groups, code = nil, ldap.LDAPResultSuccess
+		}
+		if code != ldap.LDAPResultSuccess {
+			res.SetResultCode(code)
+			w.Write(res)
+			return
+		}
+
+		groupLimit := -1
+		if fetchLimit != -1 {
+			groupLimit = fetchLimit - len(users)
+		}
+		groups = page.getGroupsOfPage(groups, groupLimit)
+	}
+
+	count, hasMore := page.truncate(len(users) + len(groups))
+	if count < len(users) {
+		users = users[:count]
+	}
+	groups = groups[:count-len(users)]
+
+	isTimeLimitExceeded := false
+	orgBaseDn := getOrgBaseDn(string(r.BaseObject()))
 	for _, user := range users {
-		dn := fmt.Sprintf("uid=%s,cn=%s,%s", user.Id, user.Name, string(r.BaseObject()))
-		e := ldap.NewSearchResultEntry(dn)
-		uidNumberStr := fmt.Sprintf("%v", hash(user.Name))
-		e.AddAttribute("uidNumber", message.AttributeValue(uidNumberStr))
-		e.AddAttribute("gidNumber", message.AttributeValue(uidNumberStr))
-		e.AddAttribute("homeDirectory", message.AttributeValue("/home/"+user.Name))
-		e.AddAttribute("cn", message.AttributeValue(user.Name))
-		e.AddAttribute("uid", message.AttributeValue(user.Id))
-		for _, group := range user.Groups {
-			e.AddAttribute(ldapMemberOfAttr, message.AttributeValue(group))
-		}
-		attrs := r.Attributes()
-		for _, attr := range attrs {
-			if string(attr) == "*" {
-				attrs = AdditionalLdapAttributes
-				break
-			}
-		}
-		for _, attr := range attrs {
-			e.AddAttribute(message.AttributeDescription(attr), getAttribute(string(attr), user))
-			if string(attr) == "title" {
-				e.AddAttribute(message.AttributeDescription(attr), getAttribute("title", user))
-			}
-		}
-
-		w.Write(e)
+		// Handle Stop Signal while writing the entries of large searches
+		select {
+		case <-m.Done:
+			log.Print("Leaving handleSearch...")
+			return
+		default:
+		}
+
+		if isTimeLimitExceeded = page.isTimeLimitExceeded(); isTimeLimitExceeded {
+			break
+		}
+
+		w.Write(getUserSearchResultEntry(user, r, orgBaseDn))
+		page.addEntry(ldapEntryTypeUser, user.GetId())
+	}
+
+	for _, group := range groups {
+		if isTimeLimitExceeded = page.isTimeLimitExceeded(); isTimeLimitExceeded {
+			break
+		}
+
+		w.Write(group.getSearchResultEntry())
+		page.addEntry(ldapEntryTypeGroup, group.Dn)
 	}
+	res.SetResultCode(page.getResultCode(m, hasMore, isTimeLimitExceeded))
 	w.Write(res)
 }
 
+func getUserSearchResultEntry(user *object.User, r message.SearchRequest, orgBaseDn string) message.SearchResultEntry {
+	dn := getUserDn(user, string(r.BaseObject()))
+	e := ldap.NewSearchResultEntry(dn)
+	for _, objectClass := range ldapUserObjectClasses {
+		e.AddAttribute(ldapObjectClassAttr, message.AttributeValue(objectClass))
+	}
+	uidNumberStr := fmt.Sprintf("%v", hash(user.Name))
+	e.AddAttribute("uidNumber", message.AttributeValue(uidNumberStr))
+	e.AddAttribute("gidNumber", message.AttributeValue(uidNumberStr))
+	e.AddAttribute("homeDirectory", message.AttributeValue("/home/"+user.Name))
+	e.AddAttribute("cn", message.AttributeValue(user.Name))
+	e.AddAttribute("uid", message.AttributeValue(user.Id))
+	for _, group := range user.Groups {
+		e.AddAttribute(ldapMemberOfAttr, message.AttributeValue(getMemberOfValue(group, orgBaseDn)))
+	}
+	attrs := r.Attributes()
+	for _, attr := range attrs {
+		if string(attr) == "*" {
+			attrs = AdditionalLdapAttributes
+			break
+		}
+	}
+	for _, attr := range attrs {
+		e.AddAttribute(message.AttributeDescription(attr), getAttribute(string(attr), user))
+		if string(attr) == "title" {
+			e.AddAttribute(message.AttributeDescription(attr), getAttribute("title", user))
+		}
+	}
+	return e
+}
+
 func hash(s string) uint32 {
 	h := fnv.New32a()
 	h.Write([]byte(s))
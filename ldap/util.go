@@ -79,7 +79,10 @@ var ldapAttributesMapping = map[string]FieldRelation{
 	},
 }
 
-const ldapMemberOfAttr = "memberOf"
+const (
+	ldapMemberOfAttr    = "memberOf"
+	ldapObjectClassAttr = "objectClass"
+)
 
 var AdditionalLdapAttributes []message.LDAPString
 
@@ -184,9 +187,16 @@ func buildUserFilterCondition(filter interface{}) (builder.Cond, error) {
 	case message.FilterEqualityMatch:
 		attr := string(f.AttributeDesc())
 
+		if strings.EqualFold(attr, ldapObjectClassAttr) {
+			if containsFold(ldapUserObjectClasses, string(f.AssertionValue())) {
+				return builder.Expr("1 = 1"), nil
+			}
+			return builder.Expr("1 != 1"), nil
+		}
+
 		if attr == ldapMemberOfAttr {
 			var names []string
-			groupId := string(f.AssertionValue())
+			groupId := getGroupIdFromMemberOf(string(f.AssertionValue()))
 			users := object.GetGroupUsersWithoutError(groupId)
 			for _, user := range users {
 				names = append(names, user.Name)
@@ -200,6 +210,10 @@ func buildUserFilterCondition(filter interface{}) (builder.Cond, error) {
 		}
 		return builder.Eq{field: string(f.AssertionValue())}, nil
 	case message.FilterPresent:
+		if strings.EqualFold(string(f), ldapObjectClassAttr) {
+			return builder.Expr("1 = 1"), nil
+		}
+
 		field, err := getUserFieldFromAttribute(string(f))
 		if err != nil {
 			return nil, err
@@ -251,7 +265,8 @@ func buildSafeCondition(filter interface{}) builder.Cond {
 	return condition
 }
 
-func GetFilteredUsers(m *ldap.Message) (filteredUsers []*object.User, code int) {
+// GetFilteredUsers returns at most limit users matching the search request after the user afterId, limit -1 means no limit
+func GetFilteredUsers(m *ldap.Message, afterId string, limit int) (filteredUsers []*object.User, code int) {
 	var err error
 	r := m.GetSearchRequest()
 
@@ -262,14 +277,14 @@ func GetFilteredUsers(m *ldap.Message) (filteredUsers []*object.User, code int)
 
 	if name == "*" { // get all users from organization 'org'
 		if m.Client.IsGlobalAdmin && org == "*" {
-			filteredUsers, err = object.GetGlobalUsersWithFilter(buildSafeCondition(r.Filter()))
+			filteredUsers, err = object.GetGlobalUsersWithFilter(buildSafeCondition(r.Filter()), afterId, limit)
 			if err != nil {
 				panic(err)
 			}
 			return filteredUsers, ldap.LDAPResultSuccess
 		}
 		if m.Client.IsGlobalAdmin || org == m.Client.OrgName {
-			filteredUsers, err = object.GetUsersWithFilter(org, buildSafeCondition(r.Filter()))
+			filteredUsers, err = object.GetUsersWithFilter(org, buildSafeCondition(r.Filter()), afterId, limit)
 			if err != nil {
 				panic(err)
 			}
@@ -282,10 +297,12 @@ func GetFilteredUsers(m *ldap.Message) (filteredUsers []*object.User, code int)
 		requestUserId := util.GetId(m.Client.OrgName, m.Client.UserName)
 		userId := util.GetId(org, name)
 
-		hasPermission, err := object.CheckUserPermission(requestUserId, userId, true, "en")
-		if !hasPermission {
-			log.Printf("err = %v", err.Error())
-			return nil, ldap.LDAPResultInsufficientAccessRights
+		if !isLdapServiceAccountOf(m, org) {
+			hasPermission, err := object.CheckUserPermission(requestUserId, userId, true, "en")
+			if !hasPermission {
+				log.Printf("err = %v", err.Error())
+				return nil, ldap.LDAPResultInsufficientAccessRights
+			}
 		}
 
 		user, err := object.GetUser(userId)
@@ -294,7 +311,9 @@ func GetFilteredUsers(m *ldap.Message) (filteredUsers []*object.User, code int)
 		}
 
 		if user != nil {
-			filteredUsers = append(filteredUsers, user)
+			if afterId == "" {
+				filteredUsers = append(filteredUsers, user)
+			}
 			return filteredUsers, ldap.LDAPResultSuccess
 		}
 
@@ -311,7 +330,7 @@ func GetFilteredUsers(m *ldap.Message) (filteredUsers []*object.User, code int)
 			return nil, ldap.LDAPResultNoSuchObject
 		}
 
-		users, err := object.GetUsersByTagWithFilter(org, name, buildSafeCondition(r.Filter()))
+		users, err := object.GetUsersByTagWithFilter(org, name, buildSafeCondition(r.Filter()), afterId, limit)
 		if err != nil {
 			panic(err)
 		}
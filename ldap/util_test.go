@@ -30,6 +30,9 @@ func TestLdapFilterAsQuery(t *testing.T) {
 		{"Should be SQL for FilterGreaterOrEqual", "(mail>=admin)", "email>=?", args("admin")},
 		{"Should be SQL for FilterLessOrEqual", "(mail<=admin)", "email<=?", args("admin")},
 		{"Should be SQL for FilterSubstrings", "(mail=admin*ex*c*m)", "email LIKE ?", args("admin%ex%c%m")},
+		{"Should be SQL for user objectClass", "(&(objectClass=posixAccount)(uid=admin))", "(1 = 1) AND name=?", args("admin")},
+		{"Should be SQL for group objectClass", "(objectClass=posixGroup)", "1 != 1", nil},
+		{"Should be SQL for objectClass present", "(objectClass=*)", "1 = 1", nil},
 	}
 
 	for _, scenery := range scenarios {
@@ -28,6 +28,7 @@ import (
 	"github.com/casdoor/casdoor/proxy"
 	"github.com/casdoor/casdoor/radius"
 	"github.com/casdoor/casdoor/routers"
+	"github.com/casdoor/casdoor/tacacs"
 	"github.com/casdoor/casdoor/util"
 )
 
@@ -92,7 +93,10 @@ func main() {
 
 	go ldap.StartLdapServer()
 	go radius.StartRadiusServer()
+	go tacacs.StartTacacsServer()
 	go object.ClearThroughputPerSecond()
+	go object.ClearExpiredTickets()
+	go object.RetryLdapProvisionTasks()
 
 	beego.Run(fmt.Sprintf(":%v", port))
 }
@@ -65,38 +65,39 @@ type Application struct {
 	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
 	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
 
-	DisplayName           string          `xorm:"varchar(100)" json:"displayName"`
-	Logo                  string          `xorm:"varchar(200)" json:"logo"`
-	HomepageUrl           string          `xorm:"varchar(100)" json:"homepageUrl"`
-	Description           string          `xorm:"varchar(100)" json:"description"`
-	Organization          string          `xorm:"varchar(100)" json:"organization"`
-	Cert                  string          `xorm:"varchar(100)" json:"cert"`
-	DefaultGroup          string          `xorm:"varchar(100)" json:"defaultGroup"`
-	HeaderHtml            string          `xorm:"mediumtext" json:"headerHtml"`
-	EnablePassword        bool            `json:"enablePassword"`
-	EnableSignUp          bool            `json:"enableSignUp"`
-	EnableSigninSession   bool            `json:"enableSigninSession"`
-	EnableAutoSignin      bool            `json:"enableAutoSignin"`
-	EnableCodeSignin      bool            `json:"enableCodeSignin"`
-	EnableSamlCompress    bool            `json:"enableSamlCompress"`
-	EnableSamlC14n10      bool            `json:"enableSamlC14n10"`
-	EnableSamlPostBinding bool            `json:"enableSamlPostBinding"`
-	UseEmailAsSamlNameId  bool            `json:"useEmailAsSamlNameId"`
-	EnableWebAuthn        bool            `json:"enableWebAuthn"`
-	EnableLinkWithEmail   bool            `json:"enableLinkWithEmail"`
-	OrgChoiceMode         string          `json:"orgChoiceMode"`
-	SamlReplyUrl          string          `xorm:"varchar(100)" json:"samlReplyUrl"`
-	Providers             []*ProviderItem `xorm:"mediumtext" json:"providers"`
-	SigninMethods         []*SigninMethod `xorm:"varchar(2000)" json:"signinMethods"`
-	SignupItems           []*SignupItem   `xorm:"varchar(3000)" json:"signupItems"`
-	SigninItems           []*SigninItem   `xorm:"mediumtext" json:"signinItems"`
-	GrantTypes            []string        `xorm:"varchar(1000)" json:"grantTypes"`
-	OrganizationObj       *Organization   `xorm:"-" json:"organizationObj"`
-	CertPublicKey         string          `xorm:"-" json:"certPublicKey"`
-	Tags                  []string        `xorm:"mediumtext" json:"tags"`
-	SamlAttributes        []*SamlItem     `xorm:"varchar(1000)" json:"samlAttributes"`
-	IsShared              bool            `json:"isShared"`
-	IpRestriction         string          `json:"ipRestriction"`
+	DisplayName           string                 `xorm:"varchar(100)" json:"displayName"`
+	Logo                  string                 `xorm:"varchar(200)" json:"logo"`
+	HomepageUrl           string                 `xorm:"varchar(100)" json:"homepageUrl"`
+	Description           string                 `xorm:"varchar(100)" json:"description"`
+	Organization          string                 `xorm:"varchar(100)" json:"organization"`
+	Cert                  string                 `xorm:"varchar(100)" json:"cert"`
+	DefaultGroup          string                 `xorm:"varchar(100)" json:"defaultGroup"`
+	HeaderHtml            string                 `xorm:"mediumtext" json:"headerHtml"`
+	EnablePassword        bool                   `json:"enablePassword"`
+	EnableSignUp          bool                   `json:"enableSignUp"`
+	EnableSigninSession   bool                   `json:"enableSigninSession"`
+	EnableAutoSignin      bool                   `json:"enableAutoSignin"`
+	EnableCodeSignin      bool                   `json:"enableCodeSignin"`
+	EnableSamlCompress    bool                   `json:"enableSamlCompress"`
+	EnableSamlC14n10      bool                   `json:"enableSamlC14n10"`
+	EnableSamlPostBinding bool                   `json:"enableSamlPostBinding"`
+	UseEmailAsSamlNameId  bool                   `json:"useEmailAsSamlNameId"`
+	EnableWebAuthn        bool                   `json:"enableWebAuthn"`
+	EnableLinkWithEmail   bool                   `json:"enableLinkWithEmail"`
+	OrgChoiceMode         string                 `json:"orgChoiceMode"`
+	SamlReplyUrl          string                 `xorm:"varchar(100)" json:"samlReplyUrl"`
+	Providers             []*ProviderItem        `xorm:"mediumtext" json:"providers"`
+	SigninMethods         []*SigninMethod        `xorm:"varchar(2000)" json:"signinMethods"`
+	SignupItems           []*SignupItem          `xorm:"varchar(3000)" json:"signupItems"`
+	SigninItems           []*SigninItem          `xorm:"mediumtext" json:"signinItems"`
+	GrantTypes            []string               `xorm:"varchar(1000)" json:"grantTypes"`
+	OrganizationObj       *Organization          `xorm:"-" json:"organizationObj"`
+	CertPublicKey         string                 `xorm:"-" json:"certPublicKey"`
+	Tags                  []string               `xorm:"mediumtext" json:"tags"`
+	SamlAttributes        []*SamlItem            `xorm:"varchar(1000)" json:"samlAttributes"`
+	SamlServiceProviders  []*SamlServiceProvider `xorm:"mediumtext" json:"samlServiceProviders"`
+	IsShared              bool                   `json:"isShared"`
+	IpRestriction         string                 `json:"ipRestriction"`
 
 	ClientId                string     `xorm:"varchar(100)" json:"clientId"`
 	ClientSecret            string     `xorm:"varchar(100)" json:"clientSecret"`
@@ -736,6 +737,12 @@ func (application *Application) IsRedirectUriValid(redirectUri string) bool {
 		return true
 	}
 
+	return application.IsRedirectUriConfigured(redirectUri)
+}
+
+// IsRedirectUriConfigured checks that the redirect URI matches a redirect URI of the application, the local origins
+// allowed by IsRedirectUriValid aren't accepted
+func (application *Application) IsRedirectUriConfigured(redirectUri string) bool {
 	for _, targetUri := range application.RedirectUris {
 		targetUriRegex := regexp.MustCompile(targetUri)
 		if targetUriRegex.MatchString(redirectUri) || strings.Contains(redirectUri, targetUri) {
@@ -808,6 +815,17 @@ func (application *Application) IsLdapEnabled() bool {
 	return false
 }
 
+func (application *Application) IsKerberosEnabled() bool {
+	if len(application.SigninMethods) > 0 {
+		for _, signinMethod := range application.SigninMethods {
+			if signinMethod.Name == "Kerberos" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (application *Application) IsFaceIdEnabled() bool {
 	if len(application.SigninMethods) > 0 {
 		for _, signinMethod := range application.SigninMethods {
@@ -312,7 +312,7 @@ func CheckLdapUserPassword(user *User, password string, lang string) error {
 
 		hit = true
 		dn := searchResult.Entries[0].DN
-		if err = conn.Conn.Bind(dn, password); err == nil {
+		if err = conn.bindUser(dn, password); err == nil {
 			ldapLoginSuccess = true
 			conn.Close()
 			break
@@ -395,6 +395,61 @@ func CheckUserPassword(organization string, username string, password string, la
 	return user, nil
 }
 
+// CheckUserCredential checks the user with a function that verifies a response computed from the stored credential,
+// e.g. CHAP or MS-CHAPv2 of RADIUS, which never send the password. LDAP users are not supported because the LDAP
+// server can only check passwords.
+func CheckUserCredential(organization string, username string, lang string, verify func(user *User) (bool, error)) (*User, error) {
+	user, err := GetUserByFields(organization, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil || user.IsDeleted {
+		return nil, fmt.Errorf(i18n.Translate(lang, "general:The user: %s doesn't exist"), util.GetId(organization, username))
+	}
+
+	if user.IsForbidden {
+		return nil, fmt.Errorf(i18n.Translate(lang, "check:The user is forbidden to sign in, please contact the administrator"))
+	}
+
+	if user.Ldap != "" {
+		return nil, fmt.Errorf(i18n.Translate(lang, "check:password or code is incorrect"))
+	}
+
+	err = checkSigninErrorTimes(user, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := verify(user)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, recordSigninErrorInfo(user, lang)
+	}
+
+	err = resetUserSigninErrorTimes(user)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkPasswordExpired(user, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// CheckLdapServiceAccount returns an error for LDAP service accounts, which can only bind to the LDAP server
+func CheckLdapServiceAccount(user *User, lang string) error {
+	if user.IsLdapServiceAccount() {
+		return fmt.Errorf(i18n.Translate(lang, "check:LDAP service accounts can only bind to the LDAP server"))
+	}
+	return nil
+}
+
 func CheckUserPermission(requestUserId, userId string, strict bool, lang string) (bool, error) {
 	if requestUserId == "" {
 		return false, fmt.Errorf(i18n.Translate(lang, "general:Please login first"))
@@ -38,6 +38,7 @@ type Group struct {
 	ParentName   string   `xorm:"-" json:"parentName"`
 	IsTopGroup   bool     `xorm:"bool" json:"isTopGroup"`
 	Users        []string `xorm:"-" json:"users"`
+	Ldap         string   `xorm:"varchar(100)" json:"ldap"`
 
 	Title        string   `json:"title,omitempty"`
 	Key          string   `json:"key,omitempty"`
@@ -127,6 +128,25 @@ func GetGroup(id string) (*Group, error) {
 	return getGroup(owner, name)
 }
 
+// GetGroupByGroupNameOnly returns the group of the name in any organization, the group names are unique
+func GetGroupByGroupNameOnly(name string) (*Group, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	group := Group{Name: name}
+	existed, err := ormer.Engine.Get(&group)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &group, nil
+	} else {
+		return nil, nil
+	}
+}
+
 func UpdateGroup(id string, group *Group) (bool, error) {
 	owner, name := util.GetOwnerAndNameFromId(id)
 	oldGroup, err := getGroup(owner, name)
@@ -209,6 +229,132 @@ func DeleteGroup(group *Group) (bool, error) {
 	return deleteGroup(group)
 }
 
+// AddGroupWithMembers adds the group with the users of the ids as its members in a transaction, so the group isn't
+// added when a member can't join it, e.g. the members of a SCIM group
+func AddGroupWithMembers(group *Group, memberIds []string) (bool, error) {
+	err := checkGroupName(group.Name)
+	if err != nil {
+		return false, err
+	}
+
+	return updateGroupWithMembers(group, memberIds, "add")
+}
+
+// UpdateGroupWithMembers updates the group and makes the users of the ids its only members in a transaction, e.g. the
+// members of a SCIM group
+func UpdateGroupWithMembers(group *Group, memberIds []string) (bool, error) {
+	return updateGroupWithMembers(group, memberIds, "update")
+}
+
+// DeleteGroupWithMembers removes the group from the groups of its members and deletes it in a transaction
+func DeleteGroupWithMembers(group *Group) (bool, error) {
+	if count, err := ormer.Engine.Where("parent_id = ?", group.Name).Count(&Group{}); err != nil {
+		return false, err
+	} else if count > 0 {
+		return false, errors.New("group has children group")
+	}
+
+	return updateGroupWithMembers(group, []string{}, "delete")
+}
+
+// getGroupMemberChanges returns the users whose groups change when the users of the ids become the only members of
+// the group, before and after the change
+func getGroupMemberChanges(group *Group, memberIds []string) ([]*User, []*User, error) {
+	groupId := group.GetId()
+	members, err := GetGroupUsers(groupId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	oldUsers := []*User{}
+	users := []*User{}
+	addChange := func(user *User, groups []string) {
+		newUser := *user
+		newUser.Groups = groups
+		oldUsers = append(oldUsers, user)
+		users = append(users, &newUser)
+	}
+
+	oldMemberIds := []string{}
+	for _, user := range members {
+		oldMemberIds = append(oldMemberIds, user.Id)
+		if !util.InSlice(memberIds, user.Id) {
+			addChange(user, util.DeleteVal(user.Groups, groupId))
+		}
+	}
+
+	for _, memberId := range memberIds {
+		if util.InSlice(oldMemberIds, memberId) {
+			continue
+		}
+
+		user, err := GetUserByUserIdOnly(memberId)
+		if err != nil {
+			return nil, nil, err
+		}
+		if user == nil {
+			return nil, nil, fmt.Errorf("the user: %s doesn't exist", memberId)
+		}
+		if user.Owner != group.Owner {
+			return nil, nil, fmt.Errorf("the user: %s is not in the organization: %s of the group: %s", user.GetId(), group.Owner, group.Name)
+		}
+
+		addChange(user, append(append([]string{}, user.Groups...), groupId))
+	}
+	return oldUsers, users, nil
+}
+
+// updateGroupWithMembers saves the group and its members in a transaction, action is "add", "update" or "delete"
+func updateGroupWithMembers(group *Group, memberIds []string, action string) (bool, error) {
+	oldUsers, users, err := getGroupMemberChanges(group, memberIds)
+	if err != nil {
+		return false, err
+	}
+
+	session := ormer.Engine.NewSession()
+	defer session.Close()
+	err = session.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	for _, user := range users {
+		user.UpdatedTime = util.GetCurrentTime()
+		_, err = session.ID(core.PK{user.Owner, user.Name}).Cols("groups", "updated_time").Update(user)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	var affected int64
+	switch action {
+	case "add":
+		affected, err = session.Insert(group)
+	case "delete":
+		affected, err = session.ID(core.PK{group.Owner, group.Name}).Delete(&Group{})
+	default:
+		affected, err = session.ID(core.PK{group.Owner, group.Name}).AllCols().Update(group)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	err = session.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	for i, user := range users {
+		_, err = userEnforcer.UpdateGroupsForUser(user.GetId(), user.Groups)
+		if err != nil {
+			return false, err
+		}
+
+		userUpdatedTrigger(oldUsers[i], user.Owner, user.Name, []string{"groups"}, "")
+	}
+	return affected != 0, nil
+}
+
 func checkGroupName(name string) error {
 	exist, err := ormer.Engine.Exist(&Organization{Owner: "admin", Name: name})
 	if err != nil {
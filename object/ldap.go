@@ -26,6 +26,9 @@ type Ldap struct {
 	ServerName   string   `xorm:"varchar(100)" json:"serverName"`
 	Host         string   `xorm:"varchar(100)" json:"host"`
 	Port         int      `xorm:"int" json:"port"`
+	Hosts        []string `xorm:"varchar(500)" json:"hosts"`
+	HostStrategy string   `xorm:"varchar(100)" json:"hostStrategy"`
+	PoolSize     int      `json:"poolSize"`
 	EnableSsl    bool     `xorm:"bool" json:"enableSsl"`
 	Username     string   `xorm:"varchar(100)" json:"username"`
 	Password     string   `xorm:"varchar(100)" json:"password"`
@@ -35,8 +38,29 @@ type Ldap struct {
 	DefaultGroup string   `xorm:"varchar(100)" json:"defaultGroup"`
 	PasswordType string   `xorm:"varchar(100)" json:"passwordType"`
 
-	AutoSync int    `json:"autoSync"`
-	LastSync string `xorm:"varchar(100)" json:"lastSync"`
+	AttributeMappings []*LdapAttributeMapping `xorm:"mediumtext" json:"attributeMappings"`
+
+	EnableGroupSync bool   `xorm:"bool" json:"enableGroupSync"`
+	GroupBaseDn     string `xorm:"varchar(100)" json:"groupBaseDn"`
+	GroupFilter     string `xorm:"varchar(200)" json:"groupFilter"`
+	GroupParent     string `xorm:"varchar(100)" json:"groupParent"`
+
+	EnableProvisioning        bool                    `xorm:"bool" json:"enableProvisioning"`
+	ProvisioningDnTemplate    string                  `xorm:"varchar(200)" json:"provisioningDnTemplate"`
+	ProvisioningObjectClasses []string                `xorm:"varchar(200)" json:"provisioningObjectClasses"`
+	ProvisioningAttributes    []*LdapAttributeMapping `xorm:"mediumtext" json:"provisioningAttributes"`
+
+	EnableKerberos        bool   `xorm:"bool" json:"enableKerberos"`
+	KerberosKeytab        string `xorm:"mediumtext" json:"kerberosKeytab"`
+	KerberosPrincipal     string `xorm:"varchar(100)" json:"kerberosPrincipal"`
+	KerberosUserAttribute string `xorm:"varchar(100)" json:"kerberosUserAttribute"`
+
+	AutoSync       int            `json:"autoSync"`
+	LastSync       string         `xorm:"varchar(100)" json:"lastSync"`
+	SyncMode       string         `xorm:"varchar(100)" json:"syncMode"`
+	DeletionPolicy string         `xorm:"varchar(100)" json:"deletionPolicy"`
+	SyncWatermark  string         `xorm:"varchar(500)" json:"syncWatermark"`
+	SyncStats      *LdapSyncStats `xorm:"json" json:"syncStats"`
 }
 
 func AddLdap(ldap *Ldap) (bool, error) {
@@ -53,6 +77,8 @@ func AddLdap(ldap *Ldap) (bool, error) {
 		return false, err
 	}
 
+	clearLdapProvisioningCache()
+
 	return affected != 0, nil
 }
 
@@ -117,6 +143,9 @@ func GetMaskedLdap(ldap *Ldap, errs ...error) (*Ldap, error) {
 	if ldap.Password != "" {
 		ldap.Password = "***"
 	}
+	if ldap.KerberosKeytab != "" {
+		ldap.KerberosKeytab = "***"
+	}
 
 	return ldap, nil
 }
@@ -148,13 +177,30 @@ func UpdateLdap(ldap *Ldap) (bool, error) {
 	if ldap.Password == "***" {
 		ldap.Password = l.Password
 	}
+	if ldap.KerberosKeytab == "***" {
+		ldap.KerberosKeytab = l.KerberosKeytab
+	}
+
+	columns := []string{"owner", "server_name", "host",
+		"port", "enable_ssl", "username", "password", "base_dn", "filter", "filter_fields", "auto_sync", "default_group", "password_type",
+		"enable_group_sync", "group_base_dn", "group_filter", "group_parent", "sync_mode", "deletion_policy", "attribute_mappings",
+		"hosts", "host_strategy", "pool_size", "enable_provisioning", "provisioning_dn_template", "provisioning_object_classes",
+		"provisioning_attributes", "enable_kerberos", "kerberos_keytab", "kerberos_principal", "kerberos_user_attribute"}
+
+	// the watermark of the incremental sync is only valid for the same directory and filter
+	if ldap.Host != l.Host || ldap.Port != l.Port || ldap.BaseDn != l.BaseDn || ldap.Filter != l.Filter || ldap.SyncMode != l.SyncMode {
+		ldap.SyncWatermark = ""
+		columns = append(columns, "sync_watermark")
+	}
 
-	affected, err := ormer.Engine.ID(ldap.Id).Cols("owner", "server_name", "host",
-		"port", "enable_ssl", "username", "password", "base_dn", "filter", "filter_fields", "auto_sync", "default_group", "password_type").Update(ldap)
+	affected, err := ormer.Engine.ID(ldap.Id).Cols(columns...).Update(ldap)
 	if err != nil {
 		return false, nil
 	}
 
+	CloseLdapPool(ldap.Id)
+	clearLdapProvisioningCache()
+
 	return affected != 0, nil
 }
 
@@ -164,5 +210,8 @@ func DeleteLdap(ldap *Ldap) (bool, error) {
 		return false, err
 	}
 
+	CloseLdapPool(ldap.Id)
+	clearLdapProvisioningCache()
+
 	return affected != 0, nil
 }
@@ -0,0 +1,317 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+const ldapAttributePropertiesPrefix = "Properties."
+
+// LdapAttributeMapping maps LDAP attributes to a field of the user, the attributes in Name are separated by "|" to use
+// the first one that is not empty, or by "+" to join them with a space
+type LdapAttributeMapping struct {
+	Name        string `json:"name"`
+	CasdoorName string `json:"casdoorName"`
+	Transform   string `json:"transform"`
+	Value       string `json:"value"`
+}
+
+// the user fields that can be mapped, in addition to "Properties.<key>"
+var ldapAttributeCasdoorNames = []string{
+	"DisplayName", "FirstName", "LastName", "Email", "Phone", "CountryCode", "Avatar", "Location", "Address",
+	"Affiliation", "Title", "IdCardType", "IdCard", "Homepage", "Bio", "Tag", "Region", "Language", "Gender",
+	"Birthday", "Education",
+}
+
+func (attribute *LdapAttributeMapping) getNames() []string {
+	res := []string{}
+	for _, name := range strings.FieldsFunc(attribute.Name, func(r rune) bool { return r == '|' || r == '+' }) {
+		res = append(res, strings.TrimSpace(name))
+	}
+	return res
+}
+
+// getValue returns the value of the user field, regexes are the compiled regexes of the attribute mappings by pattern
+func (attribute *LdapAttributeMapping) getValue(ldapUser *LdapUser, regexes map[string]*regexp.Regexp) (string, error) {
+	getValues := func(name string) []string {
+		for key, values := range ldapUser.Attributes {
+			if strings.EqualFold(key, name) {
+				return values
+			}
+		}
+		return nil
+	}
+
+	values := []string{}
+	if strings.Contains(attribute.Name, "+") {
+		parts := []string{}
+		for _, name := range attribute.getNames() {
+			if v := getValues(name); len(v) > 0 && v[0] != "" {
+				parts = append(parts, v[0])
+			}
+		}
+		values = append(values, strings.Join(parts, " "))
+	} else {
+		for _, name := range attribute.getNames() {
+			if v := getValues(name); len(v) > 0 && v[0] != "" {
+				values = v
+				break
+			}
+		}
+	}
+
+	if len(values) == 0 {
+		return "", nil
+	}
+
+	value := values[0]
+	switch attribute.Transform {
+	case "Lowercase":
+		value = strings.ToLower(value)
+	case "Uppercase":
+		value = strings.ToUpper(value)
+	case "Regex":
+		re, ok := regexes[attribute.Value]
+		if !ok {
+			return "", fmt.Errorf("the regex of the LDAP attribute: %s is not compiled", attribute.Name)
+		}
+
+		// the first group is extracted if there is one, e.g. "^cn=([^,]+)" for the name of a manager
+		match := re.FindStringSubmatch(value)
+		if len(match) == 0 {
+			return "", nil
+		} else if len(match) > 1 {
+			value = match[1]
+		} else {
+			value = match[0]
+		}
+	case "Join":
+		separator := attribute.Value
+		if separator == "" {
+			separator = ","
+		}
+		value = strings.Join(values, separator)
+	}
+
+	return value, nil
+}
+
+func (ldap *Ldap) CheckAttributeMappings() error {
+	for _, attribute := range ldap.AttributeMappings {
+		if len(attribute.getNames()) == 0 {
+			return fmt.Errorf("the LDAP attribute of %s is empty", attribute.CasdoorName)
+		}
+
+		if !strings.HasPrefix(attribute.CasdoorName, ldapAttributePropertiesPrefix) && !util.InSlice(ldapAttributeCasdoorNames, attribute.CasdoorName) {
+			return fmt.Errorf("the user field: %s is not supported for LDAP attributes", attribute.CasdoorName)
+		}
+
+		switch attribute.Transform {
+		case "", "Lowercase", "Uppercase", "Join":
+		case "Regex":
+			if _, err := regexp.Compile(attribute.Value); err != nil {
+				return fmt.Errorf("the regex of the LDAP attribute: %s is invalid: %s", attribute.Name, err.Error())
+			}
+		default:
+			return fmt.Errorf("the transform: %s of the LDAP attribute: %s is not supported", attribute.Transform, attribute.Name)
+		}
+	}
+
+	// the provisioning attributes are written to a single LDAP attribute, and the name of the user can be written
+	for _, attribute := range ldap.ProvisioningAttributes {
+		if strings.TrimSpace(attribute.Name) == "" || strings.ContainsAny(attribute.Name, "|+") {
+			return fmt.Errorf("the LDAP attribute: %s of %s is invalid for provisioning", attribute.Name, attribute.CasdoorName)
+		}
+
+		if attribute.CasdoorName != "Name" && !strings.HasPrefix(attribute.CasdoorName, ldapAttributePropertiesPrefix) && !util.InSlice(ldapAttributeCasdoorNames, attribute.CasdoorName) {
+			return fmt.Errorf("the user field: %s is not supported for LDAP attributes", attribute.CasdoorName)
+		}
+
+		switch attribute.Transform {
+		case "", "Lowercase", "Uppercase":
+		default:
+			return fmt.Errorf("the transform: %s of the LDAP attribute: %s is not supported for provisioning", attribute.Transform, attribute.Name)
+		}
+	}
+
+	for _, match := range ldapDnTemplateRegex.FindAllStringSubmatch(ldap.ProvisioningDnTemplate, -1) {
+		casdoorName := match[1]
+		if casdoorName != "Name" && !strings.HasPrefix(casdoorName, ldapAttributePropertiesPrefix) && !util.InSlice(ldapAttributeCasdoorNames, casdoorName) {
+			return fmt.Errorf("the user field: %s is not supported in the DN template", casdoorName)
+		}
+	}
+	return nil
+}
+
+// compileAttributeMappingRegexes compiles the regexes of the attribute mappings once for all the users of a sync
+func (ldap *Ldap) compileAttributeMappingRegexes() (map[string]*regexp.Regexp, error) {
+	regexes := map[string]*regexp.Regexp{}
+	for _, attribute := range ldap.AttributeMappings {
+		if attribute.Transform != "Regex" || regexes[attribute.Value] != nil {
+			continue
+		}
+
+		re, err := regexp.Compile(attribute.Value)
+		if err != nil {
+			return nil, fmt.Errorf("the regex of the LDAP attribute: %s is invalid: %s", attribute.Name, err.Error())
+		}
+		regexes[attribute.Value] = re
+	}
+	return regexes, nil
+}
+
+// isMappedCasdoorName returns true if an attribute mapping sets the user field, the field is then no longer
+// set from the default LDAP attributes
+func (ldap *Ldap) isMappedCasdoorName(casdoorName string) bool {
+	for _, attribute := range ldap.AttributeMappings {
+		if attribute.CasdoorName == casdoorName {
+			return true
+		}
+	}
+	return false
+}
+
+// getMappedAttributeNames returns the LDAP attributes to fetch for the attribute mapping
+func (ldap *Ldap) getMappedAttributeNames() []string {
+	res := []string{}
+	for _, attribute := range ldap.AttributeMappings {
+		res = append(res, attribute.getNames()...)
+	}
+	return res
+}
+
+func setUserFieldByLdapAttribute(user *User, casdoorName string, value string) (string, bool) {
+	if strings.HasPrefix(casdoorName, ldapAttributePropertiesPrefix) {
+		key := strings.TrimPrefix(casdoorName, ldapAttributePropertiesPrefix)
+		if user.Properties == nil {
+			user.Properties = map[string]string{}
+		}
+		if user.Properties[key] == value {
+			return "properties", false
+		}
+		user.Properties[key] = value
+		return "properties", true
+	}
+
+	if casdoorName == "Address" {
+		if len(user.Address) == 1 && user.Address[0] == value {
+			return "address", false
+		}
+		user.Address = []string{value}
+		return "address", true
+	}
+
+	field := getUserStringField(user, casdoorName)
+	if field == nil {
+		return "", false
+	}
+
+	if *field == value {
+		return "", false
+	}
+	*field = value
+	return util.CamelToSnakeCase(casdoorName), true
+}
+
+func getUserStringField(user *User, casdoorName string) *string {
+	switch casdoorName {
+	case "Name":
+		return &user.Name
+	case "DisplayName":
+		return &user.DisplayName
+	case "FirstName":
+		return &user.FirstName
+	case "LastName":
+		return &user.LastName
+	case "Email":
+		return &user.Email
+	case "Phone":
+		return &user.Phone
+	case "CountryCode":
+		return &user.CountryCode
+	case "Avatar":
+		return &user.Avatar
+	case "Location":
+		return &user.Location
+	case "Affiliation":
+		return &user.Affiliation
+	case "Title":
+		return &user.Title
+	case "IdCardType":
+		return &user.IdCardType
+	case "IdCard":
+		return &user.IdCard
+	case "Homepage":
+		return &user.Homepage
+	case "Bio":
+		return &user.Bio
+	case "Tag":
+		return &user.Tag
+	case "Region":
+		return &user.Region
+	case "Language":
+		return &user.Language
+	case "Gender":
+		return &user.Gender
+	case "Birthday":
+		return &user.Birthday
+	case "Education":
+		return &user.Education
+	default:
+		return nil
+	}
+}
+
+// getUserFieldForLdapAttribute returns the value of a user field for the LDAP attributes
+func getUserFieldForLdapAttribute(user *User, casdoorName string) string {
+	if strings.HasPrefix(casdoorName, ldapAttributePropertiesPrefix) {
+		return user.Properties[strings.TrimPrefix(casdoorName, ldapAttributePropertiesPrefix)]
+	}
+
+	if casdoorName == "Address" {
+		return strings.Join(user.Address, " ")
+	}
+
+	if field := getUserStringField(user, casdoorName); field != nil {
+		return *field
+	}
+	return ""
+}
+
+// applyAttributeMappings sets the mapped fields of the user from the LDAP user, and returns the changed columns.
+// Empty values are skipped, so that a missing attribute doesn't clear the field.
+func (ldap *Ldap) applyAttributeMappings(user *User, ldapUser *LdapUser, regexes map[string]*regexp.Regexp) ([]string, error) {
+	columns := []string{}
+	for _, attribute := range ldap.AttributeMappings {
+		value, err := attribute.getValue(ldapUser, regexes)
+		if err != nil {
+			return nil, err
+		}
+		if value == "" {
+			continue
+		}
+
+		column, changed := setUserFieldByLdapAttribute(user, attribute.CasdoorName, value)
+		if changed && !util.InSlice(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+	return columns, nil
+}
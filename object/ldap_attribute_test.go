@@ -0,0 +1,75 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyAttributeMappings(t *testing.T) {
+	ldapServer := &Ldap{
+		AttributeMappings: []*LdapAttributeMapping{
+			{Name: "givenName+sn", CasdoorName: "DisplayName"},
+			{Name: "mail", CasdoorName: "Email", Transform: "Lowercase"},
+			{Name: "employeeNumber", CasdoorName: "Properties.employeeNumber"},
+			{Name: "manager", CasdoorName: "Properties.manager", Transform: "Regex", Value: "^cn=([^,]+)"},
+			{Name: "departmentNumber", CasdoorName: "Affiliation", Transform: "Join", Value: ";"},
+			{Name: "nickName|cn", CasdoorName: "Title"},
+			{Name: "missing", CasdoorName: "Bio"},
+		},
+	}
+	assert.Nil(t, ldapServer.CheckAttributeMappings())
+
+	ldapUser := &LdapUser{
+		Attributes: map[string][]string{
+			"givenName":        {"Alice"},
+			"sn":               {"Smith"},
+			"mail":             {"Alice@Example.com"},
+			"employeeNumber":   {"1001"},
+			"manager":          {"cn=Bob,ou=people,dc=example,dc=com"},
+			"departmentNumber": {"10", "20"},
+			"CN":               {"alice"},
+		},
+	}
+	regexes, err := ldapServer.compileAttributeMappingRegexes()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(regexes))
+
+	user := &User{Bio: "bio", Title: "title"}
+	columns, err := ldapServer.applyAttributeMappings(user, ldapUser, regexes)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"display_name", "email", "properties", "affiliation", "title"}, columns)
+	assert.Equal(t, "Alice Smith", user.DisplayName)
+	assert.Equal(t, "alice@example.com", user.Email)
+	assert.Equal(t, map[string]string{"employeeNumber": "1001", "manager": "Bob"}, user.Properties)
+	assert.Equal(t, "10;20", user.Affiliation)
+	assert.Equal(t, "alice", user.Title)
+	assert.Equal(t, "bio", user.Bio)
+
+	columns, err = ldapServer.applyAttributeMappings(user, ldapUser, regexes)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, columns)
+
+	assert.True(t, ldapServer.isMappedCasdoorName("DisplayName"))
+	assert.True(t, ldapServer.isMappedCasdoorName("Email"))
+	assert.False(t, ldapServer.isMappedCasdoorName("Phone"))
+
+	ldapServer.AttributeMappings = []*LdapAttributeMapping{{Name: "uid", CasdoorName: "Password"}}
+	assert.NotNil(t, ldapServer.CheckAttributeMappings())
+	ldapServer.AttributeMappings = []*LdapAttributeMapping{{Name: "uid", CasdoorName: "Tag", Transform: "Regex", Value: "("}}
+	assert.NotNil(t, ldapServer.CheckAttributeMappings())
+}
@@ -91,26 +91,18 @@ func (l *LdapAutoSynchronizer) syncRoutine(ldap *Ldap, stopChan chan struct{}) e
 			return err
 		}
 
-		// fetch all users
 		conn, err := ldap.GetLdapConn()
 		if err != nil {
 			logs.Warning(fmt.Sprintf("autoSync failed for %s, error %s", ldap.Id, err))
 			continue
 		}
 
-		users, err := conn.GetLdapUsers(ldap)
+		stats, err := SyncLdapServer(ldap, conn)
 		if err != nil {
-			conn.Close()
 			logs.Warning(fmt.Sprintf("autoSync failed for %s, error %s", ldap.Id, err))
-			continue
-		}
-
-		existed, failed, err := SyncLdapUsers(ldap.Owner, AutoAdjustLdapUser(users), ldap.Id)
-		if len(failed) != 0 {
-			logs.Warning(fmt.Sprintf("ldap autosync,%d new users,but %d user failed during :", len(users)-len(existed)-len(failed), len(failed)), failed)
-			logs.Warning(err.Error())
 		} else {
-			logs.Info(fmt.Sprintf("ldap autosync success, %d new users, %d existing users", len(users)-len(existed), len(existed)))
+			logs.Info(fmt.Sprintf("ldap autosync success for %s, %s mode, %d fetched users, %d new users, %d updated users, %d failed users, %d disabled users, %d deleted users",
+				ldap.Id, stats.Mode, stats.Fetched, stats.Added, stats.Updated, stats.Failed, stats.Disabled, stats.Deleted))
 		}
 
 		conn.Close()
@@ -16,10 +16,13 @@ package object
 
 import (
 	"crypto/md5"
+	"crypto/tls"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"net"
 	"strings"
+	"time"
 
 	"github.com/casdoor/casdoor/conf"
 	"github.com/casdoor/casdoor/i18n"
@@ -32,14 +35,16 @@ import (
 type LdapConn struct {
 	Conn *goldap.Conn
 	IsAD bool
-}
 
-//type ldapGroup struct {
-//	GidNumber string
-//	Cn        string
-//}
+	address     string
+	ldap        *Ldap
+	pool        *ldapPool
+	isUserBound bool
+	idleTime    time.Time
+}
 
 type LdapUser struct {
+	Dn        string `json:"dn"`
 	UidNumber string `json:"uidNumber"`
 	Uid       string `json:"uid"`
 	Cn        string `json:"cn"`
@@ -57,33 +62,79 @@ type LdapUser struct {
 	RegisteredAddress     string
 	PostalAddress         string
 
-	GroupId  string `json:"groupId"`
-	Address  string `json:"address"`
-	MemberOf string `json:"memberOf"`
+	GroupId   string   `json:"groupId"`
+	Address   string   `json:"address"`
+	MemberOf  string   `json:"memberOf"`
+	MemberOfs []string `json:"-"`
+
+	ModifyTimestamp string              `json:"-"`
+	Attributes      map[string][]string `json:"attributes,omitempty"`
+}
+
+// GetLdapConn returns a connection bound as the admin of the LDAP server, an idle connection of the pool is reused
+// if there is one, otherwise the hosts are dialed in the order of the host strategy until one succeeds
+func (ldap *Ldap) GetLdapConn() (*LdapConn, error) {
+	pool := getLdapPool(ldap)
+	if conn := pool.get(); conn != nil {
+		return conn, nil
+	}
+
+	var err error
+	for _, address := range pool.getAddresses(ldap) {
+		var conn *LdapConn
+		conn, err = ldap.dialLdapConn(address)
+		if err != nil {
+			pool.setHostHealth(address, err)
+			if isLdapHostError(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		pool.setHostHealth(address, nil)
+		conn.pool = pool
+		return conn, nil
+	}
+
+	return nil, err
 }
 
-func (ldap *Ldap) GetLdapConn() (c *LdapConn, err error) {
-	var conn *goldap.Conn
+func (ldap *Ldap) dialLdapConn(address string) (*LdapConn, error) {
+	dialer := &net.Dialer{Timeout: ldapDialTimeout}
+
+	var netConn net.Conn
+	var err error
 	if ldap.EnableSsl {
-		conn, err = goldap.DialTLS("tcp", fmt.Sprintf("%s:%d", ldap.Host, ldap.Port), nil)
+		netConn, err = tls.DialWithDialer(dialer, "tcp", address, nil)
 	} else {
-		conn, err = goldap.Dial("tcp", fmt.Sprintf("%s:%d", ldap.Host, ldap.Port))
+		netConn, err = dialer.Dial("tcp", address)
 	}
-
 	if err != nil {
-		return nil, err
+		return nil, goldap.NewError(goldap.ErrorNetwork, err)
 	}
 
+	conn := goldap.NewConn(netConn, ldap.EnableSsl)
+	conn.Start()
+
 	err = conn.Bind(ldap.Username, ldap.Password)
 	if err != nil {
+		conn.Close()
 		return nil, err
 	}
 
 	isAD, err := isMicrosoftAD(conn)
 	if err != nil {
+		conn.Close()
 		return nil, err
 	}
-	return &LdapConn{Conn: conn, IsAD: isAD}, nil
+	return &LdapConn{Conn: conn, IsAD: isAD, address: address, ldap: ldap}, nil
+}
+
+// bindUser binds the connection as a user to check the password, the connection is bound as the admin again
+// before it goes back to the pool
+func (l *LdapConn) bindUser(dn string, password string) error {
+	l.isUserBound = true
+	return l.Conn.Bind(dn, password)
 }
 
 func (l *LdapConn) Close() {
@@ -91,6 +142,14 @@ func (l *LdapConn) Close() {
 		return
 	}
 
+	if l.pool != nil && l.pool.put(l) {
+		return
+	}
+
+	if l.Conn.IsClosing() {
+		return
+	}
+
 	err := l.Conn.Unbind()
 	if err != nil {
 		panic(err)
@@ -143,10 +202,11 @@ func isMicrosoftAD(Conn *goldap.Conn) (bool, error) {
 	return isMicrosoft, err
 }
 
-func (l *LdapConn) GetLdapUsers(ldapServer *Ldap) ([]LdapUser, error) {
+func (l *LdapConn) getLdapUserAttributes(ldapServer *Ldap) []string {
 	SearchAttributes := []string{
 		"uidNumber", "cn", "sn", "gidNumber", "entryUUID", "displayName", "mail", "email",
-		"emailAddress", "telephoneNumber", "mobile", "mobileTelephoneNumber", "registeredAddress", "postalAddress",
+		"emailAddress", "telephoneNumber", "mobile", "mobileTelephoneNumber", "registeredAddress", "postalAddress", "memberOf",
+		"modifyTimestamp",
 	}
 	if l.IsAD {
 		SearchAttributes = append(SearchAttributes, "sAMAccountName")
@@ -154,22 +214,41 @@ func (l *LdapConn) GetLdapUsers(ldapServer *Ldap) ([]LdapUser, error) {
 		SearchAttributes = append(SearchAttributes, "uid")
 	}
 
-	searchReq := goldap.NewSearchRequest(ldapServer.BaseDn, goldap.ScopeWholeSubtree, goldap.NeverDerefAliases,
-		0, 0, false,
-		ldapServer.Filter, SearchAttributes, nil)
-	searchResult, err := l.Conn.SearchWithPaging(searchReq, 100)
+	for _, name := range ldapServer.getMappedAttributeNames() {
+		if !util.InSlice(SearchAttributes, name) {
+			SearchAttributes = append(SearchAttributes, name)
+		}
+	}
+	return SearchAttributes
+}
+
+func (l *LdapConn) GetLdapUsers(ldapServer *Ldap) ([]LdapUser, error) {
+	ldapUsers, err := l.searchLdapUsers(ldapServer, ldapServer.Filter, l.getLdapUserAttributes(ldapServer))
 	if err != nil {
 		return nil, err
 	}
 
-	if len(searchResult.Entries) == 0 {
+	if len(ldapUsers) == 0 {
 		return nil, errors.New("no result")
 	}
 
+	return ldapUsers, nil
+}
+
+func (l *LdapConn) searchLdapUsers(ldapServer *Ldap, filter string, attributes []string) ([]LdapUser, error) {
+	searchReq := goldap.NewSearchRequest(ldapServer.BaseDn, goldap.ScopeWholeSubtree, goldap.NeverDerefAliases,
+		0, 0, false,
+		filter, attributes, nil)
+	searchResult, err := l.Conn.SearchWithPaging(searchReq, 100)
+	if err != nil {
+		return nil, err
+	}
+
 	var ldapUsers []LdapUser
 	for _, entry := range searchResult.Entries {
-		var user LdapUser
+		user := LdapUser{Dn: entry.DN, Attributes: map[string][]string{}}
 		for _, attribute := range entry.Attributes {
+			user.Attributes[attribute.Name] = attribute.Values
 			switch attribute.Name {
 			case "uidNumber":
 				user.UidNumber = attribute.Values[0]
@@ -207,6 +286,9 @@ func (l *LdapConn) GetLdapUsers(ldapServer *Ldap) ([]LdapUser, error) {
 				user.PostalAddress = attribute.Values[0]
 			case "memberOf":
 				user.MemberOf = attribute.Values[0]
+				user.MemberOfs = attribute.Values
+			case "modifyTimestamp":
+				user.ModifyTimestamp = attribute.Values[0]
 			}
 		}
 		ldapUsers = append(ldapUsers, user)
@@ -215,47 +297,11 @@ func (l *LdapConn) GetLdapUsers(ldapServer *Ldap) ([]LdapUser, error) {
 	return ldapUsers, nil
 }
 
-// FIXME: The Base DN does not necessarily contain the Group
-//
-//	func (l *ldapConn) GetLdapGroups(baseDn string) (map[string]ldapGroup, error) {
-//		SearchFilter := "(objectClass=posixGroup)"
-//		SearchAttributes := []string{"cn", "gidNumber"}
-//		groupMap := make(map[string]ldapGroup)
-//
-//		searchReq := goldap.NewSearchRequest(baseDn,
-//			goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 0, 0, false,
-//			SearchFilter, SearchAttributes, nil)
-//		searchResult, err := l.Conn.Search(searchReq)
-//		if err != nil {
-//			return nil, err
-//		}
-//
-//		if len(searchResult.Entries) == 0 {
-//			return nil, errors.New("no result")
-//		}
-//
-//		for _, entry := range searchResult.Entries {
-//			var ldapGroupItem ldapGroup
-//			for _, attribute := range entry.Attributes {
-//				switch attribute.Name {
-//				case "gidNumber":
-//					ldapGroupItem.GidNumber = attribute.Values[0]
-//					break
-//				case "cn":
-//					ldapGroupItem.Cn = attribute.Values[0]
-//					break
-//				}
-//			}
-//			groupMap[ldapGroupItem.GidNumber] = ldapGroupItem
-//		}
-//
-//		return groupMap, nil
-//	}
-
 func AutoAdjustLdapUser(users []LdapUser) []LdapUser {
 	res := make([]LdapUser, len(users))
 	for i, user := range users {
 		res[i] = LdapUser{
+			Dn:                user.Dn,
 			UidNumber:         user.UidNumber,
 			Uid:               user.Uid,
 			Cn:                user.Cn,
@@ -265,6 +311,9 @@ func AutoAdjustLdapUser(users []LdapUser) []LdapUser {
 			Email:             util.ReturnAnyNotEmpty(user.Email, user.EmailAddress, user.Mail),
 			Mobile:            util.ReturnAnyNotEmpty(user.Mobile, user.MobileTelephoneNumber, user.TelephoneNumber),
 			RegisteredAddress: util.ReturnAnyNotEmpty(user.PostalAddress, user.RegisteredAddress),
+			MemberOf:          user.MemberOf,
+			MemberOfs:         user.MemberOfs,
+			Attributes:        user.Attributes,
 		}
 	}
 	return res
@@ -299,23 +348,25 @@ func SyncLdapUsers(owner string, syncUsers []LdapUser, ldapId string) (existUser
 	}
 	tag := strings.Join(ou, ".")
 
-	for _, syncUser := range syncUsers {
-		existUuids, err := GetExistUuids(owner, uuids)
-		if err != nil {
-			return nil, nil, err
-		}
+	existUuids, err := GetExistUuids(owner, uuids)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		found := false
-		if len(existUuids) > 0 {
-			for _, existUuid := range existUuids {
-				if syncUser.Uuid == existUuid {
-					existUsers = append(existUsers, syncUser)
-					found = true
-				}
-			}
-		}
+	regexes, err := ldap.compileAttributeMappingRegexes()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existUuidMap := map[string]bool{}
+	for _, existUuid := range existUuids {
+		existUuidMap[existUuid] = true
+	}
 
-		if !found {
+	for _, syncUser := range syncUsers {
+		if existUuidMap[syncUser.Uuid] {
+			existUsers = append(existUsers, syncUser)
+		} else {
 			score, err := organization.GetInitScore()
 			if err != nil {
 				return nil, nil, err
@@ -347,6 +398,11 @@ func SyncLdapUsers(owner string, syncUsers []LdapUser, ldapId string) (existUser
 				newUser.Groups = []string{ldap.DefaultGroup}
 			}
 
+			_, err = ldap.applyAttributeMappings(newUser, &syncUser, regexes)
+			if err != nil {
+				return nil, nil, err
+			}
+
 			affected, err := AddUser(newUser)
 			if err != nil {
 				return nil, nil, err
@@ -356,6 +412,7 @@ func SyncLdapUsers(owner string, syncUsers []LdapUser, ldapId string) (existUser
 				failedUsers = append(failedUsers, syncUser)
 				continue
 			}
+			existUuidMap[syncUser.Uuid] = true
 		}
 	}
 
@@ -365,11 +422,21 @@ func SyncLdapUsers(owner string, syncUsers []LdapUser, ldapId string) (existUser
 func GetExistUuids(owner string, uuids []string) ([]string, error) {
 	var existUuids []string
 
+	// query in batches, large directories exceed the limit of the placeholders of a statement
 	tableNamePrefix := conf.GetConfigString("tableNamePrefix")
-	err := ormer.Engine.Table(tableNamePrefix+"user").Where("owner = ?", owner).Cols("ldap").
-		In("ldap", uuids).Select("DISTINCT ldap").Find(&existUuids)
-	if err != nil {
-		return existUuids, err
+	for i := 0; i < len(uuids); i += 1000 {
+		end := i + 1000
+		if end > len(uuids) {
+			end = len(uuids)
+		}
+
+		var batch []string
+		err := ormer.Engine.Table(tableNamePrefix+"user").Where("owner = ?", owner).Cols("ldap").
+			In("ldap", uuids[i:end]).Select("DISTINCT ldap").Find(&batch)
+		if err != nil {
+			return existUuids, err
+		}
+		existUuids = append(existUuids, batch...)
 	}
 
 	return existUuids, nil
@@ -410,8 +477,7 @@ func ResetLdapPassword(user *User, oldPassword string, newPassword string, lang
 		var pwdEncoded string
 		modifyPasswordRequest := goldap.NewModifyRequest(userDn, nil)
 		if conn.IsAD {
-			utf16 := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
-			pwdEncoded, err := utf16.NewEncoder().String("\"" + newPassword + "\"")
+			pwdEncoded, err := encodeAdPassword(newPassword)
 			if err != nil {
 				conn.Close()
 				return err
@@ -428,21 +494,10 @@ func ResetLdapPassword(user *User, oldPassword string, newPassword string, lang
 			conn.Close()
 			return nil
 		} else {
-			switch ldapServer.PasswordType {
-			case "SSHA":
-				pwdEncoded, err = generateSSHA(newPassword)
-				break
-			case "MD5":
-				md5Byte := md5.Sum([]byte(newPassword))
-				md5Password := base64.StdEncoding.EncodeToString(md5Byte[:])
-				pwdEncoded = "{MD5}" + md5Password
-				break
-			case "Plain":
-				pwdEncoded = newPassword
-				break
-			default:
-				pwdEncoded = newPassword
-				break
+			pwdEncoded, err = ldapServer.encodeLdapPassword(newPassword)
+			if err != nil {
+				conn.Close()
+				return err
 			}
 			modifyPasswordRequest.Replace("userPassword", []string{pwdEncoded})
 		}
@@ -457,6 +512,27 @@ func ResetLdapPassword(user *User, oldPassword string, newPassword string, lang
 	return nil
 }
 
+// encodeAdPassword encodes the password for the unicodePwd attribute of Active Directory, which can only be
+// written over an encrypted connection
+func encodeAdPassword(password string) (string, error) {
+	utf16 := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	return utf16.NewEncoder().String("\"" + password + "\"")
+}
+
+// encodeLdapPassword encodes the password for the userPassword attribute with the password type of the LDAP server
+func (ldap *Ldap) encodeLdapPassword(password string) (string, error) {
+	switch ldap.PasswordType {
+	case "SSHA":
+		return generateSSHA(password)
+	case "MD5":
+		md5Byte := md5.Sum([]byte(password))
+		md5Password := base64.StdEncoding.EncodeToString(md5Byte[:])
+		return "{MD5}" + md5Password, nil
+	default:
+		return password, nil
+	}
+}
+
 func (ldapUser *LdapUser) buildLdapUserName(owner string) (string, error) {
 	user := User{}
 	uidWithNumber := fmt.Sprintf("%s_%s", ldapUser.Uid, ldapUser.UidNumber)
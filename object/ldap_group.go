@@ -0,0 +1,338 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+// the groups of OpenLDAP (groupOfNames, groupOfUniqueNames, posixGroup) and Active Directory (group)
+const defaultLdapGroupFilter = "(|(objectClass=groupOfNames)(objectClass=groupOfUniqueNames)(objectClass=posixGroup)(objectClass=group))"
+
+type LdapGroup struct {
+	Dn          string   `json:"dn"`
+	Cn          string   `json:"cn"`
+	Description string   `json:"description"`
+	Members     []string `json:"members"`
+	MemberUids  []string `json:"memberUids"`
+}
+
+type LdapGroupSyncResult struct {
+	Added        []string `json:"added"`
+	Updated      []string `json:"updated"`
+	Removed      []string `json:"removed"`
+	Failed       []string `json:"failed"`
+	UpdatedUsers []string `json:"updatedUsers"`
+}
+
+func (l *LdapConn) GetLdapGroups(ldapServer *Ldap) ([]LdapGroup, error) {
+	baseDn := ldapServer.GroupBaseDn
+	if baseDn == "" {
+		baseDn = ldapServer.BaseDn
+	}
+
+	filter := ldapServer.GroupFilter
+	if filter == "" {
+		filter = defaultLdapGroupFilter
+	}
+
+	searchReq := goldap.NewSearchRequest(baseDn, goldap.ScopeWholeSubtree, goldap.NeverDerefAliases,
+		0, 0, false,
+		filter, []string{"cn", "description", "member", "uniqueMember", "memberUid"}, nil)
+	searchResult, err := l.Conn.SearchWithPaging(searchReq, 100)
+	if err != nil {
+		return nil, err
+	}
+
+	ldapGroups := []LdapGroup{}
+	for _, entry := range searchResult.Entries {
+		ldapGroups = append(ldapGroups, LdapGroup{
+			Dn:          entry.DN,
+			Cn:          entry.GetAttributeValue("cn"),
+			Description: entry.GetAttributeValue("description"),
+			Members:     append(entry.GetAttributeValues("member"), entry.GetAttributeValues("uniqueMember")...),
+			MemberUids:  entry.GetAttributeValues("memberUid"),
+		})
+	}
+
+	return ldapGroups, nil
+}
+
+func normalizeLdapDn(dn string) string {
+	rdns := strings.Split(dn, ",")
+	for i, rdn := range rdns {
+		rdns[i] = strings.TrimSpace(rdn)
+	}
+	return strings.ToLower(strings.Join(rdns, ","))
+}
+
+// buildLdapGroups converts the LDAP groups into the Casdoor group tree under the ldap's group parent, and returns
+// the IDs of the groups each LDAP user (by uuid) is a direct member of. A nested group that is a member of several
+// groups is placed under the first of them, since a Casdoor group has only one parent.
+func buildLdapGroups(ldapServer *Ldap, ldapGroups []LdapGroup, ldapUsers []LdapUser) ([]*Group, map[string][]string) {
+	sortedGroups := make([]LdapGroup, len(ldapGroups))
+	copy(sortedGroups, ldapGroups)
+	sort.Slice(sortedGroups, func(i, j int) bool {
+		return normalizeLdapDn(sortedGroups[i].Dn) < normalizeLdapDn(sortedGroups[j].Dn)
+	})
+
+	// group names are unique in Casdoor, groups with the same cn in different OUs get a suffix
+	groupNames := map[string]string{}
+	usedNames := map[string]bool{}
+	for _, ldapGroup := range sortedGroups {
+		if ldapGroup.Cn == "" {
+			continue
+		}
+
+		dn := normalizeLdapDn(ldapGroup.Dn)
+		name := ldapGroup.Cn
+		if usedNames[name] {
+			name = fmt.Sprintf("%s_%s", ldapGroup.Cn, util.GetMd5Hash(dn)[:8])
+		}
+		usedNames[name] = true
+		groupNames[dn] = name
+	}
+
+	parents := map[string]string{}
+	isAncestor := func(ancestor string, dn string) bool {
+		for dn != "" {
+			if dn == ancestor {
+				return true
+			}
+			dn = parents[dn]
+		}
+		return false
+	}
+	for _, ldapGroup := range sortedGroups {
+		dn := normalizeLdapDn(ldapGroup.Dn)
+		if groupNames[dn] == "" {
+			continue
+		}
+
+		for _, member := range ldapGroup.Members {
+			child := normalizeLdapDn(member)
+			if groupNames[child] == "" || parents[child] != "" || isAncestor(child, dn) {
+				continue
+			}
+			parents[child] = dn
+		}
+	}
+
+	topParentId := ldapServer.Owner
+	if ldapServer.GroupParent != "" {
+		_, topParentId = util.GetOwnerAndNameFromIdNoCheck(ldapServer.GroupParent)
+	}
+
+	groups := []*Group{}
+	memberGroupIds := map[string][]string{}
+	for _, ldapGroup := range sortedGroups {
+		dn := normalizeLdapDn(ldapGroup.Dn)
+		name := groupNames[dn]
+		if name == "" {
+			continue
+		}
+
+		group := &Group{
+			Owner:       ldapServer.Owner,
+			Name:        name,
+			DisplayName: ldapGroup.Cn,
+			Type:        "Virtual",
+			ParentId:    topParentId,
+			IsTopGroup:  ldapServer.GroupParent == "",
+			IsEnabled:   true,
+			Ldap:        ldapServer.Id,
+		}
+		if parent, ok := parents[dn]; ok {
+			group.ParentId = groupNames[parent]
+			group.IsTopGroup = false
+		}
+		groups = append(groups, group)
+
+		for _, member := range ldapGroup.Members {
+			memberGroupIds[normalizeLdapDn(member)] = append(memberGroupIds[normalizeLdapDn(member)], group.GetId())
+		}
+		for _, memberUid := range ldapGroup.MemberUids {
+			memberGroupIds["uid:"+memberUid] = append(memberGroupIds["uid:"+memberUid], group.GetId())
+		}
+	}
+
+	userGroupIds := map[string][]string{}
+	for _, ldapUser := range ldapUsers {
+		groupIds := []string{}
+		groupIds = append(groupIds, memberGroupIds[normalizeLdapDn(ldapUser.Dn)]...)
+		if ldapUser.Uid != "" {
+			groupIds = append(groupIds, memberGroupIds["uid:"+ldapUser.Uid]...)
+		}
+		for _, memberOf := range ldapUser.MemberOfs {
+			if name := groupNames[normalizeLdapDn(memberOf)]; name != "" {
+				groupIds = append(groupIds, util.GetId(ldapServer.Owner, name))
+			}
+		}
+
+		res := []string{}
+		for _, groupId := range groupIds {
+			if !util.InSlice(res, groupId) {
+				res = append(res, groupId)
+			}
+		}
+		userGroupIds[ldapUser.GetLdapUuid()] = res
+	}
+
+	return groups, userGroupIds
+}
+
+func isSameStringSet(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, value := range a {
+		if !util.InSlice(b, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// getLdapUserGroups returns the new groups of a user, the groups that are not synced from the LDAP server
+// (e.g. the default group) are kept, and the managed groups are replaced by the synced groups in groupIds
+func getLdapUserGroups(userGroups []string, groupIds []string, managedGroupIds map[string]bool, syncedGroupIds map[string]bool) []string {
+	newGroups := []string{}
+	for _, groupId := range userGroups {
+		if !managedGroupIds[groupId] {
+			newGroups = append(newGroups, groupId)
+		}
+	}
+	for _, groupId := range groupIds {
+		if syncedGroupIds[groupId] && !util.InSlice(newGroups, groupId) {
+			newGroups = append(newGroups, groupId)
+		}
+	}
+	return newGroups
+}
+
+// SyncLdapGroups creates and updates the groups of the LDAP server, updates the group membership of its users,
+// and removes the groups that no longer exist in the LDAP server
+func SyncLdapGroups(ldapServer *Ldap, ldapGroups []LdapGroup, ldapUsers []LdapUser) (*LdapGroupSyncResult, error) {
+	res := &LdapGroupSyncResult{}
+	groups, userGroupIds := buildLdapGroups(ldapServer, ldapGroups, ldapUsers)
+
+	existingGroups, err := GetGroups(ldapServer.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	existingGroupMap := map[string]*Group{}
+	managedGroupIds := map[string]bool{}
+	for _, group := range existingGroups {
+		existingGroupMap[group.Name] = group
+		if group.Ldap == ldapServer.Id {
+			managedGroupIds[group.GetId()] = true
+		}
+	}
+
+	syncedGroupIds := map[string]bool{}
+	for _, group := range groups {
+		existingGroup, ok := existingGroupMap[group.Name]
+		if !ok {
+			group.CreatedTime = util.GetCurrentTime()
+			group.UpdatedTime = group.CreatedTime
+			_, err = AddGroup(group)
+			if err != nil {
+				res.Failed = append(res.Failed, fmt.Sprintf("%s: %s", group.Name, err.Error()))
+				continue
+			}
+			res.Added = append(res.Added, group.Name)
+		} else if existingGroup.Ldap != ldapServer.Id {
+			// only the groups marked as synced from this LDAP server are managed, the groups with the same name
+			// that were created manually or synced from another LDAP server are never taken over
+			res.Failed = append(res.Failed, fmt.Sprintf("%s: a group with the same name that isn't synced from this LDAP server already exists", group.Name))
+			continue
+		} else if existingGroup.DisplayName != group.DisplayName || existingGroup.ParentId != group.ParentId ||
+			existingGroup.IsTopGroup != group.IsTopGroup {
+			existingGroup.DisplayName = group.DisplayName
+			existingGroup.ParentId = group.ParentId
+			existingGroup.IsTopGroup = group.IsTopGroup
+			existingGroup.UpdatedTime = util.GetCurrentTime()
+			_, err = UpdateGroup(existingGroup.GetId(), existingGroup)
+			if err != nil {
+				res.Failed = append(res.Failed, fmt.Sprintf("%s: %s", group.Name, err.Error()))
+				continue
+			}
+			res.Updated = append(res.Updated, group.Name)
+		}
+
+		managedGroupIds[group.GetId()] = true
+		syncedGroupIds[group.GetId()] = true
+	}
+
+	users, err := GetUsers(ldapServer.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	// the LDAP users are all the users of the server, the users that are no longer returned lose their managed groups too
+	for _, user := range users {
+		if user.Ldap == "" {
+			continue
+		}
+
+		newGroups := getLdapUserGroups(user.Groups, userGroupIds[user.Ldap], managedGroupIds, syncedGroupIds)
+		if isSameStringSet(user.Groups, newGroups) {
+			continue
+		}
+
+		user.Groups = newGroups
+		_, err = UpdateUser(user.GetId(), user, []string{"groups"}, false)
+		if err != nil {
+			return nil, err
+		}
+		res.UpdatedUsers = append(res.UpdatedUsers, user.Name)
+	}
+
+	// remove the groups that disappeared upstream, subgroups before their parents
+	getDepth := func(group *Group) int {
+		depth := 0
+		for group != nil && !group.IsTopGroup && depth < len(existingGroups) {
+			group = existingGroupMap[group.ParentId]
+			depth++
+		}
+		return depth
+	}
+	staleGroups := []*Group{}
+	for _, group := range existingGroups {
+		if group.Ldap == ldapServer.Id && !syncedGroupIds[group.GetId()] {
+			staleGroups = append(staleGroups, group)
+		}
+	}
+	sort.SliceStable(staleGroups, func(i, j int) bool {
+		return getDepth(staleGroups[i]) > getDepth(staleGroups[j])
+	})
+
+	for _, group := range staleGroups {
+		_, err = DeleteGroup(group)
+		if err != nil {
+			res.Failed = append(res.Failed, fmt.Sprintf("%s: %s", group.Name, err.Error()))
+			continue
+		}
+		res.Removed = append(res.Removed, group.Name)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildLdapGroups(t *testing.T) {
+	ldapServer := &Ldap{Id: "ldap-1", Owner: "org", GroupParent: "org/ldap"}
+	ldapGroups := []LdapGroup{
+		{
+			Dn:      "cn=staff,ou=groups,dc=example,dc=com",
+			Cn:      "staff",
+			Members: []string{"CN=dev, OU=groups, DC=example, DC=com", "uid=alice,ou=people,dc=example,dc=com"},
+		},
+		{
+			Dn:      "cn=dev,ou=groups,dc=example,dc=com",
+			Cn:      "dev",
+			Members: []string{"cn=staff,ou=groups,dc=example,dc=com", "uid=bob,ou=people,dc=example,dc=com"},
+		},
+		{
+			Dn:         "cn=dev,ou=legacy,dc=example,dc=com",
+			Cn:         "dev",
+			MemberUids: []string{"alice"},
+		},
+	}
+	ldapUsers := []LdapUser{
+		{Dn: "uid=alice,ou=people,dc=example,dc=com", Uid: "alice", Uuid: "uuid-alice"},
+		{Dn: "uid=bob,ou=people,dc=example,dc=com", Uid: "bob", Uuid: "uuid-bob", MemberOfs: []string{"cn=staff,ou=groups,dc=example,dc=com"}},
+		{Dn: "uid=carol,ou=people,dc=example,dc=com", Uid: "carol", Uuid: "uuid-carol"},
+	}
+
+	groups, userGroupIds := buildLdapGroups(ldapServer, ldapGroups, ldapUsers)
+	assert.Equal(t, 3, len(groups))
+
+	groupMap := map[string]*Group{}
+	for _, group := range groups {
+		groupMap[group.Name] = group
+		assert.Equal(t, "ldap-1", group.Ldap)
+		assert.False(t, group.IsTopGroup)
+	}
+
+	// the cycle between dev and staff is broken, dev comes first in DN order
+	assert.Equal(t, "ldap", groupMap["dev"].ParentId)
+	assert.Equal(t, "dev", groupMap["staff"].ParentId)
+
+	// the second dev gets a suffix, since group names are unique
+	legacyDev := groupMap["dev_"+util.GetMd5Hash("cn=dev,ou=legacy,dc=example,dc=com")[:8]]
+	assert.NotNil(t, legacyDev)
+	assert.Equal(t, "dev", legacyDev.DisplayName)
+	assert.Equal(t, "ldap", legacyDev.ParentId)
+
+	assert.ElementsMatch(t, []string{"org/staff", legacyDev.GetId()}, userGroupIds["uuid-alice"])
+	assert.ElementsMatch(t, []string{"org/dev", "org/staff"}, userGroupIds["uuid-bob"])
+	assert.Equal(t, []string{}, userGroupIds["uuid-carol"])
+
+	ldapServer.GroupParent = ""
+	groups, _ = buildLdapGroups(ldapServer, ldapGroups[2:], nil)
+	assert.Equal(t, 1, len(groups))
+	assert.Equal(t, "org", groups[0].ParentId)
+	assert.True(t, groups[0].IsTopGroup)
+}
+
+func TestGetLdapUserGroups(t *testing.T) {
+	managedGroupIds := map[string]bool{"org/dev": true, "org/staff": true, "org/old": true}
+	syncedGroupIds := map[string]bool{"org/dev": true, "org/staff": true}
+
+	assert.Equal(t, []string{"org/default", "org/staff"}, getLdapUserGroups([]string{"org/default", "org/dev", "org/old"}, []string{"org/staff"}, managedGroupIds, syncedGroupIds))
+
+	// a user that is no longer returned by the LDAP server loses the managed groups
+	assert.Equal(t, []string{"org/default"}, getLdapUserGroups([]string{"org/default", "org/dev"}, nil, managedGroupIds, syncedGroupIds))
+}
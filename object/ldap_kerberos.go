@@ -0,0 +1,210 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/messages"
+	"github.com/jcmturner/gokrb5/v8/service"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+)
+
+// the LDAP attributes that hold the name of the principal without the realm, the other attributes hold the full
+// principal like "alice@EXAMPLE.COM", e.g. userPrincipalName or krbPrincipalName
+var kerberosShortNameAttributes = []string{"sAMAccountName", "uid", "cn"}
+
+// getKerberosKeytab returns the keytab of the service, the keytab file is stored in base64
+func (ldap *Ldap) getKerberosKeytab() (*keytab.Keytab, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(ldap.KerberosKeytab))
+	if err != nil {
+		return nil, fmt.Errorf("the Kerberos keytab should be encoded in base64: %s", err.Error())
+	}
+
+	kt := keytab.New()
+	err = kt.Unmarshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("the Kerberos keytab is invalid: %s", err.Error())
+	}
+	return kt, nil
+}
+
+func (ldap *Ldap) CheckKerberos() error {
+	if !ldap.EnableKerberos {
+		return nil
+	}
+
+	if ldap.KerberosKeytab == "" {
+		return fmt.Errorf("the Kerberos keytab should not be empty")
+	}
+	if ldap.KerberosKeytab == "***" {
+		return nil
+	}
+
+	_, err := ldap.getKerberosKeytab()
+	return err
+}
+
+// getKerberosApReq returns the AP-REQ of a token from the Negotiate header, the token is usually wrapped by SPNEGO,
+// but some clients send the raw Kerberos token
+func getKerberosApReq(token []byte) (*messages.APReq, error) {
+	mechToken := token
+	var spnegoToken spnego.SPNEGOToken
+	if err := spnegoToken.Unmarshal(token); err == nil {
+		if !spnegoToken.Init {
+			return nil, fmt.Errorf("the SPNEGO token is not a NegTokenInit")
+		}
+		mechToken = spnegoToken.NegTokenInit.MechTokenBytes
+	}
+
+	var krb5Token spnego.KRB5Token
+	err := krb5Token.Unmarshal(mechToken)
+	if err != nil {
+		return nil, fmt.Errorf("the Negotiate token is not a Kerberos token, NTLM is not supported: %s", err.Error())
+	}
+	if !krb5Token.IsAPReq() {
+		return nil, fmt.Errorf("the Kerberos token is not an AP-REQ")
+	}
+	return &krb5Token.APReq, nil
+}
+
+// verifyKerberosToken decrypts the service ticket with the keytab and checks the authenticator
+func (ldap *Ldap) verifyKerberosToken(token []byte) (*credentials.Credentials, error) {
+	kt, err := ldap.getKerberosKeytab()
+	if err != nil {
+		return nil, err
+	}
+
+	apReq, err := getKerberosApReq(token)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := []func(*service.Settings){service.DecodePAC(false)}
+	if ldap.KerberosPrincipal != "" {
+		settings = append(settings, service.KeytabPrincipal(ldap.KerberosPrincipal))
+	}
+
+	ok, creds, err := service.VerifyAPREQ(apReq, service.NewSettings(kt, settings...))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("the Kerberos ticket is invalid")
+	}
+
+	// the ticket is for the service of the keytab, the principal must belong to the same realm so that the users
+	// of a trusted realm can't sign in as the users with the same name
+	if creds.Realm() != apReq.Ticket.Realm {
+		return nil, fmt.Errorf("the realm: %s of the Kerberos principal is not the realm of the service: %s", creds.Realm(), apReq.Ticket.Realm)
+	}
+	return creds, nil
+}
+
+// getKerberosUser maps the principal to the user synced from the LDAP entry whose user attribute matches the
+// principal, the attribute is the username (sAMAccountName or uid) by default
+func (ldap *Ldap) getKerberosUser(creds *credentials.Credentials) (*User, error) {
+	conn, err := ldap.GetLdapConn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	userAttribute := ldap.KerberosUserAttribute
+	if userAttribute == "" {
+		userAttribute = "uid"
+		if conn.IsAD {
+			userAttribute = "sAMAccountName"
+		}
+	}
+
+	value := fmt.Sprintf("%s@%s", creds.UserName(), creds.Realm())
+	for _, attribute := range kerberosShortNameAttributes {
+		if strings.EqualFold(attribute, userAttribute) {
+			value = creds.UserName()
+		}
+	}
+
+	filter := fmt.Sprintf("(&%s(%s=%s))", ldap.Filter, userAttribute, goldap.EscapeFilter(value))
+	ldapUsers, err := conn.searchLdapUsers(ldap, filter, conn.getLdapUserAttributes(ldap))
+	if err != nil {
+		return nil, err
+	}
+	if len(ldapUsers) == 0 {
+		return nil, nil
+	}
+	if len(ldapUsers) > 1 {
+		return nil, fmt.Errorf("the Kerberos principal: %s matches %d LDAP entries", value, len(ldapUsers))
+	}
+
+	// only the users synced from this LDAP server are signed in, not the local users with the same name
+	uuid := ldapUsers[0].GetLdapUuid()
+	if uuid == "" {
+		return nil, nil
+	}
+
+	user := &User{}
+	existed, err := ormer.Engine.Where("owner = ? and ldap = ?", ldap.Owner, uuid).Get(user)
+	if err != nil {
+		return nil, err
+	}
+	if !existed {
+		return nil, nil
+	}
+	return user, nil
+}
+
+// GetUserByKerberosToken verifies the token of the Negotiate header with the keytabs of the LDAP servers of the
+// organization, and returns the user of the principal
+func GetUserByKerberosToken(owner string, token string) (*User, error) {
+	b, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("the Negotiate token is invalid: %s", err.Error())
+	}
+
+	ldaps, err := GetLdaps(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fmt.Errorf("Kerberos is not enabled for any LDAP server of the organization: %s", owner)
+	for _, ldap := range ldaps {
+		if !ldap.EnableKerberos {
+			continue
+		}
+
+		var creds *credentials.Credentials
+		creds, err = ldap.verifyKerberosToken(b)
+		if err != nil {
+			continue
+		}
+
+		user, err := ldap.getKerberosUser(creds)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil || user.IsDeleted {
+			return nil, fmt.Errorf("the Kerberos principal: %s@%s is not mapped to a user", creds.UserName(), creds.Realm())
+		}
+		return user, nil
+	}
+
+	return nil, err
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/base64"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/iana/etypeID"
+	"github.com/jcmturner/gokrb5/v8/iana/nametype"
+	"github.com/jcmturner/gokrb5/v8/messages"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	"github.com/jcmturner/gokrb5/v8/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// the keytabs in testdata hold the keys of HTTP/casdoor.example.com@EXAMPLE.COM, http_other.keytab has other keys
+func getKerberosTestLdap(t *testing.T, file string) *Ldap {
+	b, err := os.ReadFile("testdata/" + file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Ldap{Owner: "built-in", EnableKerberos: true, KerberosKeytab: base64.StdEncoding.EncodeToString(b)}
+}
+
+// getKerberosTestToken issues a service ticket for alice with the keytab of the service, like the KDC does, and
+// wraps it in a SPNEGO token like the browser does
+func getKerberosTestToken(t *testing.T, ldapServer *Ldap) []byte {
+	kt, err := ldapServer.getKerberosKeytab()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	tkt, sessionKey, err := messages.NewTicket(types.NewPrincipalName(nametype.KRB_NT_PRINCIPAL, "alice"), "EXAMPLE.COM",
+		types.NewPrincipalName(nametype.KRB_NT_SRV_INST, "HTTP/casdoor.example.com"), "EXAMPLE.COM",
+		types.NewKrbFlags(), kt, etypeID.AES256_CTS_HMAC_SHA1_96, 2, now, now, now.Add(time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cl := client.NewWithPassword("alice", "EXAMPLE.COM", "", config.New())
+	negTokenInit, err := spnego.NewNegTokenInitKRB5(cl, tkt, sessionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := spnego.SPNEGOToken{Init: true, NegTokenInit: negTokenInit}
+	b, err := token.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestVerifyKerberosToken(t *testing.T) {
+	ldapServer := getKerberosTestLdap(t, "http.keytab")
+	assert.Nil(t, ldapServer.CheckKerberos())
+
+	creds, err := ldapServer.verifyKerberosToken(getKerberosTestToken(t, ldapServer))
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", creds.UserName())
+	assert.Equal(t, "EXAMPLE.COM", creds.Realm())
+
+	ldapServer.KerberosPrincipal = "HTTP/casdoor.example.com"
+	_, err = ldapServer.verifyKerberosToken(getKerberosTestToken(t, ldapServer))
+	assert.Nil(t, err)
+
+	// the ticket of another service can't be decrypted
+	otherLdapServer := getKerberosTestLdap(t, "http_other.keytab")
+	_, err = otherLdapServer.verifyKerberosToken(getKerberosTestToken(t, ldapServer))
+	assert.NotNil(t, err)
+
+	// NTLM tokens are sent by the browsers that have no Kerberos ticket
+	ntlmToken, _ := base64.StdEncoding.DecodeString("TlRMTVNTUAABAAAAB4IIogAAAAAAAAAAAAAAAAAAAAAKAGFKAAAADw==")
+	_, err = ldapServer.verifyKerberosToken(ntlmToken)
+	assert.NotNil(t, err)
+
+	ldapServer.KerberosKeytab = "not a keytab"
+	assert.NotNil(t, ldapServer.CheckKerberos())
+}
@@ -0,0 +1,266 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+const (
+	LdapHostStrategyFailover   = "Failover"
+	LdapHostStrategyRoundRobin = "RoundRobin"
+)
+
+const (
+	defaultLdapPoolSize = 5
+	ldapDialTimeout     = 5 * time.Second
+	// idle connections are closed after ldapIdleTimeout, and checked before reuse after ldapHealthCheckInterval
+	ldapIdleTimeout         = 5 * time.Minute
+	ldapHealthCheckInterval = 30 * time.Second
+	// a host that failed is tried after the other hosts during ldapHostDownInterval
+	ldapHostDownInterval = 30 * time.Second
+)
+
+type LdapHostHealth struct {
+	Address   string `json:"address"`
+	IsHealthy bool   `json:"isHealthy"`
+	Latency   int64  `json:"latency"`
+	Error     string `json:"error"`
+}
+
+type ldapPool struct {
+	mutex     sync.Mutex
+	config    string
+	isClosed  bool
+	idleConns []*LdapConn
+	next      int
+	downTimes map[string]time.Time
+}
+
+var (
+	ldapPoolsMutex sync.Mutex
+	ldapPools      = map[string]*ldapPool{}
+)
+
+// GetLdapAddresses returns the addresses of the LDAP server, the primary host comes first and the other hosts
+// use the port of the LDAP server unless they have their own
+func (ldap *Ldap) GetLdapAddresses() []string {
+	res := []string{net.JoinHostPort(ldap.Host, strconv.Itoa(ldap.Port))}
+	for _, host := range ldap.Hosts {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+
+		address := host
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			address = net.JoinHostPort(host, strconv.Itoa(ldap.Port))
+		}
+		if !util.InSlice(res, address) {
+			res = append(res, address)
+		}
+	}
+	return res
+}
+
+func (ldap *Ldap) getPoolConfig() string {
+	return util.GetMd5Hash(fmt.Sprintf("%s|%t|%s|%s", strings.Join(ldap.GetLdapAddresses(), ","), ldap.EnableSsl, ldap.Username, ldap.Password))
+}
+
+func (ldap *Ldap) getPoolSize() int {
+	if ldap.PoolSize <= 0 {
+		return defaultLdapPoolSize
+	}
+	return ldap.PoolSize
+}
+
+// getLdapPool returns the pool of the LDAP server, the pool is replaced when the hosts or the admin change
+func getLdapPool(ldap *Ldap) *ldapPool {
+	ldapPoolsMutex.Lock()
+	defer ldapPoolsMutex.Unlock()
+
+	config := ldap.getPoolConfig()
+	pool, ok := ldapPools[ldap.Id]
+	if ok && pool.config == config {
+		return pool
+	}
+
+	if ok {
+		pool.close()
+	}
+	pool = &ldapPool{config: config, downTimes: map[string]time.Time{}}
+	ldapPools[ldap.Id] = pool
+	return pool
+}
+
+// CloseLdapPool closes the idle connections of the LDAP server, it's called when the LDAP server is updated or deleted
+func CloseLdapPool(ldapId string) {
+	ldapPoolsMutex.Lock()
+	defer ldapPoolsMutex.Unlock()
+
+	if pool, ok := ldapPools[ldapId]; ok {
+		pool.close()
+		delete(ldapPools, ldapId)
+	}
+}
+
+func (pool *ldapPool) close() {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	pool.isClosed = true
+	for _, conn := range pool.idleConns {
+		conn.Conn.Close()
+	}
+	pool.idleConns = nil
+}
+
+// getAddresses returns the addresses in the order to dial them, the hosts that failed recently come last
+func (pool *ldapPool) getAddresses(ldap *Ldap) []string {
+	addresses := ldap.GetLdapAddresses()
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if ldap.HostStrategy == LdapHostStrategyRoundRobin {
+		start := pool.next % len(addresses)
+		pool.next++
+		addresses = append(addresses[start:], addresses[:start]...)
+	}
+
+	healthy := []string{}
+	down := []string{}
+	for _, address := range addresses {
+		if time.Now().Before(pool.downTimes[address]) {
+			down = append(down, address)
+		} else {
+			healthy = append(healthy, address)
+		}
+	}
+	return append(healthy, down...)
+}
+
+func (pool *ldapPool) setHostHealth(address string, err error) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if err != nil && isLdapHostError(err) {
+		pool.downTimes[address] = time.Now().Add(ldapHostDownInterval)
+	} else {
+		delete(pool.downTimes, address)
+	}
+}
+
+// get returns an idle connection, the connections that are closed by the server or idle for too long are dropped
+func (pool *ldapPool) get() *LdapConn {
+	for {
+		pool.mutex.Lock()
+		if len(pool.idleConns) == 0 {
+			pool.mutex.Unlock()
+			return nil
+		}
+
+		conn := pool.idleConns[len(pool.idleConns)-1]
+		pool.idleConns = pool.idleConns[:len(pool.idleConns)-1]
+		pool.mutex.Unlock()
+
+		idleDuration := time.Since(conn.idleTime)
+		if conn.Conn.IsClosing() || idleDuration > ldapIdleTimeout {
+			conn.Conn.Close()
+			continue
+		}
+
+		if idleDuration > ldapHealthCheckInterval {
+			err := checkLdapConn(conn.Conn)
+			pool.setHostHealth(conn.address, err)
+			if err != nil {
+				conn.Conn.Close()
+				continue
+			}
+		}
+
+		return conn
+	}
+}
+
+// put returns the connection to the pool, and returns false if the connection should be closed by the caller
+func (pool *ldapPool) put(conn *LdapConn) bool {
+	if conn.Conn.IsClosing() {
+		return true
+	}
+
+	if conn.isUserBound {
+		err := conn.Conn.Bind(conn.ldap.Username, conn.ldap.Password)
+		if err != nil {
+			conn.Conn.Close()
+			return true
+		}
+		conn.isUserBound = false
+	}
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if pool.isClosed || len(pool.idleConns) >= conn.ldap.getPoolSize() {
+		return false
+	}
+
+	conn.idleTime = time.Now()
+	pool.idleConns = append(pool.idleConns, conn)
+	return true
+}
+
+// checkLdapConn reads the rootDSE to check that the connection still works
+func checkLdapConn(conn *goldap.Conn) error {
+	searchReq := goldap.NewSearchRequest("", goldap.ScopeBaseObject, goldap.NeverDerefAliases, 0, int(ldapDialTimeout.Seconds()), false,
+		"(objectClass=*)", []string{"1.1"}, nil)
+	_, err := conn.Search(searchReq)
+	return err
+}
+
+// isLdapHostError returns whether the error is caused by the host rather than the request, so another host can be tried
+func isLdapHostError(err error) bool {
+	return goldap.IsErrorAnyOf(err, goldap.ErrorNetwork, goldap.LDAPResultBusy, goldap.LDAPResultUnavailable, goldap.LDAPResultUnwillingToPerform)
+}
+
+// CheckLdapHealth dials every host of the LDAP server and binds as the admin
+func (ldap *Ldap) CheckLdapHealth() []*LdapHostHealth {
+	pool := getLdapPool(ldap)
+
+	res := []*LdapHostHealth{}
+	for _, address := range ldap.GetLdapAddresses() {
+		health := &LdapHostHealth{Address: address}
+		startTime := time.Now()
+		conn, err := ldap.dialLdapConn(address)
+		health.Latency = time.Since(startTime).Milliseconds()
+		pool.setHostHealth(address, err)
+		if err != nil {
+			health.Error = err.Error()
+		} else {
+			health.IsHealthy = true
+			conn.Close()
+		}
+		res = append(res, health)
+	}
+	return res
+}
@@ -0,0 +1,53 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"errors"
+	"testing"
+
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLdapPoolAddresses(t *testing.T) {
+	ldapServer := &Ldap{Id: "ldap-pool-test", Host: "dc1.example.com", Port: 389, Hosts: []string{"dc2.example.com", " dc3.example.com:636 ", "dc1.example.com:389", ""}}
+	assert.Equal(t, []string{"dc1.example.com:389", "dc2.example.com:389", "dc3.example.com:636"}, ldapServer.GetLdapAddresses())
+
+	pool := getLdapPool(ldapServer)
+	assert.Same(t, pool, getLdapPool(ldapServer))
+	assert.Equal(t, []string{"dc1.example.com:389", "dc2.example.com:389", "dc3.example.com:636"}, pool.getAddresses(ldapServer))
+
+	// the failed host is tried last
+	pool.setHostHealth("dc1.example.com:389", goldap.NewError(goldap.ErrorNetwork, errors.New("connection refused")))
+	assert.Equal(t, []string{"dc2.example.com:389", "dc3.example.com:636", "dc1.example.com:389"}, pool.getAddresses(ldapServer))
+
+	// a host that answers with an error of the request is healthy
+	pool.setHostHealth("dc1.example.com:389", goldap.NewError(goldap.LDAPResultInvalidCredentials, errors.New("invalid credentials")))
+	assert.Equal(t, []string{"dc1.example.com:389", "dc2.example.com:389", "dc3.example.com:636"}, pool.getAddresses(ldapServer))
+
+	ldapServer.HostStrategy = LdapHostStrategyRoundRobin
+	assert.Equal(t, []string{"dc1.example.com:389", "dc2.example.com:389", "dc3.example.com:636"}, pool.getAddresses(ldapServer))
+	assert.Equal(t, []string{"dc2.example.com:389", "dc3.example.com:636", "dc1.example.com:389"}, pool.getAddresses(ldapServer))
+
+	// the pool is replaced when the admin changes
+	ldapServer.Password = "new-password"
+	newPool := getLdapPool(ldapServer)
+	assert.NotSame(t, pool, newPool)
+	assert.True(t, pool.isClosed)
+
+	CloseLdapPool(ldapServer.Id)
+	assert.True(t, newPool.isClosed)
+}
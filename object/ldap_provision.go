@@ -0,0 +1,610 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/util"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+// the userAccountControl flags of Active Directory
+const (
+	adAccountDisabled = 0x2
+	adNormalAccount   = 0x200
+)
+
+// the value of pwdAccountLockedTime that locks the account until an administrator unlocks it, see the ppolicy overlay
+const ldapPermanentlyLockedTime = "000001010000Z"
+
+var ldapDnTemplateRegex = regexp.MustCompile(`\{([A-Za-z.]+)\}`)
+
+var (
+	ldapProvisionOnce  sync.Once
+	ldapProvisionQueue chan func()
+
+	ldapProvisioningLock   sync.Mutex
+	ldapProvisioningOwners map[string]bool // the organizations with provisioning enabled, nil if not loaded
+)
+
+// LdapProvisionTask is a change of a user that failed to be pushed to an LDAP server, it's retried periodically by
+// RetryLdapProvisionTasks. The plain password is never stored, so a failed password change must be made again.
+type LdapProvisionTask struct {
+	Id            int64  `xorm:"pk autoincr" json:"id"`
+	Owner         string `xorm:"varchar(100) index" json:"owner"`
+	CreatedTime   string `xorm:"varchar(100)" json:"createdTime"`
+	Ldap          string `xorm:"varchar(100) index" json:"ldap"`
+	UserId        string `xorm:"varchar(100) index" json:"userId"`
+	OldUser       *User  `xorm:"json" json:"oldUser"`
+	User          *User  `xorm:"json" json:"user"`
+	Attempts      int    `json:"attempts"`
+	NextRetryTime string `xorm:"varchar(100) index" json:"nextRetryTime"`
+	Error         string `xorm:"mediumtext" json:"error"`
+}
+
+var defaultLdapProvisioningAttributes = []*LdapAttributeMapping{
+	{Name: "cn", CasdoorName: "Name"},
+	{Name: "sn", CasdoorName: "LastName"},
+	{Name: "givenName", CasdoorName: "FirstName"},
+	{Name: "displayName", CasdoorName: "DisplayName"},
+	{Name: "mail", CasdoorName: "Email"},
+	{Name: "mobile", CasdoorName: "Phone"},
+}
+
+func (ldap *Ldap) getProvisioningDn(user *User) string {
+	template := ldap.ProvisioningDnTemplate
+	if template == "" {
+		template = fmt.Sprintf("cn={Name},%s", ldap.BaseDn)
+	}
+
+	return ldapDnTemplateRegex.ReplaceAllStringFunc(template, func(placeholder string) string {
+		casdoorName := placeholder[1 : len(placeholder)-1]
+		return goldap.EscapeDN(getUserFieldForLdapAttribute(user, casdoorName))
+	})
+}
+
+func (ldap *Ldap) getProvisioningObjectClasses(isAD bool) []string {
+	if len(ldap.ProvisioningObjectClasses) != 0 {
+		return ldap.ProvisioningObjectClasses
+	}
+
+	if isAD {
+		return []string{"top", "person", "organizationalPerson", "user"}
+	}
+	return []string{"top", "person", "organizationalPerson", "inetOrgPerson"}
+}
+
+// getProvisioningAttributes returns the LDAP attributes of the user, the attributes without value are empty
+func (ldap *Ldap) getProvisioningAttributes(user *User, isAD bool) map[string]string {
+	res := map[string]string{}
+	attributes := ldap.ProvisioningAttributes
+	if len(attributes) == 0 {
+		attributes = defaultLdapProvisioningAttributes
+		if isAD {
+			res["sAMAccountName"] = user.Name
+		} else {
+			res["uid"] = user.Name
+		}
+	}
+
+	for _, attribute := range attributes {
+		value := getUserFieldForLdapAttribute(user, attribute.CasdoorName)
+		switch attribute.Transform {
+		case "Lowercase":
+			value = strings.ToLower(value)
+		case "Uppercase":
+			value = strings.ToUpper(value)
+		}
+		res[attribute.Name] = value
+	}
+
+	// sn is required by the person object class
+	if sn, ok := res["sn"]; ok && sn == "" {
+		res["sn"] = user.Name
+	}
+	return res
+}
+
+func getRdnAttribute(dn string) string {
+	rdnAttribute, _, _ := strings.Cut(dn, "=")
+	return strings.TrimSpace(rdnAttribute)
+}
+
+func (ldap *Ldap) addLdapEntry(conn *LdapConn, user *User, password string) error {
+	dn := ldap.getProvisioningDn(user)
+	req := goldap.NewAddRequest(dn, nil)
+	req.Attribute("objectClass", ldap.getProvisioningObjectClasses(conn.IsAD))
+	for name, value := range ldap.getProvisioningAttributes(user, conn.IsAD) {
+		if value != "" {
+			req.Attribute(name, []string{value})
+		}
+	}
+
+	if conn.IsAD {
+		// AD refuses to enable an account without a password, and the password can only be set over LDAPS
+		userAccountControl := adNormalAccount | adAccountDisabled
+		if password != "" {
+			if !ldap.EnableSsl {
+				return fmt.Errorf("the password can only be pushed to Active Directory over LDAPS")
+			}
+
+			pwdEncoded, err := encodeAdPassword(password)
+			if err != nil {
+				return err
+			}
+			req.Attribute("unicodePwd", []string{pwdEncoded})
+			if !user.IsForbidden {
+				userAccountControl = adNormalAccount
+			}
+		}
+		req.Attribute("userAccountControl", []string{strconv.Itoa(userAccountControl)})
+	} else {
+		if password != "" {
+			pwdEncoded, err := ldap.encodeLdapPassword(password)
+			if err != nil {
+				return err
+			}
+			req.Attribute("userPassword", []string{pwdEncoded})
+		}
+		if user.IsForbidden {
+			req.Attribute("pwdAccountLockedTime", []string{ldapPermanentlyLockedTime})
+		}
+	}
+
+	return conn.Conn.Add(req)
+}
+
+func (ldap *Ldap) updateLdapEntry(conn *LdapConn, oldUser *User, user *User, password string) error {
+	dn := ldap.getProvisioningDn(user)
+	oldDn := ldap.getProvisioningDn(oldUser)
+	if !strings.EqualFold(dn, oldDn) {
+		rdn, parent, _ := strings.Cut(dn, ",")
+		_, oldParent, _ := strings.Cut(oldDn, ",")
+		newSuperior := ""
+		if !strings.EqualFold(strings.TrimSpace(parent), strings.TrimSpace(oldParent)) {
+			newSuperior = parent
+		}
+
+		err := conn.Conn.ModifyDN(goldap.NewModifyDNRequest(oldDn, rdn, true, newSuperior))
+		if err != nil && !goldap.IsErrorWithCode(err, goldap.LDAPResultNoSuchObject) {
+			return err
+		}
+	}
+
+	attributes := ldap.getProvisioningAttributes(user, conn.IsAD)
+	names := []string{"userAccountControl", "pwdAccountLockedTime"}
+	for name := range attributes {
+		names = append(names, name)
+	}
+
+	searchReq := goldap.NewSearchRequest(dn, goldap.ScopeBaseObject, goldap.NeverDerefAliases, 0, 0, false,
+		"(objectClass=*)", names, nil)
+	searchResult, err := conn.Conn.Search(searchReq)
+	if err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultNoSuchObject) {
+			return ldap.addLdapEntry(conn, user, password)
+		}
+		return err
+	}
+	if len(searchResult.Entries) == 0 {
+		return ldap.addLdapEntry(conn, user, password)
+	}
+	entry := searchResult.Entries[0]
+
+	// the RDN attribute can only be changed by renaming the entry
+	rdnAttribute := getRdnAttribute(dn)
+
+	req := goldap.NewModifyRequest(dn, nil)
+	for name, value := range attributes {
+		if strings.EqualFold(name, rdnAttribute) {
+			continue
+		}
+
+		current := entry.GetEqualFoldAttributeValue(name)
+		if value == current {
+			continue
+		}
+
+		if value == "" {
+			req.Delete(name, []string{})
+		} else {
+			req.Replace(name, []string{value})
+		}
+	}
+
+	if password != "" {
+		if conn.IsAD {
+			if !ldap.EnableSsl {
+				return fmt.Errorf("the password can only be pushed to Active Directory over LDAPS")
+			}
+
+			pwdEncoded, err := encodeAdPassword(password)
+			if err != nil {
+				return err
+			}
+			req.Replace("unicodePwd", []string{pwdEncoded})
+		} else {
+			pwdEncoded, err := ldap.encodeLdapPassword(password)
+			if err != nil {
+				return err
+			}
+			req.Replace("userPassword", []string{pwdEncoded})
+		}
+	}
+
+	if conn.IsAD {
+		userAccountControl, err := strconv.Atoi(entry.GetEqualFoldAttributeValue("userAccountControl"))
+		if err == nil {
+			newUserAccountControl := userAccountControl &^ adAccountDisabled
+			if user.IsForbidden {
+				newUserAccountControl = userAccountControl | adAccountDisabled
+			}
+			if newUserAccountControl != userAccountControl {
+				req.Replace("userAccountControl", []string{strconv.Itoa(newUserAccountControl)})
+			}
+		}
+	} else {
+		isLocked := entry.GetEqualFoldAttributeValue("pwdAccountLockedTime") != ""
+		if user.IsForbidden && !isLocked {
+			req.Replace("pwdAccountLockedTime", []string{ldapPermanentlyLockedTime})
+		} else if !user.IsForbidden && isLocked {
+			req.Delete("pwdAccountLockedTime", []string{})
+		}
+	}
+
+	if len(req.Changes) == 0 {
+		return nil
+	}
+	return conn.Conn.Modify(req)
+}
+
+func (ldap *Ldap) deleteLdapEntry(conn *LdapConn, user *User) error {
+	err := conn.Conn.Del(goldap.NewDelRequest(ldap.getProvisioningDn(user), nil))
+	if err != nil && !goldap.IsErrorWithCode(err, goldap.LDAPResultNoSuchObject) {
+		return err
+	}
+	return nil
+}
+
+// provisionLdapEntry creates the entry of the user if oldUser is nil, deletes it if user is nil or deleted,
+// and updates it otherwise
+func (ldap *Ldap) provisionLdapEntry(oldUser *User, user *User, password string) error {
+	conn, err := ldap.GetLdapConn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	switch {
+	case user == nil || user.IsDeleted:
+		if oldUser == nil {
+			return nil
+		}
+		return ldap.deleteLdapEntry(conn, oldUser)
+	case oldUser == nil:
+		err = ldap.addLdapEntry(conn, user, password)
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultEntryAlreadyExists) {
+			return ldap.updateLdapEntry(conn, user, user, password)
+		}
+		return err
+	default:
+		return ldap.updateLdapEntry(conn, oldUser, user, password)
+	}
+}
+
+func getProvisioningLdaps(owner string) ([]*Ldap, error) {
+	ldaps, err := GetLdaps(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*Ldap{}
+	for _, ldap := range ldaps {
+		if ldap.EnableProvisioning {
+			res = append(res, ldap)
+		}
+	}
+	return res, nil
+}
+
+// hasProvisioningLdaps returns true if the organization has an LDAP server with provisioning enabled, the
+// organizations are cached since it's checked on every change of a user
+func hasProvisioningLdaps(owner string) (bool, error) {
+	ldapProvisioningLock.Lock()
+	defer ldapProvisioningLock.Unlock()
+
+	if ldapProvisioningOwners == nil {
+		ldaps := []*Ldap{}
+		err := ormer.Engine.Cols("owner").Where("enable_provisioning = ?", true).Find(&ldaps)
+		if err != nil {
+			return false, err
+		}
+
+		ldapProvisioningOwners = map[string]bool{}
+		for _, ldap := range ldaps {
+			ldapProvisioningOwners[ldap.Owner] = true
+		}
+	}
+	return ldapProvisioningOwners[owner], nil
+}
+
+// clearLdapProvisioningCache is called when an LDAP server is added, updated or deleted
+func clearLdapProvisioningCache() {
+	ldapProvisioningLock.Lock()
+	defer ldapProvisioningLock.Unlock()
+
+	ldapProvisioningOwners = nil
+}
+
+// ProvisionLdapUser pushes a change of the user to the LDAP servers of its organization that have provisioning
+// enabled, oldUser is nil for a new user and user is nil for a deleted user. The password is the plain password
+// if it's changed. The users synced from LDAP are not provisioned, since their directory is the source of truth.
+// The changes are queued and pushed in order in the background, so that an unavailable directory doesn't block
+// the users, the changes that fail are stored and retried.
+func ProvisionLdapUser(oldUser *User, user *User, password string) {
+	var owner string
+	for _, u := range []*User{oldUser, user} {
+		if u == nil {
+			continue
+		}
+		if u.Ldap != "" || u.Owner == "built-in" {
+			return
+		}
+		owner = u.Owner
+	}
+	if owner == "" {
+		return
+	}
+
+	hasLdaps, err := hasProvisioningLdaps(owner)
+	if err != nil {
+		logs.Warning(fmt.Sprintf("ProvisionLdapUser() error: %s", err.Error()))
+		return
+	}
+	if !hasLdaps {
+		return
+	}
+
+	ldaps, err := getProvisioningLdaps(owner)
+	if err != nil {
+		logs.Warning(fmt.Sprintf("ProvisionLdapUser() error: %s", err.Error()))
+		return
+	}
+
+	// copy the users, the callers may change them after returning
+	oldUser = copyUserForLdap(oldUser)
+	user = copyUserForLdap(user)
+
+	for _, ldap := range ldaps {
+		task := &LdapProvisionTask{
+			Owner:   owner,
+			Ldap:    ldap.Id,
+			UserId:  getUserIdForLdap(oldUser, user),
+			OldUser: oldUser,
+			User:    user,
+		}
+
+		l := ldap
+		f := func() {
+			pushLdapProvisionTask(l, task, password)
+		}
+
+		select {
+		case getLdapProvisionQueue() <- f:
+		default:
+			saveLdapProvisionTask(task, password, fmt.Errorf("the provisioning queue is full"))
+		}
+	}
+}
+
+func getLdapProvisionQueue() chan func() {
+	ldapProvisionOnce.Do(func() {
+		ldapProvisionQueue = make(chan func(), 1000)
+		startLdapProvisionWorker()
+	})
+	return ldapProvisionQueue
+}
+
+// startLdapProvisionWorker starts the worker that pushes the changes in order, a panic is recovered for each change,
+// and the worker is started again if it stops anyway
+func startLdapProvisionWorker() {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logs.Error(fmt.Sprintf("startLdapProvisionWorker() panic: %v", r))
+				startLdapProvisionWorker()
+			}
+		}()
+
+		for f := range ldapProvisionQueue {
+			runLdapProvisionFunc(f)
+		}
+	}()
+}
+
+func runLdapProvisionFunc(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			logs.Error(fmt.Sprintf("runLdapProvisionFunc() panic: %v", r))
+		}
+	}()
+
+	f()
+}
+
+// pushLdapProvisionTask pushes a change to the LDAP server, the change is stored to be retried if it fails or
+// if earlier changes of the user are still waiting to be retried, so that the changes are applied in order
+func pushLdapProvisionTask(ldap *Ldap, task *LdapProvisionTask, password string) {
+	defer func() {
+		if r := recover(); r != nil {
+			saveLdapProvisionTask(task, password, fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	count, err := ormer.Engine.Where("ldap = ? and user_id = ?", task.Ldap, task.UserId).Count(&LdapProvisionTask{})
+	if err != nil {
+		saveLdapProvisionTask(task, password, err)
+		return
+	}
+	if count != 0 {
+		saveLdapProvisionTask(task, password, fmt.Errorf("the earlier changes of the user are waiting to be retried"))
+		return
+	}
+
+	err = ldap.provisionLdapEntry(task.OldUser, task.User, password)
+	if err != nil {
+		saveLdapProvisionTask(task, password, err)
+	}
+}
+
+func getLdapProvisionRetryTime(attempts int) string {
+	delay := time.Hour
+	if attempts < 6 {
+		delay = time.Duration(1<<attempts) * time.Minute
+	}
+	return time.Now().Add(delay).Format(time.RFC3339)
+}
+
+func saveLdapProvisionTask(task *LdapProvisionTask, password string, reason error) {
+	logs.Warning(fmt.Sprintf("ProvisionLdapUser() error for LDAP: %s, user: %s, %s, the change will be retried", task.Ldap, task.UserId, reason.Error()))
+	if password != "" {
+		logs.Warning(fmt.Sprintf("ProvisionLdapUser() error for LDAP: %s, user: %s, the password isn't retried and must be set again", task.Ldap, task.UserId))
+	}
+
+	task.Id = 0
+	task.CreatedTime = util.GetCurrentTime()
+	task.Attempts = 1
+	task.NextRetryTime = getLdapProvisionRetryTime(task.Attempts)
+	task.Error = reason.Error()
+	_, err := ormer.Engine.Insert(task)
+	if err != nil {
+		logs.Error(fmt.Sprintf("ProvisionLdapUser() error: the change of user: %s can't be saved for retry: %s", task.UserId, err.Error()))
+	}
+}
+
+// retryLdapProvisionTasks retries the stored changes that are due in order, the later changes of a user wait
+// until its earlier ones succeed
+func retryLdapProvisionTasks() error {
+	tasks := []*LdapProvisionTask{}
+	err := ormer.Engine.Asc("id").Find(&tasks)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	blockedUsers := map[string]bool{}
+	ldaps := map[string]*Ldap{}
+	for _, task := range tasks {
+		key := task.Ldap + "#" + task.UserId
+		if blockedUsers[key] {
+			continue
+		}
+		if task.NextRetryTime > now {
+			blockedUsers[key] = true
+			continue
+		}
+
+		ldap, ok := ldaps[task.Ldap]
+		if !ok {
+			ldap, err = GetLdap(task.Ldap)
+			if err != nil {
+				return err
+			}
+			ldaps[task.Ldap] = ldap
+		}
+
+		if ldap != nil && ldap.EnableProvisioning {
+			err = ldap.provisionLdapEntry(task.OldUser, task.User, "")
+			if err != nil {
+				blockedUsers[key] = true
+				task.Attempts++
+				task.NextRetryTime = getLdapProvisionRetryTime(task.Attempts)
+				task.Error = err.Error()
+				_, err = ormer.Engine.ID(task.Id).Cols("attempts", "next_retry_time", "error").Update(task)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		// the change is pushed, or the LDAP server no longer provisions the users
+		_, err = ormer.Engine.ID(task.Id).Delete(&LdapProvisionTask{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RetryLdapProvisionTasks retries the failed changes of the users every minute, in the provisioning worker to keep
+// the changes of a user in order
+func RetryLdapProvisionTasks() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		getLdapProvisionQueue() <- func() {
+			err := retryLdapProvisionTasks()
+			if err != nil {
+				logs.Warning(fmt.Sprintf("RetryLdapProvisionTasks() error: %s", err.Error()))
+			}
+		}
+	}
+}
+
+// copyUserForLdap copies the user without the credentials, since the copies are stored in the LdapProvisionTask table
+// until they're pushed
+func copyUserForLdap(user *User) *User {
+	if user == nil {
+		return nil
+	}
+
+	res := *user
+	res.Password = ""
+	res.PasswordSalt = ""
+	res.NtHash = ""
+	res.Hash = ""
+	res.PreHash = ""
+	res.AccessKey = ""
+	res.AccessSecret = ""
+	res.AccessToken = ""
+	res.TotpSecret = ""
+	res.RecoveryCodes = nil
+	res.WebauthnCredentials = nil
+	res.MultiFactorAuths = nil
+	res.FaceIds = nil
+	res.MfaAccounts = nil
+	res.Properties = map[string]string{}
+	for key, value := range user.Properties {
+		res.Properties[key] = value
+	}
+	res.Address = append([]string{}, user.Address...)
+	return &res
+}
+
+func getUserIdForLdap(oldUser *User, user *User) string {
+	if user != nil {
+		return user.GetId()
+	}
+	return oldUser.GetId()
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetProvisioningDn(t *testing.T) {
+	ldapServer := &Ldap{BaseDn: "ou=people,dc=example,dc=com"}
+	user := &User{Owner: "built-in", Name: "alice, smith", Affiliation: "sales"}
+	assert.Equal(t, `cn=alice\, smith,ou=people,dc=example,dc=com`, ldapServer.getProvisioningDn(user))
+
+	ldapServer.ProvisioningDnTemplate = "uid={Name},ou={Affiliation},dc=example,dc=com"
+	assert.Equal(t, `uid=alice\, smith,ou=sales,dc=example,dc=com`, ldapServer.getProvisioningDn(user))
+	assert.Equal(t, "uid", getRdnAttribute(ldapServer.getProvisioningDn(user)))
+
+	ldapServer.ProvisioningDnTemplate = "cn={Password},dc=example,dc=com"
+	assert.NotNil(t, ldapServer.CheckAttributeMappings())
+}
+
+func TestGetProvisioningAttributes(t *testing.T) {
+	user := &User{Name: "alice", DisplayName: "Alice", Email: "Alice@Example.com", Properties: map[string]string{"employeeNumber": "42"}}
+
+	ldapServer := &Ldap{}
+	attributes := ldapServer.getProvisioningAttributes(user, false)
+	assert.Equal(t, "alice", attributes["uid"])
+	assert.Equal(t, "alice", attributes["cn"])
+	assert.Equal(t, "alice", attributes["sn"])
+	assert.Equal(t, "Alice@Example.com", attributes["mail"])
+	assert.Equal(t, "", attributes["mobile"])
+
+	attributes = ldapServer.getProvisioningAttributes(user, true)
+	assert.Equal(t, "alice", attributes["sAMAccountName"])
+	assert.NotContains(t, attributes, "uid")
+
+	ldapServer.ProvisioningAttributes = []*LdapAttributeMapping{
+		{Name: "cn", CasdoorName: "Name"},
+		{Name: "mail", CasdoorName: "Email", Transform: "Lowercase"},
+		{Name: "employeeNumber", CasdoorName: "Properties.employeeNumber"},
+	}
+	assert.Nil(t, ldapServer.CheckAttributeMappings())
+	attributes = ldapServer.getProvisioningAttributes(user, false)
+	assert.Equal(t, map[string]string{"cn": "alice", "mail": "alice@example.com", "employeeNumber": "42"}, attributes)
+
+	ldapServer.ProvisioningAttributes = append(ldapServer.ProvisioningAttributes, &LdapAttributeMapping{Name: "mail|email", CasdoorName: "Email"})
+	assert.NotNil(t, ldapServer.CheckAttributeMappings())
+}
+
+func TestLdapProvisionWorker(t *testing.T) {
+	// a panic of a change doesn't stop the worker
+	done := make(chan bool)
+	getLdapProvisionQueue() <- func() {
+		panic("provisioning failed")
+	}
+	getLdapProvisionQueue() <- func() {
+		done <- true
+	}
+	assert.True(t, <-done)
+
+	assert.True(t, getLdapProvisionRetryTime(1) < getLdapProvisionRetryTime(5))
+}
+
+func TestCopyUserForLdap(t *testing.T) {
+	user := &User{Owner: "built-in", Name: "alice", Password: "123", PasswordSalt: "salt", NtHash: "hash", TotpSecret: "secret", RecoveryCodes: []string{"code"}, IsForbidden: true, Properties: map[string]string{"vlan": "10"}}
+	res := copyUserForLdap(user)
+	assert.Equal(t, &User{Owner: "built-in", Name: "alice", IsForbidden: true, Properties: map[string]string{"vlan": "10"}, Address: []string{}}, res)
+
+	res.Properties["vlan"] = "20"
+	assert.Equal(t, "10", user.Properties["vlan"])
+	assert.Nil(t, copyUserForLdap(nil))
+}
@@ -0,0 +1,393 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/util"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+const (
+	LdapSyncModeFull        = "Full"
+	LdapSyncModeIncremental = "Incremental"
+
+	LdapDeletionPolicyNone    = "None"
+	LdapDeletionPolicyDisable = "Disable"
+	LdapDeletionPolicyDelete  = "Delete"
+)
+
+type LdapSyncStats struct {
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
+	Mode      string `json:"mode"`
+
+	Fetched  int `json:"fetched"`
+	Added    int `json:"added"`
+	Updated  int `json:"updated"`
+	Failed   int `json:"failed"`
+	Disabled int `json:"disabled"`
+	Deleted  int `json:"deleted"`
+
+	GroupsAdded   int `json:"groupsAdded"`
+	GroupsUpdated int `json:"groupsUpdated"`
+	GroupsRemoved int `json:"groupsRemoved"`
+	GroupsFailed  int `json:"groupsFailed"`
+
+	Error string `json:"error"`
+}
+
+// getUsnWatermark returns the highest USN committed by the AD domain controller, USNs are local to each
+// domain controller, so the watermark also records which one it comes from
+func (l *LdapConn) getUsnWatermark() (string, error) {
+	searchReq := goldap.NewSearchRequest("", goldap.ScopeBaseObject, goldap.NeverDerefAliases, 0, 0, false,
+		"(objectClass=*)", []string{"dsServiceName", "highestCommittedUSN"}, nil)
+	searchResult, err := l.Conn.Search(searchReq)
+	if err != nil {
+		return "", err
+	}
+	if len(searchResult.Entries) == 0 {
+		return "", fmt.Errorf("the rootDSE of the domain controller is not found")
+	}
+
+	entry := searchResult.Entries[0]
+	usn := entry.GetAttributeValue("highestCommittedUSN")
+	if usn == "" {
+		return "", fmt.Errorf("the highestCommittedUSN of the domain controller is empty")
+	}
+	return fmt.Sprintf("%s#%s", entry.GetAttributeValue("dsServiceName"), usn), nil
+}
+
+// getIncrementalFilter returns the filter of the users changed since the last sync, or "" if a full sync is needed.
+// Active Directory is tracked with uSNChanged, other directories with modifyTimestamp.
+func getIncrementalFilter(ldapServer *Ldap, isAD bool, usnWatermark string) string {
+	if ldapServer.SyncMode != LdapSyncModeIncremental || ldapServer.SyncWatermark == "" {
+		return ""
+	}
+
+	filter := ldapServer.Filter
+	if !strings.HasPrefix(filter, "(") {
+		filter = fmt.Sprintf("(%s)", filter)
+	}
+
+	if !isAD {
+		return fmt.Sprintf("(&%s(modifyTimestamp>=%s))", filter, goldap.EscapeFilter(ldapServer.SyncWatermark))
+	}
+
+	i := strings.LastIndex(ldapServer.SyncWatermark, "#")
+	j := strings.LastIndex(usnWatermark, "#")
+	if i == -1 || j == -1 || ldapServer.SyncWatermark[:i] != usnWatermark[:j] {
+		// connected to another domain controller
+		return ""
+	}
+
+	usn, err := strconv.ParseInt(ldapServer.SyncWatermark[i+1:], 10, 64)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("(&%s(uSNChanged>=%d))", filter, usn+1)
+}
+
+// getTimestampWatermark returns the latest modifyTimestamp of the users, the timestamps of the server are used
+// instead of the local clock to be immune to clock skew
+func getTimestampWatermark(watermark string, ldapUsers []LdapUser) string {
+	for _, ldapUser := range ldapUsers {
+		if len(ldapUser.ModifyTimestamp) > len(watermark) ||
+			(len(ldapUser.ModifyTimestamp) == len(watermark) && ldapUser.ModifyTimestamp > watermark) {
+			watermark = ldapUser.ModifyTimestamp
+		}
+	}
+	return watermark
+}
+
+// getLdapUserKeys returns the users of the LDAP server with only their keys, and their memberOf if the groups
+// are synced, since the group membership is computed from all the users
+func (l *LdapConn) getLdapUserKeys(ldapServer *Ldap) ([]LdapUser, error) {
+	attributes := []string{"cn", "entryUUID", "uid"}
+	if l.IsAD {
+		attributes = []string{"cn", "entryUUID", "sAMAccountName"}
+	}
+	if ldapServer.EnableGroupSync {
+		attributes = append(attributes, "memberOf")
+	}
+
+	ldapUsers, err := l.searchLdapUsers(ldapServer, ldapServer.Filter, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	// an empty result is more likely a misconfiguration than an empty directory
+	if len(ldapUsers) == 0 {
+		return nil, fmt.Errorf("no users are found in the LDAP server: %s", ldapServer.ServerName)
+	}
+	return ldapUsers, nil
+}
+
+func updateLdapUsers(ldapServer *Ldap, ldapUsers []LdapUser) (int, error) {
+	users := []*User{}
+	err := ormer.Engine.Where("owner = ? and ldap <> ?", ldapServer.Owner, "").Find(&users)
+	if err != nil {
+		return 0, err
+	}
+
+	userMap := map[string]*User{}
+	for _, user := range users {
+		userMap[user.Ldap] = user
+	}
+
+	regexes, err := ldapServer.compileAttributeMappingRegexes()
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, ldapUser := range ldapUsers {
+		user, ok := userMap[ldapUser.Uuid]
+		if !ok {
+			continue
+		}
+
+		// the fields set by the attribute mappings aren't set from the default attributes, otherwise both would
+		// rewrite the field on every sync
+		columns := []string{}
+		if displayName := ldapUser.buildLdapDisplayName(); displayName != "" && displayName != user.DisplayName && !ldapServer.isMappedCasdoorName("DisplayName") {
+			user.DisplayName = displayName
+			columns = append(columns, "display_name")
+		}
+		if ldapUser.Email != "" && ldapUser.Email != user.Email && !ldapServer.isMappedCasdoorName("Email") {
+			user.Email = ldapUser.Email
+			columns = append(columns, "email")
+		}
+		if ldapUser.Mobile != "" && ldapUser.Mobile != user.Phone && !ldapServer.isMappedCasdoorName("Phone") {
+			user.Phone = ldapUser.Mobile
+			columns = append(columns, "phone")
+		}
+
+		mappedColumns, err := ldapServer.applyAttributeMappings(user, &ldapUser, regexes)
+		if err != nil {
+			return updated, err
+		}
+		for _, column := range mappedColumns {
+			if !util.InSlice(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+		if len(columns) == 0 {
+			continue
+		}
+
+		_, err = UpdateUser(user.GetId(), user, columns, false)
+		if err != nil {
+			return updated, err
+		}
+		updated++
+	}
+
+	return updated, nil
+}
+
+// getMissingLdapUsers returns the users of the organization that were synced from LDAP but no longer exist in any
+// LDAP server of the organization, since a user doesn't record which LDAP server it comes from. If another LDAP
+// server is unreachable, its users can't be told apart from the missing ones, so none is returned.
+func getMissingLdapUsers(ldapServer *Ldap, ldapUsers []LdapUser) ([]*User, error) {
+	uuids := map[string]bool{}
+	for _, ldapUser := range ldapUsers {
+		uuids[ldapUser.GetLdapUuid()] = true
+	}
+
+	ldaps, err := GetLdaps(ldapServer.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, otherLdap := range ldaps {
+		if otherLdap.Id == ldapServer.Id {
+			continue
+		}
+
+		conn, err := otherLdap.GetLdapConn()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("getMissingLdapUsers() skips the deletion of the LDAP server: %s, the LDAP server: %s is unreachable: %s", ldapServer.ServerName, otherLdap.ServerName, err.Error()))
+			return nil, nil
+		}
+
+		otherUsers, err := conn.getLdapUserKeys(otherLdap)
+		conn.Close()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("getMissingLdapUsers() skips the deletion of the LDAP server: %s, the users of the LDAP server: %s can't be fetched: %s", ldapServer.ServerName, otherLdap.ServerName, err.Error()))
+			return nil, nil
+		}
+
+		for _, ldapUser := range otherUsers {
+			uuids[ldapUser.GetLdapUuid()] = true
+		}
+	}
+
+	users := []*User{}
+	err = ormer.Engine.Where("owner = ? and ldap <> ?", ldapServer.Owner, "").Find(&users)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*User{}
+	for _, user := range users {
+		if !uuids[user.Ldap] && !user.IsDeleted {
+			res = append(res, user)
+		}
+	}
+	return res, nil
+}
+
+func removeMissingLdapUsers(ldapServer *Ldap, ldapUsers []LdapUser, stats *LdapSyncStats) error {
+	users, err := getMissingLdapUsers(ldapServer, ldapUsers)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		switch ldapServer.DeletionPolicy {
+		case LdapDeletionPolicyDisable:
+			if user.IsForbidden {
+				continue
+			}
+
+			user.IsForbidden = true
+			_, err = UpdateUser(user.GetId(), user, []string{"is_forbidden"}, false)
+			if err != nil {
+				return err
+			}
+			stats.Disabled++
+		case LdapDeletionPolicyDelete:
+			_, err = DeleteUser(user)
+			if err != nil {
+				return err
+			}
+			stats.Deleted++
+		}
+	}
+
+	return nil
+}
+
+// SyncLdapServer syncs the users of the LDAP server that changed since the last sync, handles the users removed
+// from the LDAP server according to its deletion policy, syncs the groups if enabled and records the stats of the run
+func SyncLdapServer(ldapServer *Ldap, conn *LdapConn) (*LdapSyncStats, error) {
+	stats := &LdapSyncStats{StartTime: util.GetCurrentTime(), Mode: LdapSyncModeFull}
+	err := syncLdapServer(ldapServer, conn, stats)
+	if err != nil {
+		stats.Error = err.Error()
+	}
+	stats.EndTime = util.GetCurrentTime()
+
+	_, updateErr := ormer.Engine.ID(ldapServer.Id).Cols("sync_watermark", "sync_stats").Update(ldapServer)
+	if err == nil {
+		err = updateErr
+	}
+	return stats, err
+}
+
+func syncLdapServer(ldapServer *Ldap, conn *LdapConn, stats *LdapSyncStats) error {
+	ldapServer.SyncStats = stats
+
+	watermark := ""
+	if conn.IsAD {
+		var err error
+		watermark, err = conn.getUsnWatermark()
+		if err != nil {
+			return err
+		}
+	}
+
+	filter := ldapServer.Filter
+	if incrementalFilter := getIncrementalFilter(ldapServer, conn.IsAD, watermark); incrementalFilter != "" {
+		filter = incrementalFilter
+		stats.Mode = LdapSyncModeIncremental
+	}
+
+	ldapUsers, err := conn.searchLdapUsers(ldapServer, filter, conn.getLdapUserAttributes(ldapServer))
+	if err != nil {
+		return err
+	}
+	stats.Fetched = len(ldapUsers)
+
+	if !conn.IsAD {
+		if stats.Mode == LdapSyncModeIncremental {
+			watermark = ldapServer.SyncWatermark
+		}
+		watermark = getTimestampWatermark(watermark, ldapUsers)
+	}
+
+	if len(ldapUsers) != 0 {
+		existUsers, failedUsers, err := SyncLdapUsers(ldapServer.Owner, AutoAdjustLdapUser(ldapUsers), ldapServer.Id)
+		if err != nil {
+			return err
+		}
+		stats.Added = len(ldapUsers) - len(existUsers) - len(failedUsers)
+		stats.Failed = len(failedUsers)
+
+		stats.Updated, err = updateLdapUsers(ldapServer, existUsers)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the deletion detection and the group membership need all the users, only their keys are fetched
+	// in the incremental mode
+	allUsers := ldapUsers
+	needAllUsers := ldapServer.EnableGroupSync || (ldapServer.DeletionPolicy != "" && ldapServer.DeletionPolicy != LdapDeletionPolicyNone)
+	if stats.Mode == LdapSyncModeIncremental && needAllUsers {
+		allUsers, err = conn.getLdapUserKeys(ldapServer)
+		if err != nil {
+			return err
+		}
+	}
+
+	if ldapServer.DeletionPolicy == LdapDeletionPolicyDisable || ldapServer.DeletionPolicy == LdapDeletionPolicyDelete {
+		if len(allUsers) == 0 {
+			return fmt.Errorf("no users are found in the LDAP server: %s, the deletion is skipped", ldapServer.ServerName)
+		}
+
+		err = removeMissingLdapUsers(ldapServer, allUsers, stats)
+		if err != nil {
+			return err
+		}
+	}
+
+	if ldapServer.EnableGroupSync {
+		ldapGroups, err := conn.GetLdapGroups(ldapServer)
+		if err != nil {
+			return err
+		}
+
+		res, err := SyncLdapGroups(ldapServer, ldapGroups, allUsers)
+		if err != nil {
+			return err
+		}
+		stats.GroupsAdded = len(res.Added)
+		stats.GroupsUpdated = len(res.Updated)
+		stats.GroupsRemoved = len(res.Removed)
+		stats.GroupsFailed = len(res.Failed)
+	}
+
+	// the failed users are retried by the next run
+	if stats.Failed == 0 {
+		ldapServer.SyncWatermark = watermark
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetIncrementalFilter(t *testing.T) {
+	ldapServer := &Ldap{Filter: "(objectClass=posixAccount)", SyncMode: LdapSyncModeFull, SyncWatermark: "20250101000000Z"}
+	assert.Equal(t, "", getIncrementalFilter(ldapServer, false, ""))
+
+	ldapServer.SyncMode = LdapSyncModeIncremental
+	assert.Equal(t, "(&(objectClass=posixAccount)(modifyTimestamp>=20250101000000Z))", getIncrementalFilter(ldapServer, false, ""))
+
+	ldapServer.Filter = "objectClass=user"
+	ldapServer.SyncWatermark = "CN=NTDS Settings,CN=DC1#1000"
+	assert.Equal(t, "(&(objectClass=user)(uSNChanged>=1001))", getIncrementalFilter(ldapServer, true, "CN=NTDS Settings,CN=DC1#1200"))
+	assert.Equal(t, "", getIncrementalFilter(ldapServer, true, "CN=NTDS Settings,CN=DC2#1200"))
+
+	ldapServer.SyncWatermark = ""
+	assert.Equal(t, "", getIncrementalFilter(ldapServer, true, "CN=NTDS Settings,CN=DC1#1200"))
+}
+
+func TestGetTimestampWatermark(t *testing.T) {
+	ldapUsers := []LdapUser{
+		{ModifyTimestamp: "20250301000000Z"},
+		{ModifyTimestamp: "20250401000000Z"},
+		{},
+	}
+	assert.Equal(t, "20250401000000Z", getTimestampWatermark("", ldapUsers))
+	assert.Equal(t, "20250501000000Z", getTimestampWatermark("20250501000000Z", ldapUsers))
+	assert.Equal(t, "20250501000000Z", getTimestampWatermark("20250501000000Z", nil))
+}
@@ -49,6 +49,9 @@ const (
 	RequiredMfa      = "RequiredMfa"
 )
 
+// mfaVerificationMethod is the rule of the providers that send the verification codes of MFA
+const mfaVerificationMethod = "mfaAuth"
+
 func GetMfaUtil(mfaType string, config *MfaProps) MfaInterface {
 	switch mfaType {
 	case SmsType:
@@ -183,3 +186,47 @@ func SetPreferredMultiFactorAuth(user *User, mfaType string) error {
 	}
 	return nil
 }
+
+// SendMfaVerificationCode sends the verification code of the SMS or email MFA of the user with the providers of the
+// application, for the sign-ins outside of the web like RADIUS and TACACS+. The code is checked by the Verify() of
+// the MFA like the one of a web sign-in.
+func SendMfaVerificationCode(application *Application, user *User, mfaType string, remoteAddr string) error {
+	mfaProps := user.GetMfaProps(mfaType, false)
+	if !mfaProps.Enabled || (mfaType != SmsType && mfaType != EmailType) {
+		return fmt.Errorf("the user: %s has no %s MFA to send a verification code to", user.GetId(), mfaType)
+	}
+
+	organization, err := getOrganization("admin", user.Owner)
+	if err != nil {
+		return err
+	}
+	if organization == nil {
+		return fmt.Errorf("the organization: %s does not exist", user.Owner)
+	}
+
+	if mfaType == EmailType {
+		provider, err := application.GetEmailProvider(mfaVerificationMethod)
+		if err != nil {
+			return err
+		}
+		if provider == nil {
+			return fmt.Errorf("please add an Email provider to the \"Providers\" list for the application: %s", application.Name)
+		}
+
+		return SendVerificationCodeToEmail(organization, user, provider, remoteAddr, mfaProps.Secret)
+	}
+
+	provider, err := application.GetSmsProvider(mfaVerificationMethod, user.GetCountryCode(mfaProps.CountryCode))
+	if err != nil {
+		return err
+	}
+	if provider == nil {
+		return fmt.Errorf("please add a SMS provider to the \"Providers\" list for the application: %s", application.Name)
+	}
+
+	phone, ok := util.GetE164Number(mfaProps.Secret, mfaProps.CountryCode)
+	if !ok {
+		return fmt.Errorf("the phone number of the user: %s is invalid in the region: %s", user.GetId(), mfaProps.CountryCode)
+	}
+	return SendVerificationCodeToPhone(organization, user, provider, remoteAddr, phone)
+}
@@ -423,6 +423,31 @@ func (a *Ormer) createTable() {
 		panic(err)
 	}
 
+	err = a.Engine.Sync2(new(TacacsAccounting))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(RadiusClient))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(Ticket))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(CasSessionService))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(LdapProvisionTask))
+	if err != nil {
+		panic(err)
+	}
+
 	err = a.Engine.Sync2(new(xormadapter.CasbinRule))
 	if err != nil {
 		panic(err)
@@ -6,6 +6,7 @@ import (
 
 	"github.com/casdoor/casdoor/util"
 	"github.com/xorm-io/core"
+	"github.com/xorm-io/xorm"
 )
 
 // https://www.cisco.com/c/en/us/td/docs/ios-xml/ios/sec_usr_radatt/configuration/xe-16/sec-usr-radatt-xe-16-book/sec-rad-ov-ietf-attr.html
@@ -36,6 +37,10 @@ type RadiusAccounting struct {
 	LastUpdate         time.Time `json:"lastUpdate"`
 	AcctStartTime      time.Time `xorm:"index" json:"acctStartTime"`
 	AcctStopTime       time.Time `xorm:"index" json:"acctStopTime"`
+
+	RadiusClient string `xorm:"varchar(100)" json:"radiusClient"` // The RADIUS client that sent the accounting request, it's the target of the dynamic authorization.
+	ClientIp     string `xorm:"varchar(100)" json:"clientIp"`     // The source address of the accounting request, the dynamic authorization requests are sent to it.
+	UserId       string `xorm:"varchar(100) index" json:"userId"` // The Casdoor user resolved from the User-Name, it's the user of the dynamic authorization.
 }
 
 func (ra *RadiusAccounting) GetId() string {
@@ -58,9 +63,38 @@ func getRadiusAccounting(owner, name string) (*RadiusAccounting, error) {
 	}
 }
 
-func getPaginationRadiusAccounting(owner, field, value, sortField, sortOrder string, offset, limit int) ([]*RadiusAccounting, error) {
-	ras := []*RadiusAccounting{}
+// getRadiusAccountingSession filters the sessions of a user, and the sessions that are active or stopped
+func getRadiusAccountingSession(owner string, offset, limit int, field, value, sortField, sortOrder, user, isActive string) *xorm.Session {
 	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	if user != "" {
+		session = session.And("username = ?", user)
+	}
+	if isActive == "true" {
+		session = session.And("acct_stop_time is null")
+	} else if isActive == "false" {
+		session = session.And("acct_stop_time is not null")
+	}
+	return session
+}
+
+func GetRadiusAccountingCount(owner, field, value, user, isActive string) (int64, error) {
+	session := getRadiusAccountingSession(owner, -1, -1, field, value, "", "", user, isActive)
+	return session.Count(&RadiusAccounting{})
+}
+
+func GetRadiusAccountings(owner, user, isActive string) ([]*RadiusAccounting, error) {
+	ras := []*RadiusAccounting{}
+	session := getRadiusAccountingSession(owner, -1, -1, "", "", "", "", user, isActive)
+	err := session.Find(&ras)
+	if err != nil {
+		return ras, err
+	}
+	return ras, nil
+}
+
+func GetPaginationRadiusAccountings(owner string, offset, limit int, field, value, sortField, sortOrder, user, isActive string) ([]*RadiusAccounting, error) {
+	ras := []*RadiusAccounting{}
+	session := getRadiusAccountingSession(owner, offset, limit, field, value, sortField, sortOrder, user, isActive)
 	err := session.Find(&ras)
 	if err != nil {
 		return ras, err
@@ -73,8 +107,11 @@ func GetRadiusAccounting(id string) (*RadiusAccounting, error) {
 	return getRadiusAccounting(owner, name)
 }
 
-func GetRadiusAccountingBySessionId(sessionId string) (*RadiusAccounting, error) {
-	ras, err := getPaginationRadiusAccounting("", "acct_session_id", sessionId, "created_time", "desc", 0, 1)
+// GetRadiusAccountingBySessionId returns the latest session of the id accounted by the RADIUS client, so a NAS can't
+// update the sessions of the other clients
+func GetRadiusAccountingBySessionId(radiusClient string, sessionId string) (*RadiusAccounting, error) {
+	ras := []*RadiusAccounting{}
+	err := ormer.Engine.Where("radius_client = ? and acct_session_id = ?", radiusClient, sessionId).Desc("created_time").Limit(1).Find(&ras)
 	if err != nil {
 		return nil, err
 	}
@@ -109,6 +146,9 @@ func InterimUpdateRadiusAccounting(oldRa *RadiusAccounting, newRa *RadiusAccount
 	oldRa.AcctInputPackets = newRa.AcctInputPackets
 	oldRa.AcctOutputPackets = newRa.AcctOutputPackets
 	oldRa.AcctSessionTime = newRa.AcctSessionTime
+	if oldRa.UserId == "" {
+		oldRa.UserId = newRa.UserId
+	}
 	if stop {
 		oldRa.AcctStopTime = newRa.AcctStopTime
 		if oldRa.AcctStopTime.IsZero() {
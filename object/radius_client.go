@@ -0,0 +1,373 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+// the enabled RADIUS clients are cached for radiusClientCacheTtl, and cleared when a client is changed
+const radiusClientCacheTtl = time.Minute
+
+var (
+	radiusClientCacheLock sync.Mutex
+	radiusClientCache     []*RadiusClient
+	radiusClientCacheTime time.Time
+)
+
+// RadiusClient is a NAS (network access server) that sends RADIUS requests, e.g. a VPN concentrator or a Wi-Fi
+// controller, it's identified by the source address of the requests
+type RadiusClient struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	DisplayName string `xorm:"varchar(100)" json:"displayName"`
+
+	Address      string   `xorm:"varchar(100)" json:"address"`
+	Secret       string   `xorm:"varchar(100)" json:"secret"`
+	Organization string   `xorm:"varchar(100)" json:"organization"`
+	Applications []string `xorm:"varchar(1000)" json:"applications"`
+	IsEnabled    bool     `json:"isEnabled"`
+
+	RequiredRoles   []string                `xorm:"varchar(1000)" json:"requiredRoles"`
+	ReplyAttributes []*RadiusReplyAttribute `xorm:"mediumtext" json:"replyAttributes"`
+
+	EnableDynamicAuthorization bool `json:"enableDynamicAuthorization"`
+	DynamicAuthorizationPort   int  `json:"dynamicAuthorizationPort"`
+}
+
+func GetRadiusClientCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&RadiusClient{})
+}
+
+func GetRadiusClients(owner string) ([]*RadiusClient, error) {
+	radiusClients := []*RadiusClient{}
+	err := ormer.Engine.Desc("created_time").Find(&radiusClients, &RadiusClient{Owner: owner})
+	if err != nil {
+		return radiusClients, err
+	}
+
+	return radiusClients, nil
+}
+
+func GetPaginationRadiusClients(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*RadiusClient, error) {
+	radiusClients := []*RadiusClient{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&radiusClients)
+	if err != nil {
+		return radiusClients, err
+	}
+
+	return radiusClients, nil
+}
+
+func getRadiusClient(owner string, name string) (*RadiusClient, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	radiusClient := RadiusClient{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&radiusClient)
+	if err != nil {
+		return &radiusClient, err
+	}
+
+	if existed {
+		return &radiusClient, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetRadiusClient(id string) (*RadiusClient, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getRadiusClient(owner, name)
+}
+
+func GetMaskedRadiusClient(radiusClient *RadiusClient, errs ...error) (*RadiusClient, error) {
+	if len(errs) > 0 && errs[0] != nil {
+		return nil, errs[0]
+	}
+
+	if radiusClient == nil {
+		return nil, nil
+	}
+
+	if radiusClient.Secret != "" {
+		radiusClient.Secret = "***"
+	}
+	return radiusClient, nil
+}
+
+func GetMaskedRadiusClients(radiusClients []*RadiusClient, errs ...error) ([]*RadiusClient, error) {
+	if len(errs) > 0 && errs[0] != nil {
+		return nil, errs[0]
+	}
+
+	var err error
+	for _, radiusClient := range radiusClients {
+		radiusClient, err = GetMaskedRadiusClient(radiusClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return radiusClients, nil
+}
+
+func UpdateRadiusClient(id string, radiusClient *RadiusClient) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	oldRadiusClient, err := getRadiusClient(owner, name)
+	if err != nil {
+		return false, err
+	} else if oldRadiusClient == nil {
+		return false, nil
+	}
+
+	if radiusClient.Secret == "***" {
+		radiusClient.Secret = oldRadiusClient.Secret
+	}
+
+	err = checkRadiusClientOverlap(radiusClient, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Update(radiusClient)
+	if err != nil {
+		return false, err
+	}
+
+	clearRadiusClientCache()
+
+	return affected != 0, nil
+}
+
+func AddRadiusClient(radiusClient *RadiusClient) (bool, error) {
+	err := checkRadiusClientOverlap(radiusClient, "")
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := ormer.Engine.Insert(radiusClient)
+	if err != nil {
+		return false, err
+	}
+
+	clearRadiusClientCache()
+
+	return affected != 0, nil
+}
+
+func DeleteRadiusClient(radiusClient *RadiusClient) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{radiusClient.Owner, radiusClient.Name}).Delete(&RadiusClient{})
+	if err != nil {
+		return false, err
+	}
+
+	clearRadiusClientCache()
+
+	return affected != 0, nil
+}
+
+func (radiusClient *RadiusClient) GetId() string {
+	return fmt.Sprintf("%s/%s", radiusClient.Owner, radiusClient.Name)
+}
+
+// getIpNet returns the network of the address, a single IP is a network with the full mask
+func (radiusClient *RadiusClient) getIpNet() (*net.IPNet, error) {
+	address := strings.TrimSpace(radiusClient.Address)
+	if strings.Contains(address, "/") {
+		_, ipNet, err := net.ParseCIDR(address)
+		return ipNet, err
+	}
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return nil, fmt.Errorf("the address: %s of the RADIUS client is not an IP or CIDR", radiusClient.Address)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// GetOrganization returns the organization of the users of the client, the organization of the clients owned by an
+// organization is always their owner
+func (radiusClient *RadiusClient) GetOrganization() string {
+	if radiusClient.Organization != "" || radiusClient.Owner == "admin" {
+		return radiusClient.Organization
+	}
+	return radiusClient.Owner
+}
+
+func (radiusClient *RadiusClient) CheckRadiusClient() error {
+	if _, err := radiusClient.getIpNet(); err != nil {
+		return err
+	}
+
+	if radiusClient.Owner != "admin" && radiusClient.Organization != "" && radiusClient.Organization != radiusClient.Owner {
+		return fmt.Errorf("the organization of the RADIUS client should be its owner: %s", radiusClient.Owner)
+	}
+
+	if radiusClient.Secret == "" {
+		return fmt.Errorf("the secret of the RADIUS client should not be empty")
+	}
+
+	return radiusClient.checkReplyAttributes()
+}
+
+// matchRadiusClient returns the enabled client whose network contains the IP, the most specific network wins so that
+// a device can have its own secret inside a subnet
+func matchRadiusClient(radiusClients []*RadiusClient, ip net.IP) *RadiusClient {
+	var res *RadiusClient
+	resOnes := -1
+	for _, radiusClient := range radiusClients {
+		if !radiusClient.IsEnabled {
+			continue
+		}
+
+		ipNet, err := radiusClient.getIpNet()
+		if err != nil || !ipNet.Contains(ip) {
+			continue
+		}
+
+		ones, _ := ipNet.Mask.Size()
+		if ones > resOnes {
+			res = radiusClient
+			resOnes = ones
+		}
+	}
+	return res
+}
+
+// getOverlappingRadiusClient returns a client of another organization whose network overlaps the network of the
+// client, a source address must identify the organization of its users
+func getOverlappingRadiusClient(radiusClients []*RadiusClient, radiusClient *RadiusClient) *RadiusClient {
+	ipNet, err := radiusClient.getIpNet()
+	if err != nil {
+		return nil
+	}
+
+	for _, other := range radiusClients {
+		if other.GetOrganization() == radiusClient.GetOrganization() {
+			continue
+		}
+
+		otherIpNet, err := other.getIpNet()
+		if err != nil {
+			continue
+		}
+		if ipNet.Contains(otherIpNet.IP) || otherIpNet.Contains(ipNet.IP) {
+			return other
+		}
+	}
+	return nil
+}
+
+// checkRadiusClientOverlap checks that the network of the client doesn't overlap the clients of other organizations,
+// id is the ID of the client before the update, "" for a new client
+func checkRadiusClientOverlap(radiusClient *RadiusClient, id string) error {
+	radiusClients := []*RadiusClient{}
+	err := ormer.Engine.Find(&radiusClients)
+	if err != nil {
+		return err
+	}
+
+	others := []*RadiusClient{}
+	for _, other := range radiusClients {
+		if other.GetId() != id {
+			others = append(others, other)
+		}
+	}
+
+	if other := getOverlappingRadiusClient(others, radiusClient); other != nil {
+		return fmt.Errorf("the address: %s of the RADIUS client overlaps the address: %s of the RADIUS client: %s of another organization",
+			radiusClient.Address, other.Address, other.GetId())
+	}
+	return nil
+}
+
+// getEnabledRadiusClients returns the enabled clients, they are cached since they are matched for every packet
+func getEnabledRadiusClients() ([]*RadiusClient, error) {
+	radiusClientCacheLock.Lock()
+	defer radiusClientCacheLock.Unlock()
+
+	if radiusClientCache != nil && time.Since(radiusClientCacheTime) < radiusClientCacheTtl {
+		return radiusClientCache, nil
+	}
+
+	radiusClients := []*RadiusClient{}
+	err := ormer.Engine.Where("is_enabled = ?", true).Find(&radiusClients)
+	if err != nil {
+		return nil, err
+	}
+
+	radiusClientCache = radiusClients
+	radiusClientCacheTime = time.Now()
+	return radiusClients, nil
+}
+
+func clearRadiusClientCache() {
+	radiusClientCacheLock.Lock()
+	defer radiusClientCacheLock.Unlock()
+
+	radiusClientCache = nil
+}
+
+// GetRadiusClientByIp returns the RADIUS client of the source address of a request, or nil if no client matches.
+// isRegistered is true if any RADIUS client is enabled, the unknown addresses are then rejected.
+func GetRadiusClientByIp(ip net.IP) (radiusClient *RadiusClient, isRegistered bool, err error) {
+	radiusClients, err := getEnabledRadiusClients()
+	if err != nil {
+		return nil, false, err
+	}
+
+	return matchRadiusClient(radiusClients, ip), len(radiusClients) != 0, nil
+}
+
+// CheckRadiusClientApplications checks that the user can sign in to one of the allowed applications of the client,
+// all users are allowed if the client has no allowed applications
+func (radiusClient *RadiusClient) CheckRadiusClientApplications(user *User) (bool, error) {
+	if len(radiusClient.Applications) == 0 {
+		return true, nil
+	}
+
+	for _, applicationName := range radiusClient.Applications {
+		application, err := getApplication("admin", applicationName)
+		if err != nil {
+			return false, err
+		}
+		if application == nil || (application.Organization != user.Owner && !application.IsShared) {
+			continue
+		}
+
+		allowed, err := CheckLoginPermission(user.GetId(), application)
+		if err != nil {
+			return false, err
+		}
+		if allowed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchRadiusClient(t *testing.T) {
+	radiusClients := []*RadiusClient{
+		{Name: "vpn-subnet", Address: "10.0.0.0/16", Secret: "subnet", IsEnabled: true},
+		{Name: "vpn-1", Address: "10.0.1.1", Secret: "vpn-1", IsEnabled: true},
+		{Name: "wifi", Address: "fd00::/64", Secret: "wifi", IsEnabled: true},
+		{Name: "disabled", Address: "192.168.0.1", Secret: "disabled"},
+	}
+	for _, radiusClient := range radiusClients {
+		assert.Nil(t, radiusClient.CheckRadiusClient())
+	}
+
+	assert.Equal(t, "vpn-1", matchRadiusClient(radiusClients, net.ParseIP("10.0.1.1")).Name)
+	assert.Equal(t, "vpn-subnet", matchRadiusClient(radiusClients, net.ParseIP("10.0.2.1")).Name)
+	assert.Equal(t, "wifi", matchRadiusClient(radiusClients, net.ParseIP("fd00::1")).Name)
+	assert.Nil(t, matchRadiusClient(radiusClients, net.ParseIP("192.168.0.1")))
+	assert.Nil(t, matchRadiusClient(radiusClients, net.ParseIP("172.16.0.1")))
+
+	assert.NotNil(t, (&RadiusClient{Address: "10.0.0.0/33", Secret: "secret"}).CheckRadiusClient())
+	assert.NotNil(t, (&RadiusClient{Address: "vpn.example.com", Secret: "secret"}).CheckRadiusClient())
+	assert.NotNil(t, (&RadiusClient{Address: "10.0.0.1"}).CheckRadiusClient())
+}
+
+func TestRadiusClientOrganization(t *testing.T) {
+	assert.Equal(t, "org-a", (&RadiusClient{Owner: "org-a"}).GetOrganization())
+	assert.Equal(t, "org-b", (&RadiusClient{Owner: "admin", Organization: "org-b"}).GetOrganization())
+	assert.NotNil(t, (&RadiusClient{Owner: "org-a", Organization: "org-b", Address: "10.0.0.1", Secret: "secret"}).CheckRadiusClient())
+
+	radiusClients := []*RadiusClient{
+		{Owner: "org-a", Name: "vpn", Address: "10.0.0.0/16"},
+		{Owner: "admin", Name: "wifi", Address: "10.1.0.1", Organization: "org-b"},
+	}
+
+	// the networks of different organizations can't overlap, the networks of the same organization can
+	assert.Equal(t, "vpn", getOverlappingRadiusClient(radiusClients, &RadiusClient{Owner: "org-b", Address: "10.0.1.1"}).Name)
+	assert.Equal(t, "wifi", getOverlappingRadiusClient(radiusClients, &RadiusClient{Owner: "org-a", Address: "10.1.0.0/24"}).Name)
+	assert.Nil(t, getOverlappingRadiusClient(radiusClients, &RadiusClient{Owner: "org-a", Address: "10.0.1.1"}))
+	assert.Nil(t, getOverlappingRadiusClient(radiusClients, &RadiusClient{Owner: "org-c", Address: "10.2.0.1"}))
+}
@@ -0,0 +1,213 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+	"layeh.com/radius"
+	"layeh.com/radius/rfc2865"
+	"layeh.com/radius/rfc2866"
+	"layeh.com/radius/rfc3576"
+)
+
+const (
+	defaultRadiusDynamicAuthorizationPort = 3799
+	radiusDynamicAuthorizationTimeout     = 5 * time.Second
+)
+
+// getRadiusClient returns the RADIUS client that the session is accounted by, it's nil for the sessions accounted
+// before the RADIUS clients are recorded
+func (ra *RadiusAccounting) getRadiusClient() (*RadiusClient, error) {
+	if ra.RadiusClient == "" {
+		return nil, nil
+	}
+	return GetRadiusClient(ra.RadiusClient)
+}
+
+// getDynamicAuthorizationTarget returns the address and secret of the NAS of a session, the NAS listens on the port
+// of RFC 5176 at the source address of the accounting requests. The NAS-IP-Address isn't used since the NAS can set
+// it to any host.
+func (ra *RadiusAccounting) getDynamicAuthorizationTarget() (string, []byte, error) {
+	radiusClient, err := ra.getRadiusClient()
+	if err != nil {
+		return "", nil, err
+	}
+
+	ip := net.ParseIP(ra.ClientIp)
+	if ip == nil {
+		return "", nil, fmt.Errorf("the NAS address of the RADIUS session: %s is unknown", ra.AcctSessionId)
+	}
+
+	port := defaultRadiusDynamicAuthorizationPort
+	secret := conf.GetConfigString("radiusSecret")
+	if radiusClient != nil {
+		if radiusClient.DynamicAuthorizationPort != 0 {
+			port = radiusClient.DynamicAuthorizationPort
+		}
+		secret = radiusClient.Secret
+	}
+	return net.JoinHostPort(ip.String(), strconv.Itoa(port)), []byte(secret), nil
+}
+
+// newDynamicAuthorizationRequest returns a Disconnect-Request or CoA-Request with the attributes that identify the
+// session, see RFC 5176, 3
+func (ra *RadiusAccounting) newDynamicAuthorizationRequest(code radius.Code, secret []byte) (*radius.Packet, error) {
+	p := radius.New(code, secret)
+	err := rfc2866.AcctSessionID_SetString(p, ra.AcctSessionId)
+	if err == nil && ra.Username != "" {
+		err = rfc2865.UserName_SetString(p, ra.Username)
+	}
+	if ip := net.ParseIP(ra.NasIpAddr).To4(); err == nil && ip != nil && !ip.IsUnspecified() {
+		err = rfc2865.NASIPAddress_Set(p, ip)
+	}
+	if ip := net.ParseIP(ra.FramedIpAddr).To4(); err == nil && ip != nil && !ip.IsUnspecified() {
+		err = rfc2865.FramedIPAddress_Set(p, ip)
+	}
+	return p, err
+}
+
+func (ra *RadiusAccounting) sendDynamicAuthorizationRequest(code radius.Code, addAttributes func(p *radius.Packet) error) error {
+	addr, secret, err := ra.getDynamicAuthorizationTarget()
+	if err != nil {
+		return err
+	}
+
+	request, err := ra.newDynamicAuthorizationRequest(code, secret)
+	if err != nil {
+		return err
+	}
+	if addAttributes != nil {
+		err = addAttributes(request)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), radiusDynamicAuthorizationTimeout)
+	defer cancel()
+	response, err := radius.Exchange(ctx, request, addr)
+	if err != nil {
+		return fmt.Errorf("the NAS: %s doesn't respond to the %s of the RADIUS session: %s: %s", addr, code, ra.AcctSessionId, err.Error())
+	}
+
+	if response.Code != radius.CodeDisconnectACK && response.Code != radius.CodeCoAACK {
+		return fmt.Errorf("the NAS: %s rejects the %s of the RADIUS session: %s, error cause: %s", addr, code, ra.AcctSessionId, rfc3576.ErrorCause_Get(response))
+	}
+	return nil
+}
+
+// DisconnectRadiusAccounting sends a Disconnect-Request to the NAS of the session, the session is stopped if the NAS
+// acknowledges it, in case the NAS doesn't send the Accounting-Request of the stop
+func DisconnectRadiusAccounting(ra *RadiusAccounting) error {
+	err := ra.sendDynamicAuthorizationRequest(radius.CodeDisconnectRequest, nil)
+	if err != nil {
+		return err
+	}
+
+	if !ra.AcctStopTime.IsZero() {
+		return nil
+	}
+
+	ra.AcctStopTime = time.Now()
+	ra.AcctTerminateCause = int64(rfc2866.AcctTerminateCause_Value_AdminReset)
+	_, err = ormer.Engine.ID(core.PK{ra.Owner, ra.Name}).Cols("acct_stop_time", "acct_terminate_cause").Update(ra)
+	return err
+}
+
+// ReauthorizeRadiusAccounting sends a CoA-Request with the current reply attributes of the user to the NAS of the
+// session, e.g. the VLAN or the filter of new roles
+func ReauthorizeRadiusAccounting(ra *RadiusAccounting) error {
+	radiusClient, err := ra.getRadiusClient()
+	if err != nil {
+		return err
+	}
+	if radiusClient == nil {
+		return fmt.Errorf("the RADIUS session: %s has no RADIUS client for the reply attributes", ra.AcctSessionId)
+	}
+
+	if ra.UserId == "" {
+		return fmt.Errorf("the RADIUS session: %s has no Casdoor user", ra.AcctSessionId)
+	}
+
+	user, err := GetUser(ra.UserId)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("the user: %s doesn't exist", ra.UserId)
+	}
+
+	return ra.sendDynamicAuthorizationRequest(radius.CodeCoARequest, func(p *radius.Packet) error {
+		return radiusClient.AddRadiusReplyAttributes(p, user)
+	})
+}
+
+// getActiveRadiusAccountingsOfUser returns the sessions of the user that aren't stopped, the sessions are matched by
+// the resolved user because the User-Name can be the email, phone or "organization/name" of the user
+func getActiveRadiusAccountingsOfUser(user *User) ([]*RadiusAccounting, error) {
+	ras := []*RadiusAccounting{}
+	err := ormer.Engine.Where("user_id = ? and acct_stop_time is null", user.GetId()).Find(&ras)
+	return ras, err
+}
+
+// updateRadiusAccountingsOfUser disconnects the sessions of a forbidden or deleted user, and sends the new reply
+// attributes to the sessions of a user whose groups change. Only the RADIUS clients that enable the dynamic
+// authorization are notified, in the background because the NAS can be slow or unreachable.
+func updateRadiusAccountingsOfUser(oldUser *User, user *User) {
+	isDisabled := user == nil || user.IsForbidden || user.IsDeleted
+	if oldUser == nil || oldUser.IsForbidden || oldUser.IsDeleted {
+		return
+	}
+	if !isDisabled && strings.Join(oldUser.Groups, ",") == strings.Join(user.Groups, ",") {
+		return
+	}
+
+	ras, err := getActiveRadiusAccountingsOfUser(oldUser)
+	if err != nil {
+		logs.Warning(fmt.Sprintf("updateRadiusAccountingsOfUser() error: %s", err.Error()))
+		return
+	}
+	if len(ras) == 0 {
+		return
+	}
+
+	util.SafeGoroutine(func() {
+		for _, ra := range ras {
+			radiusClient, err := ra.getRadiusClient()
+			if err != nil || radiusClient == nil || !radiusClient.EnableDynamicAuthorization {
+				continue
+			}
+
+			if isDisabled {
+				err = DisconnectRadiusAccounting(ra)
+			} else {
+				err = ReauthorizeRadiusAccounting(ra)
+			}
+			if err != nil {
+				logs.Warning(fmt.Sprintf("updateRadiusAccountingsOfUser() error: %s", err.Error()))
+			}
+		}
+	})
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"layeh.com/radius"
+	"layeh.com/radius/rfc2865"
+	"layeh.com/radius/rfc2866"
+)
+
+func TestNewDynamicAuthorizationRequest(t *testing.T) {
+	ra := &RadiusAccounting{
+		Owner:         "built-in",
+		Username:      "alice",
+		AcctSessionId: "session-1",
+		NasIpAddr:     "0.0.0.0",
+		FramedIpAddr:  "10.0.1.100",
+		ClientIp:      "192.168.0.1",
+	}
+
+	addr, _, err := ra.getDynamicAuthorizationTarget()
+	assert.Nil(t, err)
+	assert.Equal(t, "192.168.0.1:3799", addr)
+
+	// the NAS-IP-Address is sent in the request but isn't the target
+	ra.NasIpAddr = "192.168.0.2"
+	addr, _, err = ra.getDynamicAuthorizationTarget()
+	assert.Nil(t, err)
+	assert.Equal(t, "192.168.0.1:3799", addr)
+
+	p, err := ra.newDynamicAuthorizationRequest(radius.CodeDisconnectRequest, []byte("secret"))
+	assert.Nil(t, err)
+	assert.Equal(t, radius.CodeDisconnectRequest, p.Code)
+	assert.Equal(t, "session-1", rfc2866.AcctSessionID_GetString(p))
+	assert.Equal(t, "alice", rfc2865.UserName_GetString(p))
+	assert.True(t, net.ParseIP("192.168.0.2").Equal(rfc2865.NASIPAddress_Get(p)))
+	assert.True(t, net.ParseIP("10.0.1.100").Equal(rfc2865.FramedIPAddress_Get(p)))
+
+	ra.ClientIp = ""
+	_, _, err = ra.getDynamicAuthorizationTarget()
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,45 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import "github.com/casdoor/casdoor/util"
+
+// getRadiusMfaApplication returns the application whose providers send the verification codes of the RADIUS MFA,
+// it's the first allowed application of the RADIUS client for the user, or the default application of the organization
+func getRadiusMfaApplication(radiusClient *RadiusClient, user *User) (*Application, error) {
+	if radiusClient != nil {
+		for _, applicationName := range radiusClient.Applications {
+			application, err := getApplication("admin", applicationName)
+			if err != nil {
+				return nil, err
+			}
+			if application != nil && (application.Organization == user.Owner || application.IsShared) {
+				return application, nil
+			}
+		}
+	}
+
+	return GetDefaultApplication(util.GetId("admin", user.Owner))
+}
+
+// SendRadiusVerificationCode sends the verification code of the SMS or email MFA of the user for an Access-Challenge
+func SendRadiusVerificationCode(radiusClient *RadiusClient, user *User, mfaType string, remoteAddr string) error {
+	application, err := getRadiusMfaApplication(radiusClient, user)
+	if err != nil {
+		return err
+	}
+
+	return SendMfaVerificationCode(application, user, mfaType, remoteAddr)
+}
@@ -0,0 +1,330 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+	"layeh.com/radius"
+	"layeh.com/radius/rfc2865"
+)
+
+// the kinds of the values of RADIUS attributes
+const (
+	RadiusAttributeString  = "String"
+	RadiusAttributeInteger = "Integer"
+	RadiusAttributeIp      = "IP"
+)
+
+const radiusVendorSpecific = "Vendor-Specific"
+
+// the max length of the value of a RADIUS attribute, the value of a Vendor-Specific attribute also contains the vendor
+// ID, the vendor type and the vendor length
+const (
+	radiusMaxValueLength       = 253
+	radiusMaxVendorValueLength = radiusMaxValueLength - 6
+)
+
+type RadiusAttributeDefinition struct {
+	Type byte
+	Kind string
+}
+
+// RadiusReplyAttributeDefinitions are the RADIUS attributes that can be returned in the Access-Accept. The tunnel
+// attributes are sent without a tag like most servers do, e.g. the VLAN of RFC 3580.
+var RadiusReplyAttributeDefinitions = map[string]*RadiusAttributeDefinition{
+	"Framed-IP-Address":       {Type: 8, Kind: RadiusAttributeIp},
+	"Filter-Id":               {Type: 11, Kind: RadiusAttributeString},
+	"Reply-Message":           {Type: 18, Kind: RadiusAttributeString},
+	"Class":                   {Type: 25, Kind: RadiusAttributeString},
+	radiusVendorSpecific:      {Type: 26, Kind: RadiusAttributeString},
+	"Session-Timeout":         {Type: 27, Kind: RadiusAttributeInteger},
+	"Idle-Timeout":            {Type: 28, Kind: RadiusAttributeInteger},
+	"Tunnel-Type":             {Type: 64, Kind: RadiusAttributeInteger},
+	"Tunnel-Medium-Type":      {Type: 65, Kind: RadiusAttributeInteger},
+	"Tunnel-Private-Group-Id": {Type: 81, Kind: RadiusAttributeString},
+	"Acct-Interim-Interval":   {Type: 85, Kind: RadiusAttributeInteger},
+	"Framed-Pool":             {Type: 88, Kind: RadiusAttributeString},
+}
+
+// the names of the integer values, the VLAN of RFC 3580 is Tunnel-Type = VLAN and Tunnel-Medium-Type = IEEE-802
+var radiusIntegerValueNames = map[string]map[string]uint32{
+	"Tunnel-Type":        {"VLAN": 13},
+	"Tunnel-Medium-Type": {"IPv4": 1, "IPv6": 2, "IEEE-802": 6},
+}
+
+// the user fields in the values, like "{Name}" or "{Properties.vlan}"
+var radiusTemplateRegex = regexp.MustCompile(`\{([A-Za-z0-9_.\-]+)\}`)
+
+// RadiusReplyAttribute is a RADIUS attribute of the Access-Accept, it's only returned to the users with the role and
+// in the group if they are set. The value can contain user fields like "{Properties.vlan}", the attribute is skipped
+// if the value is empty. VendorKind is the kind of the value of a Vendor-Specific attribute, it's a string by default.
+type RadiusReplyAttribute struct {
+	Name       string `json:"name"`
+	VendorId   int    `json:"vendorId"`
+	VendorType int    `json:"vendorType"`
+	VendorKind string `json:"vendorKind"`
+	Value      string `json:"value"`
+	Role       string `json:"role"`
+	Group      string `json:"group"`
+}
+
+func (attribute *RadiusReplyAttribute) GetIntegerValue() (uint32, error) {
+	if value, ok := radiusIntegerValueNames[attribute.Name][attribute.Value]; ok {
+		return value, nil
+	}
+
+	value, err := strconv.ParseUint(attribute.Value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("the value: %s of the RADIUS attribute: %s is not an integer", attribute.Value, attribute.Name)
+	}
+	return uint32(value), nil
+}
+
+func (attribute *RadiusReplyAttribute) getKind() string {
+	if attribute.Name == radiusVendorSpecific && attribute.VendorKind != "" {
+		return attribute.VendorKind
+	}
+	return RadiusReplyAttributeDefinitions[attribute.Name].Kind
+}
+
+func (attribute *RadiusReplyAttribute) checkValue() error {
+	switch attribute.getKind() {
+	case RadiusAttributeInteger:
+		_, err := attribute.GetIntegerValue()
+		return err
+	case RadiusAttributeIp:
+		if net.ParseIP(attribute.Value).To4() == nil {
+			return fmt.Errorf("the value: %s of the RADIUS attribute: %s is not an IPv4 address", attribute.Value, attribute.Name)
+		}
+	default:
+		maxLength := radiusMaxValueLength
+		if attribute.Name == radiusVendorSpecific {
+			maxLength = radiusMaxVendorValueLength
+		}
+		if len(attribute.Value) > maxLength {
+			return fmt.Errorf("the value of the RADIUS attribute: %s should not be longer than %d bytes", attribute.Name, maxLength)
+		}
+	}
+	return nil
+}
+
+func (radiusClient *RadiusClient) checkReplyAttributes() error {
+	for _, attribute := range radiusClient.ReplyAttributes {
+		if _, ok := RadiusReplyAttributeDefinitions[attribute.Name]; !ok {
+			return fmt.Errorf("the RADIUS attribute: %s is not supported", attribute.Name)
+		}
+
+		if attribute.Name == radiusVendorSpecific {
+			if attribute.VendorId <= 0 || attribute.VendorType <= 0 || attribute.VendorType > 255 {
+				return fmt.Errorf("the vendor ID and vendor type of the Vendor-Specific attribute are invalid")
+			}
+			if attribute.VendorKind != "" && !util.InSlice([]string{RadiusAttributeString, RadiusAttributeInteger, RadiusAttributeIp}, attribute.VendorKind) {
+				return fmt.Errorf("the vendor kind: %s of the Vendor-Specific attribute is not supported", attribute.VendorKind)
+			}
+		}
+
+		if attribute.Value == "" {
+			return fmt.Errorf("the value of the RADIUS attribute: %s should not be empty", attribute.Name)
+		}
+
+		// the values with user fields are checked when they are returned
+		matches := radiusTemplateRegex.FindAllStringSubmatch(attribute.Value, -1)
+		for _, match := range matches {
+			casdoorName := match[1]
+			if casdoorName != "Name" && casdoorName != "Owner" && !strings.HasPrefix(casdoorName, ldapAttributePropertiesPrefix) && getUserStringField(&User{}, casdoorName) == nil {
+				return fmt.Errorf("the user field: %s is not supported in the RADIUS attribute: %s", casdoorName, attribute.Name)
+			}
+		}
+		if len(matches) == 0 {
+			if err := attribute.checkValue(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// radiusUserRoles gets the roles of the user once, the roles include the roles that inherit the roles of the user
+type radiusUserRoles struct {
+	user    *User
+	roleIds []string
+}
+
+func (userRoles *radiusUserRoles) hasRole(roleId string) (bool, error) {
+	if userRoles.roleIds == nil {
+		roles, err := getRolesByUser(userRoles.user.GetId())
+		if err != nil {
+			return false, err
+		}
+
+		userRoles.roleIds = []string{}
+		for _, role := range roles {
+			userRoles.roleIds = append(userRoles.roleIds, role.GetId())
+		}
+	}
+	return util.InSlice(userRoles.roleIds, roleId), nil
+}
+
+// CheckRadiusClientRoles checks that the user has one of the required roles of the client, all users are allowed if
+// the client has no required roles
+func (radiusClient *RadiusClient) CheckRadiusClientRoles(user *User) (bool, error) {
+	if len(radiusClient.RequiredRoles) == 0 {
+		return true, nil
+	}
+
+	userRoles := &radiusUserRoles{user: user}
+	for _, roleId := range radiusClient.RequiredRoles {
+		hasRole, err := userRoles.hasRole(roleId)
+		if err != nil || hasRole {
+			return hasRole, err
+		}
+	}
+	return false, nil
+}
+
+func getRadiusTemplateValue(template string, user *User) string {
+	return radiusTemplateRegex.ReplaceAllStringFunc(template, func(s string) string {
+		casdoorName := radiusTemplateRegex.FindStringSubmatch(s)[1]
+		if casdoorName == "Owner" {
+			return user.Owner
+		}
+		return getUserFieldForLdapAttribute(user, casdoorName)
+	})
+}
+
+// GetRadiusReplyAttributes returns the reply attributes of the client for the user, with the user fields in the
+// values. The first matching attribute of a name wins except for Vendor-Specific, so the attributes of the higher
+// priority go first, e.g. the VLAN of a role before the default VLAN. Tunnel-Type and Tunnel-Medium-Type of a VLAN
+// are added if Tunnel-Private-Group-Id is returned without them.
+func (radiusClient *RadiusClient) GetRadiusReplyAttributes(user *User) ([]*RadiusReplyAttribute, error) {
+	res := []*RadiusReplyAttribute{}
+	names := []string{}
+	userRoles := &radiusUserRoles{user: user}
+	for _, attribute := range radiusClient.ReplyAttributes {
+		if attribute.Name != radiusVendorSpecific && util.InSlice(names, attribute.Name) {
+			continue
+		}
+
+		if attribute.Group != "" && !util.InSlice(user.Groups, attribute.Group) {
+			continue
+		}
+		if attribute.Role != "" {
+			hasRole, err := userRoles.hasRole(attribute.Role)
+			if err != nil {
+				return nil, err
+			}
+			if !hasRole {
+				continue
+			}
+		}
+
+		value := getRadiusTemplateValue(attribute.Value, user)
+		if value == "" {
+			continue
+		}
+
+		replyAttribute := *attribute
+		replyAttribute.Value = value
+		if err := replyAttribute.checkValue(); err != nil {
+			return nil, err
+		}
+
+		res = append(res, &replyAttribute)
+		names = append(names, attribute.Name)
+	}
+
+	if util.InSlice(names, "Tunnel-Private-Group-Id") {
+		if !util.InSlice(names, "Tunnel-Type") {
+			res = append(res, &RadiusReplyAttribute{Name: "Tunnel-Type", Value: "VLAN"})
+		}
+		if !util.InSlice(names, "Tunnel-Medium-Type") {
+			res = append(res, &RadiusReplyAttribute{Name: "Tunnel-Medium-Type", Value: "IEEE-802"})
+		}
+	}
+	return res, nil
+}
+
+// getRadiusVendorValue encodes the value of a Vendor-Specific attribute like the standard attributes of the same kind
+func getRadiusVendorValue(attribute *RadiusReplyAttribute) ([]byte, error) {
+	if err := attribute.checkValue(); err != nil {
+		return nil, err
+	}
+
+	switch attribute.getKind() {
+	case RadiusAttributeInteger:
+		value, err := attribute.GetIntegerValue()
+		if err != nil {
+			return nil, err
+		}
+
+		res := make([]byte, 4)
+		binary.BigEndian.PutUint32(res, value)
+		return res, nil
+	case RadiusAttributeIp:
+		return net.ParseIP(attribute.Value).To4(), nil
+	default:
+		return []byte(attribute.Value), nil
+	}
+}
+
+func newRadiusAttribute(attribute *RadiusReplyAttribute) (radius.Type, radius.Attribute, error) {
+	if attribute.Name == radiusVendorSpecific {
+		value, err := getRadiusVendorValue(attribute)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		value = append([]byte{byte(attribute.VendorType), byte(2 + len(value))}, value...)
+		a, err := radius.NewVendorSpecific(uint32(attribute.VendorId), value)
+		return rfc2865.VendorSpecific_Type, a, err
+	}
+
+	definition := RadiusReplyAttributeDefinitions[attribute.Name]
+	switch definition.Kind {
+	case RadiusAttributeInteger:
+		value, err := attribute.GetIntegerValue()
+		return radius.Type(definition.Type), radius.NewInteger(value), err
+	case RadiusAttributeIp:
+		a, err := radius.NewIPAddr(net.ParseIP(attribute.Value).To4())
+		return radius.Type(definition.Type), a, err
+	default:
+		a, err := radius.NewString(attribute.Value)
+		return radius.Type(definition.Type), a, err
+	}
+}
+
+// AddRadiusReplyAttributes adds the reply attributes of the client for the user to the Access-Accept or CoA-Request
+func (radiusClient *RadiusClient) AddRadiusReplyAttributes(p *radius.Packet, user *User) error {
+	attributes, err := radiusClient.GetRadiusReplyAttributes(user)
+	if err != nil {
+		return err
+	}
+
+	for _, attribute := range attributes {
+		key, value, err := newRadiusAttribute(attribute)
+		if err != nil {
+			return err
+		}
+		p.Add(key, value)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"layeh.com/radius"
+)
+
+func TestGetRadiusReplyAttributes(t *testing.T) {
+	radiusClient := &RadiusClient{
+		Address: "10.0.0.1",
+		Secret:  "secret",
+		ReplyAttributes: []*RadiusReplyAttribute{
+			{Name: "Tunnel-Private-Group-Id", Value: "20", Group: "built-in/engineering"},
+			{Name: "Tunnel-Private-Group-Id", Value: "{Properties.vlan}"},
+			{Name: "Session-Timeout", Value: "3600"},
+			{Name: "Filter-Id", Value: "{Owner}-{Name}"},
+			{Name: "Vendor-Specific", VendorId: 9, VendorType: 1, Value: "shell:priv-lvl=1"},
+			{Name: "Vendor-Specific", VendorId: 9, VendorType: 1, Value: "ip:inacl#1=permit ip any any"},
+		},
+	}
+	assert.Nil(t, radiusClient.CheckRadiusClient())
+
+	user := &User{Owner: "built-in", Name: "alice", Properties: map[string]string{"vlan": "10"}}
+	attributes, err := radiusClient.GetRadiusReplyAttributes(user)
+	assert.Nil(t, err)
+	assert.Equal(t, []*RadiusReplyAttribute{
+		{Name: "Tunnel-Private-Group-Id", Value: "10"},
+		{Name: "Session-Timeout", Value: "3600"},
+		{Name: "Filter-Id", Value: "built-in-alice"},
+		{Name: "Vendor-Specific", VendorId: 9, VendorType: 1, Value: "shell:priv-lvl=1"},
+		{Name: "Vendor-Specific", VendorId: 9, VendorType: 1, Value: "ip:inacl#1=permit ip any any"},
+		{Name: "Tunnel-Type", Value: "VLAN"},
+		{Name: "Tunnel-Medium-Type", Value: "IEEE-802"},
+	}, attributes)
+
+	user = &User{Owner: "built-in", Name: "bob", Groups: []string{"built-in/engineering"}}
+	attributes, err = radiusClient.GetRadiusReplyAttributes(user)
+	assert.Nil(t, err)
+	assert.Equal(t, "20", attributes[0].Value)
+
+	radiusClient.ReplyAttributes = []*RadiusReplyAttribute{{Name: "Session-Timeout", Value: "{Properties.timeout}"}}
+	user = &User{Owner: "built-in", Name: "carol", Properties: map[string]string{"timeout": "one hour"}}
+	_, err = radiusClient.GetRadiusReplyAttributes(user)
+	assert.NotNil(t, err)
+
+	value, err := (&RadiusReplyAttribute{Name: "Tunnel-Type", Value: "VLAN"}).GetIntegerValue()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(13), value)
+
+	for _, attribute := range []*RadiusReplyAttribute{
+		{Name: "User-Password", Value: "secret"},
+		{Name: "Session-Timeout", Value: "one hour"},
+		{Name: "Framed-IP-Address", Value: "fd00::1"},
+		{Name: "Vendor-Specific", Value: "shell:priv-lvl=15"},
+		{Name: "Filter-Id", Value: ""},
+		{Name: "Filter-Id", Value: "{Password}"},
+		{Name: "Filter-Id", Value: strings.Repeat("a", 254)},
+		{Name: "Vendor-Specific", VendorId: 9, VendorType: 1, Value: strings.Repeat("a", 248)},
+		{Name: "Vendor-Specific", VendorId: 9, VendorType: 1, VendorKind: "Octets", Value: "01"},
+		{Name: "Vendor-Specific", VendorId: 9, VendorType: 1, VendorKind: RadiusAttributeInteger, Value: "one"},
+	} {
+		radiusClient.ReplyAttributes = []*RadiusReplyAttribute{attribute}
+		assert.NotNil(t, radiusClient.CheckRadiusClient(), attribute.Name)
+	}
+}
+
+func TestNewRadiusAttribute(t *testing.T) {
+	key, value, err := newRadiusAttribute(&RadiusReplyAttribute{Name: "Tunnel-Type", Value: "VLAN"})
+	assert.Nil(t, err)
+	assert.Equal(t, radius.Type(64), key)
+	assert.Equal(t, radius.Attribute{0, 0, 0, 13}, value)
+
+	key, value, err = newRadiusAttribute(&RadiusReplyAttribute{Name: "Vendor-Specific", VendorId: 9, VendorType: 1, Value: "priv-lvl=1"})
+	assert.Nil(t, err)
+	assert.Equal(t, radius.Type(26), key)
+	assert.Equal(t, append(radius.Attribute{0, 0, 0, 9, 1, 12}, "priv-lvl=1"...), value)
+
+	key, value, err = newRadiusAttribute(&RadiusReplyAttribute{Name: "Vendor-Specific", VendorId: 14122, VendorType: 2, VendorKind: RadiusAttributeInteger, Value: "300"})
+	assert.Nil(t, err)
+	assert.Equal(t, radius.Type(26), key)
+	assert.Equal(t, radius.Attribute{0, 0, 0x37, 0x2a, 2, 6, 0, 0, 1, 0x2c}, value)
+
+	_, value, err = newRadiusAttribute(&RadiusReplyAttribute{Name: "Vendor-Specific", VendorId: 9, VendorType: 3, VendorKind: RadiusAttributeIp, Value: "10.0.0.1"})
+	assert.Nil(t, err)
+	assert.Equal(t, radius.Attribute{0, 0, 0, 9, 3, 6, 10, 0, 0, 1}, value)
+
+	_, _, err = newRadiusAttribute(&RadiusReplyAttribute{Name: "Vendor-Specific", VendorId: 9, VendorType: 1, Value: strings.Repeat("a", 254)})
+	assert.NotNil(t, err)
+}
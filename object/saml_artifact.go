@@ -0,0 +1,227 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/google/uuid"
+)
+
+const (
+	SamlBindingHttpArtifact = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Artifact"
+	SamlBindingSoap         = "urn:oasis:names:tc:SAML:2.0:bindings:SOAP"
+
+	SamlResponseParam = "SAMLResponse"
+	SamlArtifactParam = "SAMLart"
+)
+
+// the type code of SAML 2.0 artifacts, see section 3.6.4 of saml-bindings-2.0-os
+const samlArtifactTypeCode = 0x0004
+
+// an artifact must be resolved shortly after it is issued
+const samlArtifactExpireTime = 5 * time.Minute
+
+type samlArtifactItem struct {
+	ApplicationId string
+	Issuer        string
+	Response      string
+}
+
+// newSamlArtifact returns a type 0x0004 SAML artifact: TypeCode, EndpointIndex, SourceID and MessageHandle
+func newSamlArtifact(idpEntityId string, endpointIndex int) (string, error) {
+	messageHandle := make([]byte, 20)
+	_, err := rand.Read(messageHandle)
+	if err != nil {
+		return "", err
+	}
+
+	sourceId := sha1.Sum([]byte(idpEntityId))
+
+	artifact := make([]byte, 4, 44)
+	binary.BigEndian.PutUint16(artifact[0:2], samlArtifactTypeCode)
+	binary.BigEndian.PutUint16(artifact[2:4], uint16(endpointIndex))
+	artifact = append(artifact, sourceId[:]...)
+	artifact = append(artifact, messageHandle...)
+	return base64.StdEncoding.EncodeToString(artifact), nil
+}
+
+// storeSamlArtifact stores the signed SAML response and returns the artifact referring to it,
+// issuer is the entity ID of the service provider that is allowed to resolve the artifact
+func storeSamlArtifact(application *Application, samlResponse *etree.Element, issuer string, endpointIndex int, idpEntityId string) (string, error) {
+	doc := etree.NewDocument()
+	doc.SetRoot(samlResponse)
+	response, err := doc.WriteToString()
+	if err != nil {
+		return "", err
+	}
+
+	artifact, err := newSamlArtifact(idpEntityId, endpointIndex)
+	if err != nil {
+		return "", err
+	}
+
+	err = getTicketStore().Put(getSamlArtifactTicket(artifact), &samlArtifactItem{
+		ApplicationId: application.GetId(),
+		Issuer:        issuer,
+		Response:      response,
+	}, samlArtifactExpireTime)
+	if err != nil {
+		return "", err
+	}
+	return artifact, nil
+}
+
+func getSamlArtifactTicket(artifact string) string {
+	return getTicketKey(ticketTypeSamlArtifact, artifact)
+}
+
+// getSamlArtifactItem returns the stored item of the artifact without consuming it, nil if not found
+func getSamlArtifactItem(artifact string) (*samlArtifactItem, error) {
+	var item samlArtifactItem
+	ok, err := getTicketStore().Get(getSamlArtifactTicket(artifact), &item)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &item, nil
+}
+
+// takeSamlArtifact consumes the artifact, an artifact can only be resolved once
+func takeSamlArtifact(artifact string) (bool, error) {
+	var item samlArtifactItem
+	return getTicketStore().Take(getSamlArtifactTicket(artifact), &item)
+}
+
+// checkSamlArtifactBinding checks that the service provider can resolve artifacts, the ArtifactResolve
+// requests must be signed, otherwise anyone knowing an artifact and the SP's entity ID could resolve it
+func checkSamlArtifactBinding(sp *SamlServiceProvider) error {
+	if sp == nil || sp.SigningCert == "" {
+		return fmt.Errorf("err: the HTTP-Artifact binding requires a SAML service provider with a signing certificate")
+	}
+	return nil
+}
+
+// GetSamlArtifactResponse handles a SAML ArtifactResolve request (the content of the SOAP body)
+// and returns the signed ArtifactResponse containing the SAML response the artifact refers to.
+// The artifact is only consumed after the issuer and the signature of the request are verified.
+func GetSamlArtifactResponse(artifactResolve string, host string) (string, error) {
+	doc := etree.NewDocument()
+	err := doc.ReadFromString(artifactResolve)
+	if err != nil {
+		return "", fmt.Errorf("err: Failed to parse ArtifactResolve, %s", err.Error())
+	}
+
+	root := doc.Root()
+	if root == nil || root.Tag != "ArtifactResolve" {
+		return "", fmt.Errorf("err: the SOAP body should contain an ArtifactResolve element")
+	}
+
+	artifactElement := root.FindElement("./Artifact")
+	if artifactElement == nil || artifactElement.Text() == "" {
+		return "", fmt.Errorf("err: Artifact field not found in ArtifactResolve")
+	}
+	artifact := artifactElement.Text()
+
+	issuer := ""
+	if issuerElement := root.FindElement("./Issuer"); issuerElement != nil {
+		issuer = issuerElement.Text()
+	}
+
+	item, err := getSamlArtifactItem(artifact)
+	if err != nil {
+		return "", err
+	}
+	if item == nil {
+		return "", fmt.Errorf("err: the SAML artifact: %s is not found or expired", artifact)
+	}
+
+	if item.Issuer == "" || item.Issuer != issuer {
+		return "", fmt.Errorf("err: the SAML artifact is not issued to: %s", issuer)
+	}
+
+	application, err := GetApplication(item.ApplicationId)
+	if err != nil {
+		return "", err
+	}
+	if application == nil {
+		return "", fmt.Errorf("the application: %s does not exist", item.ApplicationId)
+	}
+
+	sp := application.GetSamlServiceProvider(issuer)
+	err = checkSamlArtifactBinding(sp)
+	if err != nil {
+		return "", err
+	}
+
+	err = verifySamlRequestSignature([]byte(artifactResolve), sp.SigningCert)
+	if err != nil {
+		return "", err
+	}
+
+	ok, err := takeSamlArtifact(artifact)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("err: the SAML artifact: %s is not found or expired", artifact)
+	}
+
+	responseDoc := etree.NewDocument()
+	err = responseDoc.ReadFromString(item.Response)
+	if err != nil {
+		return "", err
+	}
+
+	cert, certificate, err := getSamlCertificate(application)
+	if err != nil {
+		return "", err
+	}
+
+	_, originBackend := getOriginFromHost(host)
+
+	artifactResponse := &etree.Element{
+		Space: "samlp",
+		Tag:   "ArtifactResponse",
+	}
+	artifactResponse.CreateAttr("xmlns:samlp", "urn:oasis:names:tc:SAML:2.0:protocol")
+	artifactResponse.CreateAttr("xmlns:saml", "urn:oasis:names:tc:SAML:2.0:assertion")
+	artifactResponse.CreateAttr("ID", fmt.Sprintf("_%s", uuid.New()))
+	artifactResponse.CreateAttr("Version", "2.0")
+	artifactResponse.CreateAttr("IssueInstant", time.Now().UTC().Format(time.RFC3339))
+	if requestId := root.SelectAttrValue("ID", ""); requestId != "" {
+		artifactResponse.CreateAttr("InResponseTo", requestId)
+	}
+	artifactResponse.CreateElement("saml:Issuer").SetText(originBackend)
+	artifactResponse.CreateElement("samlp:Status").CreateElement("samlp:StatusCode").CreateAttr("Value", "urn:oasis:names:tc:SAML:2.0:status:Success")
+	artifactResponse.AddChild(responseDoc.Root())
+
+	err = signSamlElement(application, artifactResponse, cert, certificate)
+	if err != nil {
+		return "", err
+	}
+
+	resDoc := etree.NewDocument()
+	resDoc.SetRoot(artifactResponse)
+	return resDoc.WriteToString()
+}
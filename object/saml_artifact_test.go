@@ -0,0 +1,111 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+)
+
+// mapTicketStore is an in-memory TicketStore for the tests that don't have a database
+type mapTicketStore struct {
+	tickets sync.Map
+}
+
+func (s *mapTicketStore) Put(ticket string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	s.tickets.Store(ticket, data)
+	return nil
+}
+
+func (s *mapTicketStore) Take(ticket string, value interface{}) (bool, error) {
+	data, ok := s.tickets.LoadAndDelete(ticket)
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(data.([]byte), value)
+}
+
+func (s *mapTicketStore) Get(ticket string, value interface{}) (bool, error) {
+	data, ok := s.tickets.Load(ticket)
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(data.([]byte), value)
+}
+
+func (s *mapTicketStore) Delete(ticket string) error {
+	s.tickets.Delete(ticket)
+	return nil
+}
+
+func TestSamlArtifact(t *testing.T) {
+	defer setTicketStore(setTicketStore(&mapTicketStore{}))
+
+	application := &Application{Owner: "admin", Name: "app-built-in"}
+	samlResponse := etree.NewElement("samlp:Response")
+	samlResponse.CreateAttr("ID", "_response")
+
+	artifact, err := storeSamlArtifact(application, samlResponse, "https://sp.example.com/metadata", 2, "https://idp.example.com")
+	assert.Nil(t, err)
+
+	data, err := base64.StdEncoding.DecodeString(artifact)
+	assert.Nil(t, err)
+	assert.Equal(t, 44, len(data))
+	assert.Equal(t, []byte{0x00, 0x04, 0x00, 0x02}, data[:4])
+	sourceId := sha1.Sum([]byte("https://idp.example.com"))
+	assert.Equal(t, sourceId[:], data[4:24])
+
+	item, err := getSamlArtifactItem(artifact)
+	assert.Nil(t, err)
+	assert.NotNil(t, item)
+	assert.Equal(t, "admin/app-built-in", item.ApplicationId)
+	assert.Equal(t, "https://sp.example.com/metadata", item.Issuer)
+	assert.Contains(t, item.Response, `ID="_response"`)
+
+	// a request with another issuer doesn't consume the artifact
+	_, err = GetSamlArtifactResponse(`<samlp:ArtifactResolve xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_1"><saml:Issuer>https://evil.example.com</saml:Issuer><samlp:Artifact>`+artifact+`</samlp:Artifact></samlp:ArtifactResolve>`, "door.casdoor.com")
+	assert.NotNil(t, err)
+
+	item, err = getSamlArtifactItem(artifact)
+	assert.Nil(t, err)
+	assert.NotNil(t, item)
+
+	// an artifact can only be resolved once
+	ok, err := takeSamlArtifact(artifact)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = takeSamlArtifact(artifact)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	item, err = getSamlArtifactItem(artifact)
+	assert.Nil(t, err)
+	assert.Nil(t, item)
+
+	assert.NotNil(t, checkSamlArtifactBinding(nil))
+	assert.NotNil(t, checkSamlArtifactBinding(&SamlServiceProvider{EntityId: "https://sp.example.com/metadata"}))
+}
@@ -26,6 +26,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 	"time"
 
@@ -38,7 +39,7 @@ import (
 
 // NewSamlResponse
 // returns a saml2 response
-func NewSamlResponse(application *Application, user *User, host string, certificate string, destination string, iss string, requestId string, redirectUri []string) (*etree.Element, error) {
+func NewSamlResponse(application *Application, user *User, host string, certificate string, destination string, iss string, requestId string, redirectUri []string, sp *SamlServiceProvider) (*etree.Element, error) {
 	samlResponse := &etree.Element{
 		Space: "samlp",
 		Tag:   "Response",
@@ -53,7 +54,9 @@ func NewSamlResponse(application *Application, user *User, host string, certific
 	samlResponse.CreateAttr("Version", "2.0")
 	samlResponse.CreateAttr("IssueInstant", now)
 	samlResponse.CreateAttr("Destination", destination)
-	samlResponse.CreateAttr("InResponseTo", requestId)
+	if requestId != "" {
+		samlResponse.CreateAttr("InResponseTo", requestId)
+	}
 	samlResponse.CreateElement("saml:Issuer").SetText(host)
 
 	samlResponse.CreateElement("samlp:Status").CreateElement("samlp:StatusCode").CreateAttr("Value", "urn:oasis:names:tc:SAML:2.0:status:Success")
@@ -66,22 +69,27 @@ func NewSamlResponse(application *Application, user *User, host string, certific
 	assertion.CreateAttr("IssueInstant", now)
 	assertion.CreateElement("saml:Issuer").SetText(host)
 	subject := assertion.CreateElement("saml:Subject")
-	nameIDValue := user.Name
-	if application.UseEmailAsSamlNameId {
-		nameIDValue = user.Email
+	nameIDFormat, nameIDValue := getSamlNameId(application, user, sp)
+	nameID := subject.CreateElement("saml:NameID")
+	if nameIDFormat != "" {
+		nameID.CreateAttr("Format", nameIDFormat)
 	}
-	subject.CreateElement("saml:NameID").SetText(nameIDValue)
+	nameID.SetText(nameIDValue)
 	subjectConfirmation := subject.CreateElement("saml:SubjectConfirmation")
 	subjectConfirmation.CreateAttr("Method", "urn:oasis:names:tc:SAML:2.0:cm:bearer")
 	subjectConfirmationData := subjectConfirmation.CreateElement("saml:SubjectConfirmationData")
-	subjectConfirmationData.CreateAttr("InResponseTo", requestId)
+	if requestId != "" {
+		subjectConfirmationData.CreateAttr("InResponseTo", requestId)
+	}
 	subjectConfirmationData.CreateAttr("Recipient", destination)
 	subjectConfirmationData.CreateAttr("NotOnOrAfter", expireTime)
 	condition := assertion.CreateElement("saml:Conditions")
 	condition.CreateAttr("NotBefore", now)
 	condition.CreateAttr("NotOnOrAfter", expireTime)
 	audience := condition.CreateElement("saml:AudienceRestriction")
-	audience.CreateElement("saml:Audience").SetText(iss)
+	if iss != "" {
+		audience.CreateElement("saml:Audience").SetText(iss)
+	}
 	for _, value := range redirectUri {
 		audience.CreateElement("saml:Audience").SetText(value)
 	}
@@ -115,6 +123,26 @@ func NewSamlResponse(application *Application, user *User, host string, certific
 		role.CreateElement("saml:AttributeValue").CreateAttr("xsi:type", "xs:string").Element().SetText(item.Value)
 	}
 
+	if sp != nil {
+		attributeNames := []string{}
+		for attributeName := range sp.AttributeMapping {
+			attributeNames = append(attributeNames, attributeName)
+		}
+		sort.Strings(attributeNames)
+
+		for _, attributeName := range attributeNames {
+			value, err := getSamlMappedAttributeValue(user, sp.AttributeMapping[attributeName])
+			if err != nil {
+				return nil, err
+			}
+
+			attribute := attributes.CreateElement("saml:Attribute")
+			attribute.CreateAttr("Name", attributeName)
+			attribute.CreateAttr("NameFormat", "urn:oasis:names:tc:SAML:2.0:attrname-format:basic")
+			attribute.CreateElement("saml:AttributeValue").CreateAttr("xsi:type", "xs:string").Element().SetText(value)
+		}
+	}
+
 	roles := attributes.CreateElement("saml:Attribute")
 	roles.CreateAttr("Name", "Roles")
 	roles.CreateAttr("NameFormat", "urn:oasis:names:tc:SAML:2.0:attrname-format:basic")
@@ -178,9 +206,10 @@ type IdpSSODescriptor struct {
 	XMLName                    xml.Name `xml:"urn:oasis:names:tc:SAML:2.0:metadata IDPSSODescriptor"`
 	ProtocolSupportEnumeration string   `xml:"protocolSupportEnumeration,attr"`
 	SigningKeyDescriptor       KeyDescriptor
-	NameIDFormats              []NameIDFormat      `xml:"NameIDFormat"`
-	SingleSignOnService        SingleSignOnService `xml:"SingleSignOnService"`
-	Attribute                  []Attribute         `xml:"Attribute"`
+	ArtifactResolutionService  ArtifactResolutionService `xml:"ArtifactResolutionService"`
+	NameIDFormats              []NameIDFormat            `xml:"NameIDFormat"`
+	SingleSignOnService        SingleSignOnService       `xml:"SingleSignOnService"`
+	Attribute                  []Attribute               `xml:"Attribute"`
 }
 
 type NameIDFormat struct {
@@ -194,6 +223,12 @@ type SingleSignOnService struct {
 	Location string `xml:"Location,attr"`
 }
 
+type ArtifactResolutionService struct {
+	Binding  string `xml:"Binding,attr"`
+	Location string `xml:"Location,attr"`
+	Index    int    `xml:"index,attr"`
+}
+
 type Attribute struct {
 	// XMLName      xml.Name
 	Xmlns        string   `xml:"xmlns,attr"`
@@ -251,6 +286,11 @@ func GetSamlMeta(application *Application, host string, enablePostBinding bool)
 					},
 				},
 			},
+			ArtifactResolutionService: ArtifactResolutionService{
+				Binding:  SamlBindingSoap,
+				Location: fmt.Sprintf("%s/api/saml/artifact-resolve", originBackend),
+				Index:    0,
+			},
 			NameIDFormats: []NameIDFormat{
 				{Value: "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"},
 				{Value: "urn:oasis:names:tc:SAML:2.0:nameid-format:persistent"},
@@ -274,14 +314,18 @@ func GetSamlMeta(application *Application, host string, enablePostBinding bool)
 
 // GetSamlResponse generates a SAML2.0 response
 // parameter samlRequest is saml request in base64 format
-func GetSamlResponse(application *Application, user *User, samlRequest string, host string) (string, string, string, error) {
+// parameter signedQuery is the raw SAMLRequest, RelayState and SigAlg query string of a request signed by the HTTP-Redirect binding,
+// parameter signature is its Signature query parameter
+// returns the message, the ACS URL, the HTTP method and the parameter name ("SAMLResponse" or "SAMLart") of the message
+func GetSamlResponse(application *Application, user *User, samlRequest string, relayState string, signedQuery string, signature string, host string) (string, string, string, string, error) {
 	// request type
 	method := "GET"
 	samlRequest = strings.ReplaceAll(samlRequest, " ", "+")
+	signature = strings.ReplaceAll(signature, " ", "+")
 	// base64 decode
 	defated, err := base64.StdEncoding.DecodeString(samlRequest)
 	if err != nil {
-		return "", "", "", fmt.Errorf("err: Failed to decode SAML request, %s", err.Error())
+		return "", "", "", "", fmt.Errorf("err: Failed to decode SAML request, %s", err.Error())
 	}
 
 	var requestByte []byte
@@ -300,7 +344,7 @@ func GetSamlResponse(application *Application, user *User, samlRequest string, h
 				if err == io.EOF {
 					break
 				}
-				return "", "", "", err
+				return "", "", "", "", err
 			}
 		}
 
@@ -310,46 +354,216 @@ func GetSamlResponse(application *Application, user *User, samlRequest string, h
 	var authnRequest saml.AuthNRequest
 	err = xml.Unmarshal(requestByte, &authnRequest)
 	if err != nil {
-		return "", "", "", fmt.Errorf("err: Failed to unmarshal AuthnRequest, please check the SAML request, %s", err.Error())
+		return "", "", "", "", fmt.Errorf("err: Failed to unmarshal AuthnRequest, please check the SAML request, %s", err.Error())
 	}
 
+	// the SAML service provider registered for the issuer, the legacy Redirect URIs and SAML reply URL are used if not found
+	sp := application.GetSamlServiceProvider(authnRequest.Issuer)
+
 	// verify samlRequest
-	if isValid := application.IsRedirectUriValid(authnRequest.Issuer); !isValid {
-		return "", "", "", fmt.Errorf("err: Issuer URI: %s doesn't exist in the allowed Redirect URI list", authnRequest.Issuer)
+	redirectUris := application.RedirectUris
+	if sp != nil {
+		if sp.SigningCert != "" {
+			if signature != "" {
+				err = verifySamlRedirectSignature(signedQuery, samlRequest, relayState, signature, sp.SigningCert)
+			} else {
+				err = verifySamlRequestSignature(requestByte, sp.SigningCert)
+			}
+			if err != nil {
+				return "", "", "", "", err
+			}
+		}
+		redirectUris = nil
+	} else if isValid := application.IsRedirectUriValid(authnRequest.Issuer); !isValid {
+		return "", "", "", "", fmt.Errorf("err: Issuer URI: %s doesn't exist in the allowed Redirect URI list", authnRequest.Issuer)
 	}
 
 	// get certificate string
-	cert, err := getCertByApplication(application)
+	cert, certificate, err := getSamlCertificate(application)
 	if err != nil {
-		return "", "", "", err
+		return "", "", "", "", err
 	}
 
-	if cert.Certificate == "" {
-		return "", "", "", fmt.Errorf("the certificate field should not be empty for the cert: %v", cert)
-	}
-
-	block, _ := pem.Decode([]byte(cert.Certificate))
-	certificate := base64.StdEncoding.EncodeToString(block.Bytes)
-
 	// redirect Url (Assertion Consumer Url)
-	if application.SamlReplyUrl != "" {
+	isArtifact := authnRequest.ProtocolBinding == SamlBindingHttpArtifact
+	endpointIndex := 0
+	if sp != nil {
+		acsUrl, err := sp.getAcsUrl(authnRequest.AssertionConsumerServiceURL)
+		if err != nil {
+			return "", "", "", "", err
+		}
+		authnRequest.AssertionConsumerServiceURL = acsUrl.Url
+		if acsUrl.Binding != saml.BindingHttpRedirect {
+			method = "POST"
+		}
+		if acsUrl.Binding == SamlBindingHttpArtifact {
+			isArtifact = true
+		}
+		endpointIndex = acsUrl.Index
+	} else if application.SamlReplyUrl != "" {
 		method = "POST"
 		authnRequest.AssertionConsumerServiceURL = application.SamlReplyUrl
 	} else if authnRequest.AssertionConsumerServiceURL == "" {
-		return "", "", "", fmt.Errorf("err: SAML request don't has attribute 'AssertionConsumerServiceURL' in <samlp:AuthnRequest>")
+		return "", "", "", "", fmt.Errorf("err: SAML request don't has attribute 'AssertionConsumerServiceURL' in <samlp:AuthnRequest>")
 	}
 	if authnRequest.ProtocolBinding == "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" {
 		method = "POST"
 	}
+	if isArtifact {
+		err = checkSamlArtifactBinding(sp)
+		if err != nil {
+			return "", "", "", "", err
+		}
+	}
 
 	_, originBackend := getOriginFromHost(host)
 
 	// build signedResponse
-	samlResponse, err := NewSamlResponse(application, user, originBackend, certificate, authnRequest.AssertionConsumerServiceURL, authnRequest.Issuer, authnRequest.ID, application.RedirectUris)
+	samlResponse, err := NewSamlResponse(application, user, originBackend, certificate, authnRequest.AssertionConsumerServiceURL, authnRequest.Issuer, authnRequest.ID, redirectUris, sp)
+	if err != nil {
+		return "", "", "", "", fmt.Errorf("err: NewSamlResponse() error, %s", err.Error())
+	}
+
+	if sp != nil && sp.EncryptionCert != "" {
+		err = encryptSamlAssertion(samlResponse, sp.EncryptionCert)
+		if err != nil {
+			return "", "", "", "", err
+		}
+	}
+
+	err = signSamlElement(application, samlResponse, cert, certificate)
+	if err != nil {
+		return "", "", "", "", err
+	}
+
+	// the artifact is sent with a redirect, the SP resolves it back-channel with an ArtifactResolve request
+	if isArtifact {
+		artifact, err := storeSamlArtifact(application, samlResponse, authnRequest.Issuer, endpointIndex, originBackend)
+		if err != nil {
+			return "", "", "", "", err
+		}
+		return artifact, authnRequest.AssertionConsumerServiceURL, "GET", SamlArtifactParam, nil
+	}
+
+	res, err := encodeSamlResponse(application, samlResponse)
+	if err != nil {
+		return "", "", "", "", err
+	}
+
+	return res, authnRequest.AssertionConsumerServiceURL, method, SamlResponseParam, nil
+}
+
+// GetSamlIdpInitiatedResponse generates an unsolicited SAML2.0 response for IdP-initiated SSO,
+// the response is posted to the default ACS URL of the SAML service provider whose entity ID is spEntityId,
+// or to the application's SAML reply URL (Assertion Consumer Service) if spEntityId is empty
+// returns the message, the ACS URL and the parameter name ("SAMLResponse" or "SAMLart") of the message
+func GetSamlIdpInitiatedResponse(application *Application, user *User, spEntityId string, host string) (string, string, string, error) {
+	var sp *SamlServiceProvider
+	acsUrl := application.SamlReplyUrl
+	audience := ""
+	isArtifact := false
+	endpointIndex := 0
+	redirectUris := application.RedirectUris
+	if spEntityId != "" {
+		sp = application.GetSamlServiceProvider(spEntityId)
+		if sp == nil {
+			return "", "", "", fmt.Errorf("err: the SAML service provider: %s is not registered in application: %s", spEntityId, application.GetId())
+		}
+
+		spAcsUrl, err := sp.getAcsUrl("")
+		if err != nil {
+			return "", "", "", err
+		}
+
+		acsUrl = spAcsUrl.Url
+		isArtifact = spAcsUrl.Binding == SamlBindingHttpArtifact
+		if isArtifact {
+			err = checkSamlArtifactBinding(sp)
+			if err != nil {
+				return "", "", "", err
+			}
+		}
+		endpointIndex = spAcsUrl.Index
+		audience = sp.EntityId
+		redirectUris = nil
+	}
+
+	if acsUrl == "" {
+		return "", "", "", fmt.Errorf("err: the SAML reply URL of application: %s should not be empty for IdP-initiated SSO", application.GetId())
+	}
+
+	// SPs reject an assertion whose AudienceRestriction has no Audience
+	if audience == "" && len(redirectUris) == 0 {
+		return "", "", "", fmt.Errorf("err: the redirect URLs of application: %s should contain the SAML service provider's entity ID for IdP-initiated SSO", application.GetId())
+	}
+
+	cert, certificate, err := getSamlCertificate(application)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	_, originBackend := getOriginFromHost(host)
+
+	// there is no AuthnRequest, so the response has no InResponseTo
+	samlResponse, err := NewSamlResponse(application, user, originBackend, certificate, acsUrl, audience, "", redirectUris, sp)
 	if err != nil {
 		return "", "", "", fmt.Errorf("err: NewSamlResponse() error, %s", err.Error())
 	}
 
+	if sp != nil && sp.EncryptionCert != "" {
+		err = encryptSamlAssertion(samlResponse, sp.EncryptionCert)
+		if err != nil {
+			return "", "", "", err
+		}
+	}
+
+	err = signSamlElement(application, samlResponse, cert, certificate)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if isArtifact {
+		artifact, err := storeSamlArtifact(application, samlResponse, audience, endpointIndex, originBackend)
+		if err != nil {
+			return "", "", "", err
+		}
+		return artifact, acsUrl, SamlArtifactParam, nil
+	}
+
+	res, err := encodeSamlResponse(application, samlResponse)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return res, acsUrl, SamlResponseParam, nil
+}
+
+// getSamlCertificate returns the application's cert and its certificate in base64 DER format
+func getSamlCertificate(application *Application) (*Cert, string, error) {
+	cert, err := getCertByApplication(application)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if cert == nil {
+		return nil, "", errors.New("please set a cert for the application first")
+	}
+
+	if cert.Certificate == "" {
+		return nil, "", fmt.Errorf("the certificate field should not be empty for the cert: %v", cert)
+	}
+
+	block, _ := pem.Decode([]byte(cert.Certificate))
+	if block == nil {
+		return nil, "", fmt.Errorf("failed to decode the certificate of the cert: %s", cert.GetId())
+	}
+
+	certificate := base64.StdEncoding.EncodeToString(block.Bytes)
+	return cert, certificate, nil
+}
+
+// signSamlElement signs the SAML response (or another SAML protocol message) with the application's cert
+func signSamlElement(application *Application, samlResponse *etree.Element, cert *Cert, certificate string) error {
 	randomKeyStore := &X509Key{
 		PrivateKey:      cert.PrivateKey,
 		X509Certificate: certificate,
@@ -368,16 +582,20 @@ func GetSamlResponse(application *Application, user *User, samlRequest string, h
 
 	sig, err := ctx.ConstructSignature(samlResponse, true)
 	if err != nil {
-		return "", "", "", fmt.Errorf("err: Failed to serializes the SAML request into bytes, %s", err.Error())
+		return fmt.Errorf("err: Failed to serializes the SAML request into bytes, %s", err.Error())
 	}
 
 	samlResponse.InsertChildAt(1, sig)
+	return nil
+}
 
+// encodeSamlResponse serializes, compresses (if enabled) and base64 encodes the signed SAML response
+func encodeSamlResponse(application *Application, samlResponse *etree.Element) (string, error) {
 	doc := etree.NewDocument()
 	doc.SetRoot(samlResponse)
 	xmlBytes, err := doc.WriteToBytes()
 	if err != nil {
-		return "", "", "", fmt.Errorf("err: Failed to serializes the SAML request into bytes, %s", err.Error())
+		return "", fmt.Errorf("err: Failed to serializes the SAML request into bytes, %s", err.Error())
 	}
 
 	// compress
@@ -385,24 +603,24 @@ func GetSamlResponse(application *Application, user *User, samlRequest string, h
 		flated := bytes.NewBuffer(nil)
 		writer, err := flate.NewWriter(flated, flate.DefaultCompression)
 		if err != nil {
-			return "", "", "", err
+			return "", err
 		}
 
 		_, err = writer.Write(xmlBytes)
 		if err != nil {
-			return "", "", "", err
+			return "", err
 		}
 
 		err = writer.Close()
 		if err != nil {
-			return "", "", "", err
+			return "", err
 		}
 
 		xmlBytes = flated.Bytes()
 	}
 	// base64 encode
 	res := base64.StdEncoding.EncodeToString(xmlBytes)
-	return res, authnRequest.AssertionConsumerServiceURL, method, err
+	return res, nil
 }
 
 // NewSamlResponse11 return a saml1.1 response(not 2.0)
@@ -0,0 +1,207 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/russellhaering/gosaml2/types"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+// parseBase64Certificate parses a certificate in base64 DER format, as used in SAML metadata
+func parseBase64Certificate(certificate string) (*x509.Certificate, error) {
+	certData, err := base64.StdEncoding.DecodeString(certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.ParseCertificate(certData)
+}
+
+// encryptSamlAssertion replaces the assertion of the SAML response with an EncryptedAssertion.
+// The assertion is encrypted with AES-256-CBC, and the AES key is encrypted with RSA-OAEP
+// for the service provider's encryption certificate.
+func encryptSamlAssertion(samlResponse *etree.Element, encryptionCert string) error {
+	var assertion *etree.Element
+	for _, child := range samlResponse.ChildElements() {
+		if child.Tag == "Assertion" {
+			assertion = child
+			break
+		}
+	}
+	if assertion == nil {
+		return fmt.Errorf("the SAML response has no assertion to encrypt")
+	}
+
+	cert, err := parseBase64Certificate(encryptionCert)
+	if err != nil {
+		return fmt.Errorf("failed to parse the encryption certificate, %s", err.Error())
+	}
+
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("the encryption certificate should contain an RSA public key")
+	}
+
+	// the assertion is decrypted standalone by the service provider, so it must declare its own namespace
+	plainAssertion := assertion.Copy()
+	plainAssertion.CreateAttr("xmlns:saml", "urn:oasis:names:tc:SAML:2.0:assertion")
+	doc := etree.NewDocument()
+	doc.SetRoot(plainAssertion)
+	plainText, err := doc.WriteToBytes()
+	if err != nil {
+		return err
+	}
+
+	key := make([]byte, 32)
+	_, err = rand.Read(key)
+	if err != nil {
+		return err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+
+	// PKCS#7 padding, the padding length is always non-zero
+	padLength := block.BlockSize() - len(plainText)%block.BlockSize()
+	plainText = append(plainText, bytes.Repeat([]byte{byte(padLength)}, padLength)...)
+
+	cipherText := make([]byte, block.BlockSize()+len(plainText))
+	iv := cipherText[:block.BlockSize()]
+	_, err = rand.Read(iv)
+	if err != nil {
+		return err
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(cipherText[block.BlockSize():], plainText)
+
+	encryptedKey, err := rsa.EncryptOAEP(sha1.New(), rand.Reader, publicKey, key, nil)
+	if err != nil {
+		return err
+	}
+
+	encryptedAssertion := etree.NewElement("saml:EncryptedAssertion")
+	encryptedData := encryptedAssertion.CreateElement("xenc:EncryptedData")
+	encryptedData.CreateAttr("xmlns:xenc", "http://www.w3.org/2001/04/xmlenc#")
+	encryptedData.CreateAttr("Type", "http://www.w3.org/2001/04/xmlenc#Element")
+	encryptedData.CreateElement("xenc:EncryptionMethod").CreateAttr("Algorithm", types.MethodAES256CBC)
+
+	keyInfo := encryptedData.CreateElement("ds:KeyInfo")
+	keyInfo.CreateAttr("xmlns:ds", dsig.Namespace)
+	encryptedKeyElement := keyInfo.CreateElement("xenc:EncryptedKey")
+	keyEncryptionMethod := encryptedKeyElement.CreateElement("xenc:EncryptionMethod")
+	keyEncryptionMethod.CreateAttr("Algorithm", types.MethodRSAOAEP)
+	keyEncryptionMethod.CreateElement("ds:DigestMethod").CreateAttr("Algorithm", types.MethodSHA1)
+	encryptedKeyElement.CreateElement("xenc:CipherData").CreateElement("xenc:CipherValue").SetText(base64.StdEncoding.EncodeToString(encryptedKey))
+
+	encryptedData.CreateElement("xenc:CipherData").CreateElement("xenc:CipherValue").SetText(base64.StdEncoding.EncodeToString(cipherText))
+
+	samlResponse.RemoveChild(assertion)
+	samlResponse.AddChild(encryptedAssertion)
+	return nil
+}
+
+// verifySamlRequestSignature verifies the enveloped signature of a SAML request with the
+// service provider's signing certificate, unsigned requests are rejected
+func verifySamlRequestSignature(requestByte []byte, signingCert string) error {
+	doc := etree.NewDocument()
+	err := doc.ReadFromBytes(requestByte)
+	if err != nil {
+		return err
+	}
+
+	root := doc.Root()
+	if root == nil || root.FindElement("./Signature") == nil {
+		return fmt.Errorf("the SAML request should be signed by the service provider's signing certificate")
+	}
+
+	cert, err := parseBase64Certificate(signingCert)
+	if err != nil {
+		return fmt.Errorf("failed to parse the signing certificate, %s", err.Error())
+	}
+
+	ctx := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{
+		Roots: []*x509.Certificate{cert},
+	})
+	_, err = ctx.Validate(root)
+	if err != nil {
+		return fmt.Errorf("failed to verify the signature of the SAML request, %s", err.Error())
+	}
+	return nil
+}
+
+// verifySamlRedirectSignature verifies the signature of a SAML request sent by the HTTP-Redirect binding,
+// the signature is over the SAMLRequest, RelayState and SigAlg query parameters as they were encoded by the SP,
+// so signedQuery is the raw query string of these parameters and they are checked against the SAML request
+// https://docs.oasis-open.org/security/saml/v2.0/saml-bindings-2.0-os.pdf section 3.4.4.1
+func verifySamlRedirectSignature(signedQuery string, samlRequest string, relayState string, signature string, signingCert string) error {
+	values, err := url.ParseQuery(signedQuery)
+	if err != nil {
+		return fmt.Errorf("failed to parse the signed query of the SAML request, %s", err.Error())
+	}
+	if strings.ReplaceAll(values.Get("SAMLRequest"), " ", "+") != samlRequest || values.Get("RelayState") != relayState {
+		return fmt.Errorf("the signed query doesn't match the SAML request")
+	}
+
+	sigAlg := values.Get("SigAlg")
+	var hash crypto.Hash
+	switch sigAlg {
+	case dsig.RSASHA1SignatureMethod:
+		hash = crypto.SHA1
+	case dsig.RSASHA256SignatureMethod:
+		hash = crypto.SHA256
+	case dsig.RSASHA512SignatureMethod:
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("the signature algorithm: %s of the SAML request is not supported", sigAlg)
+	}
+
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode the signature of the SAML request, %s", err.Error())
+	}
+
+	cert, err := parseBase64Certificate(signingCert)
+	if err != nil {
+		return fmt.Errorf("failed to parse the signing certificate, %s", err.Error())
+	}
+
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("the signing certificate should contain an RSA public key")
+	}
+
+	hasher := hash.New()
+	hasher.Write([]byte(signedQuery))
+	err = rsa.VerifyPKCS1v15(publicKey, hash, hasher.Sum(nil), signatureBytes)
+	if err != nil {
+		return fmt.Errorf("failed to verify the signature of the SAML request, %s", err.Error())
+	}
+	return nil
+}
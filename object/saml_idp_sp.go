@@ -0,0 +1,267 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/casdoor/casdoor/proxy"
+	"github.com/google/uuid"
+	saml "github.com/russellhaering/gosaml2"
+	"github.com/russellhaering/gosaml2/types"
+)
+
+// SamlAcsUrl is an Assertion Consumer Service endpoint of a SAML service provider
+type SamlAcsUrl struct {
+	Url       string `json:"url"`
+	Binding   string `json:"binding"`
+	Index     int    `json:"index"`
+	IsDefault bool   `json:"isDefault"`
+}
+
+// SamlServiceProvider is a SAML service provider registered in an application,
+// it is selected by matching the issuer of the incoming AuthnRequest with EntityId
+type SamlServiceProvider struct {
+	Name             string            `json:"name"`
+	EntityId         string            `json:"entityId"`
+	MetadataUrl      string            `json:"metadataUrl"`
+	AcsUrls          []*SamlAcsUrl     `json:"acsUrls"`
+	SigningCert      string            `json:"signingCert"`
+	EncryptionCert   string            `json:"encryptionCert"`
+	NameIdFormat     string            `json:"nameIdFormat"`
+	AttributeMapping map[string]string `json:"attributeMapping"`
+	// WsFedTokenType is the token type issued when the service provider is a WS-Federation relying party
+	// whose realm is EntityId, "SAML11" (default) or "SAML20"
+	WsFedTokenType string `json:"wsFedTokenType"`
+}
+
+// GetSamlServiceProvider returns the SAML service provider whose entity ID is entityId, nil if not found
+func (application *Application) GetSamlServiceProvider(entityId string) *SamlServiceProvider {
+	if entityId == "" {
+		return nil
+	}
+
+	for _, sp := range application.SamlServiceProviders {
+		if sp.EntityId == entityId {
+			return sp
+		}
+	}
+	return nil
+}
+
+// getAcsUrl returns the ACS URL and binding to send the SAML response to. The requested URL is used
+// if it is registered, otherwise the default ACS URL (or the one with the lowest index) is used.
+func (sp *SamlServiceProvider) getAcsUrl(requestedUrl string) (*SamlAcsUrl, error) {
+	if len(sp.AcsUrls) == 0 {
+		return nil, fmt.Errorf("the SAML service provider: %s has no ACS URL", sp.EntityId)
+	}
+
+	if requestedUrl != "" {
+		for _, acsUrl := range sp.AcsUrls {
+			if acsUrl.Url == requestedUrl {
+				return acsUrl, nil
+			}
+		}
+		return nil, fmt.Errorf("the ACS URL: %s is not registered for the SAML service provider: %s", requestedUrl, sp.EntityId)
+	}
+
+	for _, acsUrl := range sp.AcsUrls {
+		if acsUrl.IsDefault {
+			return acsUrl, nil
+		}
+	}
+
+	acsUrls := make([]*SamlAcsUrl, len(sp.AcsUrls))
+	copy(acsUrls, sp.AcsUrls)
+	sort.SliceStable(acsUrls, func(i, j int) bool {
+		return acsUrls[i].Index < acsUrls[j].Index
+	})
+	return acsUrls[0], nil
+}
+
+// getSamlNameId returns the NameID format and value of the user for the SAML service provider
+func getSamlNameId(application *Application, user *User, sp *SamlServiceProvider) (string, string) {
+	format := ""
+	if sp != nil {
+		format = sp.NameIdFormat
+	}
+
+	switch format {
+	case saml.NameIdFormatEmailAddress:
+		return format, user.Email
+	case saml.NameIdFormatPersistent:
+		return format, user.Id
+	case saml.NameIdFormatTransient:
+		return format, fmt.Sprintf("_%s", uuid.New())
+	}
+
+	if application.UseEmailAsSamlNameId {
+		return format, user.Email
+	}
+	return format, user.Name
+}
+
+// getSamlMappedAttributeValue returns the value of a user field for SAML attribute mapping,
+// fields are Go field names of User (e.g. "Email"), user properties are referenced as "Properties.xxx"
+func getSamlMappedAttributeValue(user *User, field string) (string, error) {
+	if strings.HasPrefix(field, "Properties.") {
+		return getUserProperty(user, strings.TrimPrefix(field, "Properties.")), nil
+	}
+
+	ok, value, err := GetUserFieldStringValue(user, field)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("the user field: %s doesn't exist", field)
+	}
+	return value, nil
+}
+
+// ParseSamlSpMetadata parses the SAML metadata XML of a service provider into a SamlServiceProvider
+func ParseSamlSpMetadata(data []byte) (*SamlServiceProvider, error) {
+	var descriptor types.EntityDescriptor
+	err := xml.Unmarshal(data, &descriptor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the SAML metadata, %s", err.Error())
+	}
+
+	if descriptor.SPSSODescriptor == nil {
+		return nil, fmt.Errorf("the SAML metadata of entity: %s has no SPSSODescriptor", descriptor.EntityID)
+	}
+
+	sp := &SamlServiceProvider{
+		Name:             descriptor.EntityID,
+		EntityId:         descriptor.EntityID,
+		AcsUrls:          []*SamlAcsUrl{},
+		AttributeMapping: map[string]string{},
+	}
+
+	for _, acs := range descriptor.SPSSODescriptor.AssertionConsumerServices {
+		sp.AcsUrls = append(sp.AcsUrls, &SamlAcsUrl{
+			Url:     acs.Location,
+			Binding: acs.Binding,
+			Index:   acs.Index,
+		})
+	}
+
+	if len(descriptor.SPSSODescriptor.NameIDFormats) > 0 {
+		sp.NameIdFormat = strings.TrimSpace(descriptor.SPSSODescriptor.NameIDFormats[0])
+	}
+
+	for _, keyDescriptor := range descriptor.SPSSODescriptor.KeyDescriptors {
+		if len(keyDescriptor.KeyInfo.X509Data.X509Certificates) == 0 {
+			continue
+		}
+
+		certificate := strings.Join(strings.Fields(keyDescriptor.KeyInfo.X509Data.X509Certificates[0].Data), "")
+		switch keyDescriptor.Use {
+		case "signing":
+			sp.SigningCert = certificate
+		case "encryption":
+			sp.EncryptionCert = certificate
+		default:
+			// a key descriptor without "use" is used for both signing and encryption
+			if sp.SigningCert == "" {
+				sp.SigningCert = certificate
+			}
+			if sp.EncryptionCert == "" {
+				sp.EncryptionCert = certificate
+			}
+		}
+	}
+
+	return sp, nil
+}
+
+// maxSamlSpMetadataSize limits the size of the downloaded SAML metadata of a service provider
+const maxSamlSpMetadataSize = 1 << 20
+
+// checkSamlSpMetadataUrl only allows HTTP(S) URLs of public hosts, the metadata URL is fetched by
+// the server, so it must not reach the loopback, intranet or link-local (e.g. cloud metadata) addresses
+func checkSamlSpMetadataUrl(metadataUrl string) error {
+	u, err := url.Parse(metadataUrl)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("the scheme of the SAML metadata URL: %s should be http or https", metadataUrl)
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("the SAML metadata URL: %s has no host", metadataUrl)
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return err
+	}
+
+	for _, ip := range ips {
+		if ip.IsPrivate() || ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+			return fmt.Errorf("the host of the SAML metadata URL: %s is not a public host", metadataUrl)
+		}
+	}
+	return nil
+}
+
+// GetSamlSpMetadataFromUrl downloads and parses the SAML metadata of a service provider
+func GetSamlSpMetadataFromUrl(metadataUrl string) (*SamlServiceProvider, error) {
+	err := checkSamlSpMetadataUrl(metadataUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	// the redirects are checked as well, so that a public URL can't redirect to an intranet one
+	client := *proxy.DefaultHttpClient
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		return checkSamlSpMetadataUrl(req.URL.String())
+	}
+
+	resp, err := client.Get(metadataUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get the SAML metadata from: %s, status: %s", metadataUrl, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSamlSpMetadataSize))
+	if err != nil {
+		return nil, err
+	}
+
+	sp, err := ParseSamlSpMetadata(data)
+	if err != nil {
+		return nil, err
+	}
+
+	sp.MetadataUrl = metadataUrl
+	return sp, nil
+}
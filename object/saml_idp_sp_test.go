@@ -0,0 +1,171 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/beevik/etree"
+	"github.com/russellhaering/gosaml2/types"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSpMetadata = `<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="https://sp.example.com/metadata">
+  <md:SPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:KeyDescriptor use="signing">
+      <ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#">
+        <ds:X509Data><ds:X509Certificate>
+          U0lHTklORw==
+        </ds:X509Certificate></ds:X509Data>
+      </ds:KeyInfo>
+    </md:KeyDescriptor>
+    <md:KeyDescriptor use="encryption">
+      <ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#">
+        <ds:X509Data><ds:X509Certificate>RU5DUllQVElPTg==</ds:X509Certificate></ds:X509Data>
+      </ds:KeyInfo>
+    </md:KeyDescriptor>
+    <md:NameIDFormat>urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress</md:NameIDFormat>
+    <md:AssertionConsumerService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://sp.example.com/acs/redirect" index="1"/>
+    <md:AssertionConsumerService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" Location="https://sp.example.com/acs/post" index="0"/>
+  </md:SPSSODescriptor>
+</md:EntityDescriptor>`
+
+func TestParseSamlSpMetadata(t *testing.T) {
+	sp, err := ParseSamlSpMetadata([]byte(testSpMetadata))
+	assert.Nil(t, err)
+
+	assert.Equal(t, "https://sp.example.com/metadata", sp.EntityId)
+	assert.Equal(t, "U0lHTklORw==", sp.SigningCert)
+	assert.Equal(t, "RU5DUllQVElPTg==", sp.EncryptionCert)
+	assert.Equal(t, "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress", sp.NameIdFormat)
+	assert.Equal(t, 2, len(sp.AcsUrls))
+
+	acsUrl, err := sp.getAcsUrl("")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://sp.example.com/acs/post", acsUrl.Url)
+
+	acsUrl, err = sp.getAcsUrl("https://sp.example.com/acs/redirect")
+	assert.Nil(t, err)
+	assert.Equal(t, "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect", acsUrl.Binding)
+
+	_, err = sp.getAcsUrl("https://evil.example.com/acs")
+	assert.NotNil(t, err)
+
+	_, err = ParseSamlSpMetadata([]byte(`<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="idp"/>`))
+	assert.NotNil(t, err)
+}
+
+func TestEncryptSamlAssertion(t *testing.T) {
+	certificate, privateKey, err := generateRsaKeys(2048, 256, 1, "Casdoor Cert", "Casdoor Organization")
+	assert.Nil(t, err)
+
+	block, _ := pem.Decode([]byte(certificate))
+	encryptionCert := base64.StdEncoding.EncodeToString(block.Bytes)
+
+	samlResponse := etree.NewElement("samlp:Response")
+	samlResponse.CreateAttr("xmlns:samlp", "urn:oasis:names:tc:SAML:2.0:protocol")
+	samlResponse.CreateAttr("xmlns:saml", "urn:oasis:names:tc:SAML:2.0:assertion")
+	samlResponse.CreateElement("saml:Issuer").SetText("https://idp.example.com")
+	assertion := samlResponse.CreateElement("saml:Assertion")
+	assertion.CreateElement("saml:Subject").CreateElement("saml:NameID").SetText("alice")
+
+	err = encryptSamlAssertion(samlResponse, encryptionCert)
+	assert.Nil(t, err)
+	assert.Nil(t, samlResponse.FindElement("./Assertion"))
+
+	encryptedAssertionElement := samlResponse.FindElement("./EncryptedAssertion").Copy()
+	encryptedAssertionElement.CreateAttr("xmlns:saml", "urn:oasis:names:tc:SAML:2.0:assertion")
+	doc := etree.NewDocument()
+	doc.SetRoot(encryptedAssertionElement)
+	data, err := doc.WriteToBytes()
+	assert.Nil(t, err)
+
+	var encryptedAssertion types.EncryptedAssertion
+	err = xml.Unmarshal(data, &encryptedAssertion)
+	assert.Nil(t, err)
+
+	keyPair, err := tls.X509KeyPair([]byte(certificate), []byte(privateKey))
+	assert.Nil(t, err)
+
+	plainText, err := encryptedAssertion.DecryptBytes(&keyPair)
+	assert.Nil(t, err)
+
+	decrypted := etree.NewDocument()
+	err = decrypted.ReadFromBytes(plainText)
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", decrypted.FindElement("//NameID").Text())
+}
+
+func TestVerifySamlRedirectSignature(t *testing.T) {
+	certificate, privateKey, err := generateRsaKeys(2048, 256, 1, "Casdoor Cert", "Casdoor Organization")
+	assert.Nil(t, err)
+
+	block, _ := pem.Decode([]byte(certificate))
+	signingCert := base64.StdEncoding.EncodeToString(block.Bytes)
+
+	keyPair, err := tls.X509KeyPair([]byte(certificate), []byte(privateKey))
+	assert.Nil(t, err)
+
+	samlRequest := "fZJNT+MwEIb/iuV7mg/K0lpNpS4V2krsbkXKHrhMjTOo+slXs/FvXwGJuICETfn3nfeRz3ZmaPQfJ/e9fm/jQ=="
+	relayState := "https://sp.example.com/app?a=1&b=2"
+	// the SP encodes the query with lowercase hex digits, which url.QueryEscape doesn't reproduce
+	escape := func(s string) string {
+		return regexp.MustCompile("%[0-9A-F]{2}").ReplaceAllStringFunc(url.QueryEscape(s), strings.ToLower)
+	}
+	signedQuery := "SAMLRequest=" + escape(samlRequest) + "&RelayState=" + escape(relayState) + "&SigAlg=" + escape(dsig.RSASHA256SignatureMethod)
+	digest := sha256.Sum256([]byte(signedQuery))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, keyPair.PrivateKey.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+	assert.Nil(t, err)
+	signatureString := base64.StdEncoding.EncodeToString(signature)
+
+	err = verifySamlRedirectSignature(signedQuery, samlRequest, relayState, signatureString, signingCert)
+	assert.Nil(t, err)
+
+	// the relay state is covered by the signature
+	err = verifySamlRedirectSignature(signedQuery, samlRequest, "https://evil.example.com", signatureString, signingCert)
+	assert.NotNil(t, err)
+
+	forgedQuery := "SAMLRequest=" + url.QueryEscape(samlRequest) + "&RelayState=" + url.QueryEscape("https://evil.example.com") + "&SigAlg=" + url.QueryEscape(dsig.RSASHA256SignatureMethod)
+	err = verifySamlRedirectSignature(forgedQuery, samlRequest, "https://evil.example.com", signatureString, signingCert)
+	assert.NotNil(t, err)
+
+	dsaQuery := "SAMLRequest=" + url.QueryEscape(samlRequest) + "&RelayState=" + url.QueryEscape(relayState) + "&SigAlg=" + url.QueryEscape("http://www.w3.org/2000/09/xmldsig#dsa-sha1")
+	err = verifySamlRedirectSignature(dsaQuery, samlRequest, relayState, signatureString, signingCert)
+	assert.NotNil(t, err)
+
+	// an unsigned POST binding request is rejected when the SP has a signing certificate
+	err = verifySamlRequestSignature([]byte(`<samlp:AuthnRequest xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" ID="_1"/>`), signingCert)
+	assert.NotNil(t, err)
+}
+
+func TestCheckSamlSpMetadataUrl(t *testing.T) {
+	assert.NotNil(t, checkSamlSpMetadataUrl("file:///etc/passwd"))
+	assert.NotNil(t, checkSamlSpMetadataUrl("http://127.0.0.1:8000/api/get-users"))
+	assert.NotNil(t, checkSamlSpMetadataUrl("http://169.254.169.254/latest/meta-data"))
+	assert.NotNil(t, checkSamlSpMetadataUrl("https://10.0.0.1/metadata"))
+	assert.NotNil(t, checkSamlSpMetadataUrl("https:///metadata"))
+}
@@ -0,0 +1,43 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSamlIdpInitiatedResponseAudience(t *testing.T) {
+	application := &Application{
+		Owner:        "admin",
+		Name:         "app-saml",
+		SamlReplyUrl: "https://sp.example.com/acs",
+	}
+	user := &User{Owner: "built-in", Name: "alice"}
+
+	// no SP entity ID to put into the AudienceRestriction
+	_, _, _, err := GetSamlIdpInitiatedResponse(application, user, "", "door.casdoor.com")
+	assert.NotNil(t, err)
+
+	// the SP is not registered in the application
+	_, _, _, err = GetSamlIdpInitiatedResponse(application, user, "https://sp.example.com/metadata", "door.casdoor.com")
+	assert.NotNil(t, err)
+
+	application.SamlReplyUrl = ""
+	application.RedirectUris = []string{"https://sp.example.com/metadata"}
+	_, _, _, err = GetSamlIdpInitiatedResponse(application, user, "", "door.casdoor.com")
+	assert.NotNil(t, err)
+}
@@ -18,16 +18,21 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/xml"
 	"fmt"
 	"net/url"
 	"regexp"
 	"strings"
 
+	"github.com/beevik/etree"
+	"github.com/casdoor/casdoor/conf"
 	"github.com/casdoor/casdoor/idp"
+	"github.com/casdoor/casdoor/util"
 	"github.com/mitchellh/mapstructure"
 
 	"github.com/casdoor/casdoor/i18n"
 	saml2 "github.com/russellhaering/gosaml2"
+	"github.com/russellhaering/gosaml2/types"
 	dsig "github.com/russellhaering/goxmldsig"
 )
 
@@ -110,45 +115,168 @@ func buildSp(provider *Provider, samlResponse string, host string) (*saml2.SAMLS
 	sp := &saml2.SAMLServiceProvider{
 		ServiceProviderIssuer:       fmt.Sprintf("%s/api/acs", origin),
 		AssertionConsumerServiceURL: fmt.Sprintf("%s/api/acs", origin),
-		SignAuthnRequests:           false,
+		ServiceProviderSLOURL:       getSamlSpSloUrl(provider, origin),
+		SignAuthnRequests:           provider.EnableSignAuthnRequest,
 		IDPCertificateStore:         &certStore,
-		SPKeyStore:                  dsig.RandomKeyStoreForTest(),
 	}
 
 	if provider.Endpoint != "" {
 		sp.IdentityProviderSSOURL = provider.Endpoint
 		sp.IdentityProviderIssuer = provider.IssuerUrl
 	}
-	if provider.EnableSignAuthnRequest {
-		sp.SignAuthnRequests = true
-		sp.SPKeyStore, err = buildSpKeyStore()
-		if err != nil {
-			return nil, err
-		}
+	sp.IdentityProviderSLOURL, err = getSamlIdpSloUrl(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	// the key store signs the AuthnRequests and decrypts the encrypted assertions from the IdP,
+	// it is the key advertised in the SP metadata, so the IdP can encrypt the assertions to it
+	sp.SPKeyStore, err = buildSpKeyStore(provider)
+	if err != nil {
+		return nil, err
 	}
 
 	return sp, nil
 }
 
-func buildSpKeyStore() (dsig.X509KeyStore, error) {
-	keyPair, err := tls.LoadX509KeyPair("object/token_jwt_key.pem", "object/token_jwt_key.key")
+func getSamlSpSloUrl(provider *Provider, origin string) string {
+	return fmt.Sprintf("%s/api/saml/slo/%s/%s", origin, provider.Owner, provider.Name)
+}
+
+// getSamlIdpSloUrl returns the Single Logout URL of the IdP from the IdP metadata of the provider, if any
+func getSamlIdpSloUrl(provider *Provider) (string, error) {
+	if provider.Metadata == "" {
+		return "", nil
+	}
+
+	var descriptor types.EntityDescriptor
+	err := xml.Unmarshal([]byte(provider.Metadata), &descriptor)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to parse the IdP metadata of provider: %s, %s", provider.Name, err.Error())
 	}
+
+	if descriptor.IDPSSODescriptor == nil {
+		return "", nil
+	}
+
+	sloUrl := ""
+	for _, service := range descriptor.IDPSSODescriptor.SingleLogoutServices {
+		if service.Binding == saml2.BindingHttpPost {
+			return service.Location, nil
+		}
+		if sloUrl == "" {
+			sloUrl = service.Location
+		}
+	}
+	return sloUrl, nil
+}
+
+// buildSpKeyStore returns the key pair of the cert selected in the provider,
+// or the built-in JWT key pair if the provider has no cert
+func buildSpKeyStore(provider *Provider) (dsig.X509KeyStore, error) {
+	var keyPair tls.Certificate
+	var err error
+	if provider.Cert != "" {
+		cert, err := GetCert(util.GetId(provider.Owner, provider.Cert))
+		if err != nil {
+			return nil, err
+		}
+		if cert == nil {
+			return nil, fmt.Errorf("the cert: %s does not exist", provider.Cert)
+		}
+
+		keyPair, err = tls.X509KeyPair([]byte(cert.Certificate), []byte(cert.PrivateKey))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		keyPair, err = tls.LoadX509KeyPair("object/token_jwt_key.pem", "object/token_jwt_key.key")
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &dsig.TLSCertKeyStore{
 		PrivateKey:  keyPair.PrivateKey,
 		Certificate: keyPair.Certificate,
 	}, nil
 }
 
+// GetSamlSpMetadata returns the metadata of Casdoor as the SAML service provider of the provider,
+// to be imported into the IdP (e.g. ADFS, Okta)
+func GetSamlSpMetadata(provider *Provider, host string) (*types.EntityDescriptor, error) {
+	if provider.Category != "SAML" {
+		return nil, fmt.Errorf("the provider: %s's category is not SAML", provider.Name)
+	}
+
+	_, origin := getOriginFromHost(host)
+
+	spKeyStore, err := buildSpKeyStore(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	sp := &saml2.SAMLServiceProvider{
+		ServiceProviderIssuer:       fmt.Sprintf("%s/api/acs", origin),
+		AssertionConsumerServiceURL: fmt.Sprintf("%s/api/acs", origin),
+		ServiceProviderSLOURL:       getSamlSpSloUrl(provider, origin),
+		SignAuthnRequests:           provider.EnableSignAuthnRequest,
+		SPKeyStore:                  spKeyStore,
+	}
+
+	return sp.MetadataWithSLO(0)
+}
+
+// HandleSamlLogoutRequest validates the LogoutRequest sent by the IdP of the provider and returns the NameID
+// of the user being logged out and the auto-submitting HTML form posting the LogoutResponse back to the IdP,
+// the form is empty if the IdP has no Single Logout URL
+func HandleSamlLogoutRequest(provider *Provider, samlRequest string, relayState string, host string) (string, []byte, error) {
+	sp, err := buildSp(provider, "", host)
+	if err != nil {
+		return "", nil, err
+	}
+
+	logoutRequest, err := sp.ValidateEncodedLogoutRequestPOST(samlRequest)
+	if err != nil {
+		return "", nil, err
+	}
+
+	nameId := ""
+	if logoutRequest.NameID != nil {
+		nameId = logoutRequest.NameID.Value
+	}
+
+	if sp.IdentityProviderSLOURL == "" {
+		return nameId, nil, nil
+	}
+
+	var doc *etree.Document
+	if sp.SignAuthnRequests || provider.Cert != "" {
+		doc, err = sp.BuildLogoutResponseDocument(saml2.StatusCodeSuccess, logoutRequest.ID)
+	} else {
+		doc, err = sp.BuildLogoutResponseDocumentNoSig(saml2.StatusCodeSuccess, logoutRequest.ID)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	form, err := sp.BuildLogoutResponseBodyPostFromDocument(relayState, doc)
+	if err != nil {
+		return "", nil, err
+	}
+	return nameId, form, nil
+}
+
 func buildSpCertificateStore(provider *Provider, samlResponse string) (certStore dsig.MemoryX509CertificateStore, err error) {
 	certEncodedData := ""
-	if samlResponse != "" {
+	if samlResponse != "" && !(provider.IdP != "" && isSamlResponseEncrypted(samlResponse)) {
 		certEncodedData, err = getCertificateFromSamlResponse(samlResponse, provider.Type)
 		if err != nil {
 			return
 		}
 	} else if provider.IdP != "" {
+		// the certificates inside an encrypted response may belong to the SP (in EncryptedKey),
+		// so the configured IdP certificate is used to validate the signature instead
 		certEncodedData = provider.IdP
 	}
 
@@ -191,5 +319,45 @@ func getCertificateFromSamlResponse(samlResponse string, providerType string) (s
 		expression = fmt.Sprintf("<%s:X509Certificate>([\\s\\S]*?)</%s:X509Certificate>", tag, tag)
 	}
 	res := regexp.MustCompile(expression).FindStringSubmatch(deStr)
+	if len(res) < 2 {
+		return "", fmt.Errorf("the X509Certificate is not found in the SAML response, please set the IdP certificate of the provider")
+	}
 	return res[1], nil
 }
+
+// isSamlResponseEncrypted returns true if the SAML response contains an EncryptedAssertion element
+func isSamlResponseEncrypted(samlResponse string) bool {
+	de, err := base64.StdEncoding.DecodeString(samlResponse)
+	if err != nil {
+		return false
+	}
+
+	doc := etree.NewDocument()
+	err = doc.ReadFromBytes(de)
+	if err != nil || doc.Root() == nil {
+		return false
+	}
+
+	for _, child := range doc.Root().ChildElements() {
+		if child.Tag == "EncryptedAssertion" && child.NamespaceURI() == saml2.SAMLAssertionNamespace {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSamlNameIdOfUser returns true if the NameID of a SAML LogoutRequest from the IdP of the provider refers to the user,
+// the NameID is matched in the same way as the SAML login: the name, email, phone or the linked IdP account ID
+func IsSamlNameIdOfUser(user *User, provider *Provider, nameId string) bool {
+	if user == nil || nameId == "" {
+		return false
+	}
+
+	if conf.GetConfigBool("isUsernameLowered") {
+		nameId = strings.ToLower(nameId)
+	}
+	nameId = strings.TrimSpace(nameId)
+
+	return user.Name == nameId || user.Email == nameId || user.Phone == nameId ||
+		getUserProperty(user, fmt.Sprintf("oauth_%s_id", provider.Type)) == nameId
+}
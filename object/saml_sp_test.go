@@ -0,0 +1,66 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testIdpMetadata = `<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="https://idp.example.com">
+  <md:IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:SingleLogoutService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://idp.example.com/slo/redirect"/>
+    <md:SingleLogoutService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" Location="https://idp.example.com/slo/post"/>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" Location="https://idp.example.com/sso"/>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>`
+
+func TestGetSamlIdpSloUrl(t *testing.T) {
+	sloUrl, err := getSamlIdpSloUrl(&Provider{Name: "provider_saml", Metadata: testIdpMetadata})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://idp.example.com/slo/post", sloUrl)
+
+	sloUrl, err = getSamlIdpSloUrl(&Provider{Name: "provider_saml"})
+	assert.Nil(t, err)
+	assert.Equal(t, "", sloUrl)
+
+	assert.Equal(t, "https://door.casdoor.com/api/saml/slo/admin/provider_saml", getSamlSpSloUrl(&Provider{Owner: "admin", Name: "provider_saml"}, "https://door.casdoor.com"))
+}
+
+func TestIsSamlResponseEncrypted(t *testing.T) {
+	encrypted := base64.StdEncoding.EncodeToString([]byte(`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion"><saml:EncryptedAssertion/></samlp:Response>`))
+	assert.True(t, isSamlResponseEncrypted(encrypted))
+
+	plain := base64.StdEncoding.EncodeToString([]byte(`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion"><saml:Assertion/></samlp:Response>`))
+	assert.False(t, isSamlResponseEncrypted(plain))
+
+	// the text "EncryptedAssertion" in an attribute value doesn't make the response encrypted
+	attribute := base64.StdEncoding.EncodeToString([]byte(`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion"><saml:Assertion><saml:AttributeValue>EncryptedAssertion</saml:AttributeValue></saml:Assertion></samlp:Response>`))
+	assert.False(t, isSamlResponseEncrypted(attribute))
+}
+
+func TestIsSamlNameIdOfUser(t *testing.T) {
+	user := &User{Owner: "built-in", Name: "alice", Email: "alice@example.com", Properties: map[string]string{"oauth_Keycloak_id": "f81d4fae"}}
+	provider := &Provider{Type: "Keycloak"}
+
+	assert.True(t, IsSamlNameIdOfUser(user, provider, "alice"))
+	assert.True(t, IsSamlNameIdOfUser(user, provider, "alice@example.com"))
+	assert.True(t, IsSamlNameIdOfUser(user, provider, "f81d4fae"))
+	assert.False(t, IsSamlNameIdOfUser(user, provider, "bob"))
+	assert.False(t, IsSamlNameIdOfUser(user, provider, ""))
+	assert.False(t, IsSamlNameIdOfUser(nil, provider, "alice"))
+}
@@ -139,15 +139,20 @@ func AddSession(session *Session) (bool, error) {
 
 func DeleteSession(id string) (bool, error) {
 	owner, name, application := util.GetOwnerAndNameAndOtherFromId(id)
-	if owner == CasdoorOrganization && application == CasdoorApplication {
-		session, err := GetSingleSession(id)
-		if err != nil {
-			return false, err
-		}
+	session, err := GetSingleSession(id)
+	if err != nil {
+		return false, err
+	}
 
-		if session != nil {
+	if session != nil {
+		if owner == CasdoorOrganization && application == CasdoorApplication {
 			DeleteBeegoSession(session.SessionId)
 		}
+
+		err = SendCasLogoutRequests(session.SessionId)
+		if err != nil {
+			return false, err
+		}
 	}
 
 	affected, err := ormer.Engine.ID(core.PK{owner, name, application}).Delete(&Session{})
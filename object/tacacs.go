@@ -0,0 +1,102 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+// TacacsAccounting is a TACACS+ accounting record of a network device, a shell session or a command, see RFC 8907, 7
+type TacacsAccounting struct {
+	Owner       string    `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string    `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime time.Time `json:"createdTime"`
+
+	Username      string `xorm:"index" json:"username"`
+	PrivLvl       int    `json:"privLvl"`       // The privilege level of the user on the device, 0 to 15
+	AuthenMethod  int    `json:"authenMethod"`  // e.g. TACACSPLUS (6)
+	AuthenService int    `json:"authenService"` // e.g. LOGIN (1)
+
+	NasAddr string `xorm:"varchar(100)" json:"nasAddr"` // The address of the network device that sent the accounting request
+	Port    string `xorm:"varchar(100)" json:"port"`    // The port of the device that the user is connected to, e.g. "tty0"
+	RemAddr string `xorm:"varchar(100)" json:"remAddr"` // The address of the user, e.g. "192.168.0.100"
+
+	TaskId  string   `xorm:"varchar(100) index" json:"taskId"`
+	Service string   `xorm:"varchar(100)" json:"service"` // e.g. "shell"
+	Command string   `xorm:"mediumtext" json:"command"`   // The command line of a command accounting, e.g. "show running-config"
+	Args    []string `xorm:"mediumtext" json:"args"`
+
+	ElapsedTime   int64     `json:"elapsedTime"` // Indicates how long (in seconds) the task has run.
+	LastUpdate    time.Time `json:"lastUpdate"`
+	AcctStartTime time.Time `xorm:"index" json:"acctStartTime"`
+	AcctStopTime  time.Time `xorm:"index" json:"acctStopTime"`
+}
+
+func (ta *TacacsAccounting) GetId() string {
+	return util.GetId(ta.Owner, ta.Name)
+}
+
+// GetTacacsAccountingByTaskId returns the record of a task of a device, the start, watchdog and stop records of a task
+// share the task_id
+func GetTacacsAccountingByTaskId(nasAddr string, taskId string) (*TacacsAccounting, error) {
+	ta := TacacsAccounting{}
+	existed, err := ormer.Engine.Where("nas_addr = ? and task_id = ?", nasAddr, taskId).Desc("created_time").Get(&ta)
+	if err != nil {
+		return nil, err
+	}
+	if existed {
+		return &ta, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func AddTacacsAccounting(ta *TacacsAccounting) error {
+	_, err := ormer.Engine.Insert(ta)
+	return err
+}
+
+func UpdateTacacsAccounting(id string, ta *TacacsAccounting) error {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	_, err := ormer.Engine.ID(core.PK{owner, name}).Update(ta)
+	return err
+}
+
+// InterimUpdateTacacsAccounting updates the record of a task with a watchdog or stop record of the task
+func InterimUpdateTacacsAccounting(oldTa *TacacsAccounting, newTa *TacacsAccounting, stop bool) error {
+	if oldTa.TaskId != newTa.TaskId {
+		return fmt.Errorf("TaskId is not equal, newTa = %s, oldTa = %s", newTa.TaskId, oldTa.TaskId)
+	}
+	oldTa.ElapsedTime = newTa.ElapsedTime
+	oldTa.LastUpdate = time.Now()
+	if newTa.Command != "" {
+		oldTa.Command = newTa.Command
+	}
+	if len(newTa.Args) != 0 {
+		oldTa.Args = newTa.Args
+	}
+	if stop {
+		oldTa.AcctStopTime = newTa.AcctStopTime
+		if oldTa.AcctStopTime.IsZero() {
+			oldTa.AcctStopTime = time.Now()
+		}
+	}
+
+	return UpdateTacacsAccounting(oldTa.GetId(), oldTa)
+}
@@ -0,0 +1,329 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/conf"
+	"github.com/gomodule/redigo/redis"
+)
+
+// TicketStore stores short-lived tickets (CAS service tickets, PGTs, TGTs, SAML artifacts, etc.)
+// so that a ticket issued by one Casdoor instance can be consumed by another one
+type TicketStore interface {
+	// Put stores the JSON encoding of value under ticket, the ticket expires after ttl
+	Put(ticket string, value interface{}, ttl time.Duration) error
+	// Take decodes the value of ticket into value and removes the ticket,
+	// it returns false if the ticket doesn't exist, has expired or has already been taken
+	Take(ticket string, value interface{}) (bool, error)
+	// Get decodes the value of ticket into value without removing it, for multi-use tickets like CAS TGTs
+	Get(ticket string, value interface{}) (bool, error)
+	// Delete removes the ticket
+	Delete(ticket string) error
+}
+
+// Ticket is a ticket persisted by the database ticket store
+type Ticket struct {
+	Name       string `xorm:"varchar(200) notnull pk" json:"name"`
+	Value      string `xorm:"mediumtext" json:"value"`
+	ExpireTime int64  `xorm:"index" json:"expireTime"`
+}
+
+// the ticket types are the prefixes of the ticket keys, so that a ticket of one type
+// (e.g. a PGT) can't be looked up as a ticket of another type (e.g. an ST)
+const (
+	ticketTypeCasServiceTicket        = "cas:st"
+	ticketTypeCasProxyTicket          = "cas:pt"
+	ticketTypeCasProxyGrantingTicket  = "cas:pgt"
+	ticketTypeCasTicketGrantingTicket = "cas:tgt"
+	ticketTypeSamlArtifact            = "saml:artifact"
+)
+
+var (
+	ticketStore     TicketStore
+	ticketStoreLock sync.Mutex
+)
+
+// getTicketStore returns the Redis ticket store if "redisEndpoint" is configured, otherwise the database ticket store
+func getTicketStore() TicketStore {
+	ticketStoreLock.Lock()
+	defer ticketStoreLock.Unlock()
+
+	if ticketStore == nil {
+		redisEndpoint := conf.GetConfigString("redisEndpoint")
+		if redisEndpoint == "" {
+			ticketStore = &dbTicketStore{}
+		} else {
+			ticketStore = newRedisTicketStore(redisEndpoint)
+		}
+	}
+	return ticketStore
+}
+
+// setTicketStore replaces the ticket store and returns the previous one, e.g. with an in-memory store in the tests
+func setTicketStore(store TicketStore) TicketStore {
+	ticketStoreLock.Lock()
+	defer ticketStoreLock.Unlock()
+
+	oldStore := ticketStore
+	ticketStore = store
+	return oldStore
+}
+
+func getTicketKey(ticketType string, ticket string) string {
+	return fmt.Sprintf("%s:%s", ticketType, ticket)
+}
+
+// ClearExpiredTickets removes the expired tickets that have never been taken from the database ticket store,
+// the Redis ticket store expires the tickets by itself. The expired services of the CAS Single Logout are
+// removed too.
+func ClearExpiredTickets() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if store, ok := getTicketStore().(*dbTicketStore); ok {
+			err := store.deleteExpiredTickets()
+			if err != nil {
+				logs.Warning(fmt.Sprintf("ClearExpiredTickets() error: %s", err.Error()))
+			}
+		}
+
+		err := deleteExpiredCasSessionServices()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("ClearExpiredTickets() error: %s", err.Error()))
+		}
+	}
+}
+
+// PutTicket stores value in the ticket store, for the short-lived states of the other packages like the
+// Access-Challenges of the RADIUS server
+func PutTicket(ticket string, value interface{}, ttl time.Duration) error {
+	return getTicketStore().Put(ticket, value, ttl)
+}
+
+// TakeTicket decodes the value of ticket into value and removes the ticket
+func TakeTicket(ticket string, value interface{}) (bool, error) {
+	return getTicketStore().Take(ticket, value)
+}
+
+type dbTicketStore struct{}
+
+func (s *dbTicketStore) Put(ticket string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = ormer.Engine.Insert(&Ticket{
+		Name:       ticket,
+		Value:      string(data),
+		ExpireTime: time.Now().Add(ttl).Unix(),
+	})
+	return err
+}
+
+func (s *dbTicketStore) deleteExpiredTickets() error {
+	_, err := ormer.Engine.Where("expire_time < ?", time.Now().Unix()).Delete(&Ticket{})
+	return err
+}
+
+func (s *dbTicketStore) Take(ticket string, value interface{}) (bool, error) {
+	t := Ticket{Name: ticket}
+	existed, err := ormer.Engine.Get(&t)
+	if err != nil {
+		return false, err
+	}
+	if !existed {
+		return false, nil
+	}
+
+	// only the instance that actually deletes the row owns the ticket
+	affected, err := ormer.Engine.ID(ticket).Delete(&Ticket{})
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 || time.Now().Unix() > t.ExpireTime {
+		return false, nil
+	}
+
+	err = json.Unmarshal([]byte(t.Value), value)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *dbTicketStore) Get(ticket string, value interface{}) (bool, error) {
+	t := Ticket{Name: ticket}
+	existed, err := ormer.Engine.Get(&t)
+	if err != nil {
+		return false, err
+	}
+	if !existed || time.Now().Unix() > t.ExpireTime {
+		return false, nil
+	}
+
+	err = json.Unmarshal([]byte(t.Value), value)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *dbTicketStore) Delete(ticket string) error {
+	_, err := ormer.Engine.ID(ticket).Delete(&Ticket{})
+	return err
+}
+
+type redisTicketStore struct {
+	pool *redis.Pool
+}
+
+// newRedisTicketStore accepts the same "redisEndpoint" format as the beego Redis session provider:
+// "address[,poolSize[,password[,dbNum]]]"
+func newRedisTicketStore(redisEndpoint string) *redisTicketStore {
+	tokens := strings.Split(redisEndpoint, ",")
+	address := tokens[0]
+	maxIdle := 100
+	password := ""
+	dbNum := 0
+	if len(tokens) > 1 {
+		if n, err := strconv.Atoi(tokens[1]); err == nil && n > 0 {
+			maxIdle = n
+		}
+	}
+	if len(tokens) > 2 {
+		password = tokens[2]
+	}
+	if len(tokens) > 3 {
+		if n, err := strconv.Atoi(tokens[3]); err == nil {
+			dbNum = n
+		}
+	}
+
+	return &redisTicketStore{
+		pool: &redis.Pool{
+			MaxIdle:     maxIdle,
+			IdleTimeout: 240 * time.Second,
+			Dial: func() (redis.Conn, error) {
+				return redis.Dial("tcp", address, redis.DialPassword(password), redis.DialDatabase(dbNum))
+			},
+		},
+	}
+}
+
+func getRedisTicketKey(ticket string) string {
+	return fmt.Sprintf("casdoor:ticket:%s", ticket)
+}
+
+func (s *redisTicketStore) Put(ticket string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	seconds := int64(ttl / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	_, err = conn.Do("SET", getRedisTicketKey(ticket), data, "EX", seconds)
+	return err
+}
+
+func (s *redisTicketStore) Take(ticket string, value interface{}) (bool, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	key := getRedisTicketKey(ticket)
+	err := conn.Send("MULTI")
+	if err != nil {
+		return false, err
+	}
+	err = conn.Send("GET", key)
+	if err != nil {
+		return false, err
+	}
+	err = conn.Send("DEL", key)
+	if err != nil {
+		return false, err
+	}
+
+	replies, err := redis.Values(conn.Do("EXEC"))
+	if err != nil {
+		return false, err
+	}
+	if len(replies) != 2 || replies[0] == nil {
+		return false, nil
+	}
+
+	// only the client that actually deletes the key owns the ticket
+	deleted, err := redis.Int(replies[1], nil)
+	if err != nil {
+		return false, err
+	}
+	if deleted == 0 {
+		return false, nil
+	}
+
+	data, err := redis.Bytes(replies[0], nil)
+	if err != nil {
+		return false, err
+	}
+
+	err = json.Unmarshal(data, value)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *redisTicketStore) Get(ticket string, value interface{}) (bool, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	data, err := redis.Bytes(conn.Do("GET", getRedisTicketKey(ticket)))
+	if err == redis.ErrNil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	err = json.Unmarshal(data, value)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *redisTicketStore) Delete(ticket string) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("DEL", getRedisTicketKey(ticket))
+	return err
+}
@@ -23,7 +23,6 @@ import (
 	"fmt"
 	"math/rand"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/beevik/etree"
@@ -118,11 +117,11 @@ type Saml11AssertionArtifact struct {
 	InnerXML string   `xml:",innerxml"`
 }
 
-// st is short for service ticket
-var stToServiceResponse sync.Map
+// st is short for service ticket, pt is short for proxy ticket
+const casServiceTicketExpireTime = 5 * time.Minute
 
 // pgt is short for proxy granting ticket
-var pgtToServiceResponse sync.Map
+const casProxyGrantingTicketExpireTime = 2 * time.Hour
 
 func CheckCasLogin(application *Application, lang string, service string) error {
 	if len(application.RedirectUris) > 0 && !application.IsRedirectUriValid(service) {
@@ -131,14 +130,17 @@ func CheckCasLogin(application *Application, lang string, service string) error
 	return nil
 }
 
-func StoreCasTokenForPgt(token *CasAuthenticationSuccess, service, userId string) string {
+func StoreCasTokenForPgt(token *CasAuthenticationSuccess, service, userId string) (string, error) {
 	pgt := fmt.Sprintf("PGT-%s", util.GenerateId())
-	pgtToServiceResponse.Store(pgt, &CasAuthenticationSuccessWrapper{
+	err := getTicketStore().Put(getTicketKey(ticketTypeCasProxyGrantingTicket, pgt), &CasAuthenticationSuccessWrapper{
 		AuthenticationSuccess: token,
 		Service:               service,
 		UserId:                userId,
-	})
-	return pgt
+	}, casProxyGrantingTicketExpireTime)
+	if err != nil {
+		return "", err
+	}
+	return pgt, nil
 }
 
 func GenerateId() {
@@ -151,13 +153,16 @@ func GenerateId() {
 @ret2: token, nil if not found
 @ret3: the service URL who requested to issue this token
 @ret4: userIf of user who requested to issue this token
+@ret5: error
 */
-func GetCasTokenByPgt(pgt string) (bool, *CasAuthenticationSuccess, string, string) {
-	if responseWrapperType, ok := pgtToServiceResponse.LoadAndDelete(pgt); ok {
-		responseWrapperTypeCast := responseWrapperType.(*CasAuthenticationSuccessWrapper)
-		return true, responseWrapperTypeCast.AuthenticationSuccess, responseWrapperTypeCast.Service, responseWrapperTypeCast.UserId
-	}
-	return false, nil, "", ""
+func GetCasTokenByPgt(pgt string) (bool, *CasAuthenticationSuccess, string, string, error) {
+	return getCasToken(ticketTypeCasProxyGrantingTicket, pgt)
+}
+
+// GetCasTokenByServiceTicket is the same as GetCasTokenByTicket, but only accepts service tickets,
+// for "/validate" and "/samlValidate"
+func GetCasTokenByServiceTicket(ticket string) (bool, *CasAuthenticationSuccess, string, string, error) {
+	return getCasToken(ticketTypeCasServiceTicket, ticket)
 }
 
 // GetCasTokenByTicket
@@ -166,23 +171,40 @@ func GetCasTokenByPgt(pgt string) (bool, *CasAuthenticationSuccess, string, stri
 @ret2: token, nil if not found
 @ret3: the service URL who requested to issue this token
 @ret4: userIf of user who requested to issue this token
+@ret5: error
 */
-func GetCasTokenByTicket(ticket string) (bool, *CasAuthenticationSuccess, string, string) {
-	if responseWrapperType, ok := stToServiceResponse.LoadAndDelete(ticket); ok {
-		responseWrapperTypeCast := responseWrapperType.(*CasAuthenticationSuccessWrapper)
-		return true, responseWrapperTypeCast.AuthenticationSuccess, responseWrapperTypeCast.Service, responseWrapperTypeCast.UserId
+func GetCasTokenByTicket(ticket string) (bool, *CasAuthenticationSuccess, string, string, error) {
+	// a proxy ticket is accepted wherever a service ticket is, except for "/serviceValidate"
+	if strings.HasPrefix(ticket, "PT-") {
+		return getCasToken(ticketTypeCasProxyTicket, ticket)
+	}
+	return getCasToken(ticketTypeCasServiceTicket, ticket)
+}
+
+// getCasToken takes the ticket of the type from the ticket store, a ticket can only be validated once
+func getCasToken(ticketType string, ticket string) (bool, *CasAuthenticationSuccess, string, string, error) {
+	var responseWrapper CasAuthenticationSuccessWrapper
+	ok, err := getTicketStore().Take(getTicketKey(ticketType, ticket), &responseWrapper)
+	if err != nil {
+		return false, nil, "", "", err
 	}
-	return false, nil, "", ""
+	if !ok || responseWrapper.AuthenticationSuccess == nil {
+		return false, nil, "", "", nil
+	}
+	return true, responseWrapper.AuthenticationSuccess, responseWrapper.Service, responseWrapper.UserId, nil
 }
 
-func StoreCasTokenForProxyTicket(token *CasAuthenticationSuccess, targetService, userId string) string {
+func StoreCasTokenForProxyTicket(token *CasAuthenticationSuccess, targetService, userId string) (string, error) {
 	proxyTicket := fmt.Sprintf("PT-%s", util.GenerateId())
-	stToServiceResponse.Store(proxyTicket, &CasAuthenticationSuccessWrapper{
+	err := getTicketStore().Put(getTicketKey(ticketTypeCasProxyTicket, proxyTicket), &CasAuthenticationSuccessWrapper{
 		AuthenticationSuccess: token,
 		Service:               targetService,
 		UserId:                userId,
-	})
-	return proxyTicket
+	}, casServiceTicketExpireTime)
+	if err != nil {
+		return "", err
+	}
+	return proxyTicket, nil
 }
 
 func escapeXMLText(input string) (string, error) {
@@ -248,11 +270,14 @@ func GenerateCasToken(userId string, service string) (string, error) {
 	}
 
 	st := fmt.Sprintf("ST-%d", rand.Int())
-	stToServiceResponse.Store(st, &CasAuthenticationSuccessWrapper{
+	err = getTicketStore().Put(getTicketKey(ticketTypeCasServiceTicket, st), &CasAuthenticationSuccessWrapper{
 		AuthenticationSuccess: &authenticationSuccess,
 		Service:               service,
 		UserId:                userId,
-	})
+	}, casServiceTicketExpireTime)
+	if err != nil {
+		return "", err
+	}
 	return st, nil
 }
 
@@ -274,7 +299,10 @@ func GetValidationBySaml(samlRequest string, host string) (string, string, error
 		return "", "", fmt.Errorf("request.AssertionArtifact.InnerXML error, AssertionArtifact field not found")
 	}
 
-	ok, _, service, userId := GetCasTokenByTicket(ticket)
+	ok, _, service, userId, err := GetCasTokenByServiceTicket(ticket)
+	if err != nil {
+		return "", "", err
+	}
 	if !ok {
 		return "", "", fmt.Errorf("the CAS token for ticket %s is not found", ticket)
 	}
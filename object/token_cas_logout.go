@@ -0,0 +1,145 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/beego/beego"
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/util"
+	"github.com/google/uuid"
+)
+
+// CasSessionService records a service that received a CAS service ticket in a Casdoor session,
+// the service is notified by a CAS LogoutRequest when the session is logged out. The record expires
+// with the session.
+type CasSessionService struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	User       string `xorm:"varchar(100)" json:"user"`
+	SessionId  string `xorm:"varchar(100) index" json:"sessionId"`
+	Service    string `xorm:"varchar(1000)" json:"service"`
+	ExpireTime int64  `xorm:"index" json:"expireTime"`
+}
+
+// AddCasSessionService records that the service ticket st was issued to service in the Casdoor session sessionId.
+// The LogoutRequest is posted by Casdoor itself, so only the services matching a redirect URI of the application
+// are recorded, not the ones accepted because the application has no redirect URIs.
+func AddCasSessionService(sessionId string, application *Application, userId string, service string, st string) (bool, error) {
+	if sessionId == "" || service == "" || !application.IsRedirectUriConfigured(service) {
+		return false, nil
+	}
+
+	owner, name := util.GetOwnerAndNameFromId(userId)
+	casSessionService := &CasSessionService{
+		Owner:       owner,
+		Name:        st,
+		CreatedTime: util.GetCurrentTime(),
+		User:        name,
+		SessionId:   sessionId,
+		Service:     service,
+		ExpireTime:  time.Now().Unix() + beego.BConfig.WebConfig.Session.SessionGCMaxLifetime,
+	}
+
+	affected, err := ormer.Engine.Insert(casSessionService)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func getCasSessionServices(sessionId string) ([]*CasSessionService, error) {
+	casSessionServices := []*CasSessionService{}
+	err := ormer.Engine.Where("session_id = ?", sessionId).Find(&casSessionServices)
+	if err != nil {
+		return casSessionServices, err
+	}
+
+	return casSessionServices, nil
+}
+
+// deleteExpiredCasSessionServices removes the services of the sessions that have expired without being logged out
+func deleteExpiredCasSessionServices() error {
+	_, err := ormer.Engine.Where("expire_time < ?", time.Now().Unix()).Delete(&CasSessionService{})
+	return err
+}
+
+// getCasLogoutRequest returns the SAML LogoutRequest defined by the CAS protocol,
+// the SessionIndex is the service ticket issued to the service
+func getCasLogoutRequest(user string, st string) string {
+	return fmt.Sprintf(`<samlp:LogoutRequest xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_%s" Version="2.0" IssueInstant="%s"><saml:NameID>%s</saml:NameID><samlp:SessionIndex>%s</samlp:SessionIndex></samlp:LogoutRequest>`,
+		uuid.New(), time.Now().UTC().Format(time.RFC3339), user, st)
+}
+
+func sendCasLogoutRequest(casSessionService *CasSessionService) error {
+	user, err := escapeXMLText(casSessionService.User)
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{}
+	form.Set("logoutRequest", getCasLogoutRequest(user, casSessionService.Name))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(casSessionService.Service, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("the CAS service: %s responded to the LogoutRequest with status: %s", casSessionService.Service, resp.Status)
+	}
+	return nil
+}
+
+// SendCasLogoutRequests sends a CAS LogoutRequest to every service that received a service ticket
+// in the Casdoor sessions, the requests are sent in the background and the failures are only logged
+func SendCasLogoutRequests(sessionIds []string) error {
+	for _, sessionId := range sessionIds {
+		casSessionServices, err := getCasSessionServices(sessionId)
+		if err != nil {
+			return err
+		}
+
+		if len(casSessionServices) == 0 {
+			continue
+		}
+
+		_, err = ormer.Engine.Where("session_id = ?", sessionId).Delete(&CasSessionService{})
+		if err != nil {
+			return err
+		}
+
+		util.SafeGoroutine(func() {
+			for _, casSessionService := range casSessionServices {
+				err := sendCasLogoutRequest(casSessionService)
+				if err != nil {
+					logs.Warning("failed to send the CAS LogoutRequest to: %s, %s", casSessionService.Service, err.Error())
+				}
+			}
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendCasLogoutRequest(t *testing.T) {
+	var logoutRequest string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		values, _ := url.ParseQuery(string(body))
+		logoutRequest = values.Get("logoutRequest")
+	}))
+	defer server.Close()
+
+	err := sendCasLogoutRequest(&CasSessionService{
+		Owner:   "built-in",
+		Name:    "ST-123",
+		User:    "alice",
+		Service: server.URL,
+	})
+	assert.Nil(t, err)
+
+	doc := etree.NewDocument()
+	err = doc.ReadFromString(logoutRequest)
+	assert.Nil(t, err)
+	assert.Equal(t, "LogoutRequest", doc.Root().Tag)
+	assert.Equal(t, "alice", doc.FindElement("//NameID").Text())
+	assert.Equal(t, "ST-123", doc.FindElement("//SessionIndex").Text())
+}
+
+func TestAddCasSessionServiceWithoutRedirectUri(t *testing.T) {
+	application := &Application{Owner: "admin", Name: "app"}
+
+	affected, err := AddCasSessionService("session", application, "built-in/alice", "http://10.0.0.1/logout", "ST-123")
+	assert.Nil(t, err)
+	assert.False(t, affected)
+
+	application.RedirectUris = []string{"https://example.com/cas"}
+	assert.True(t, application.IsRedirectUriConfigured("https://example.com/cas/login"))
+	assert.False(t, application.IsRedirectUriConfigured("http://localhost:8000/cas"))
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+// tgt is short for ticket granting ticket, it is issued by the CAS REST protocol
+// and can be used to get service tickets until it expires or is deleted
+const casTicketGrantingTicketExpireTime = 8 * time.Hour
+
+type CasTicketGrantingTicket struct {
+	Application string
+	UserId      string
+}
+
+func GenerateCasTgt(application *Application, userId string) (string, error) {
+	tgt := fmt.Sprintf("TGT-%s", util.GenerateId())
+	err := getTicketStore().Put(getTicketKey(ticketTypeCasTicketGrantingTicket, tgt), &CasTicketGrantingTicket{
+		Application: application.GetId(),
+		UserId:      userId,
+	}, casTicketGrantingTicketExpireTime)
+	if err != nil {
+		return "", err
+	}
+	return tgt, nil
+}
+
+// GetCasTgt returns the ticket granting ticket issued for the application, nil if not found or expired
+func GetCasTgt(application *Application, tgt string) (*CasTicketGrantingTicket, error) {
+	var ticketGrantingTicket CasTicketGrantingTicket
+	ok, err := getTicketStore().Get(getTicketKey(ticketTypeCasTicketGrantingTicket, tgt), &ticketGrantingTicket)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || ticketGrantingTicket.Application != application.GetId() {
+		return nil, nil
+	}
+	return &ticketGrantingTicket, nil
+}
+
+func DeleteCasTgt(tgt string) error {
+	return getTicketStore().Delete(getTicketKey(ticketTypeCasTicketGrantingTicket, tgt))
+}
+
+// GetCasTgtUrl returns the URL of the TGT resource of the CAS REST protocol
+func GetCasTgtUrl(host string, application *Application, tgt string) string {
+	_, originBackend := getOriginFromHost(host)
+	return fmt.Sprintf("%s/cas/%s/%s/v1/tickets/%s", originBackend, application.Organization, application.Name, tgt)
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCasTgt(t *testing.T) {
+	defer setTicketStore(setTicketStore(&mapTicketStore{}))
+
+	application := &Application{Owner: "admin", Name: "app-built-in", Organization: "built-in"}
+	otherApplication := &Application{Owner: "admin", Name: "app-other", Organization: "built-in"}
+
+	tgt, err := GenerateCasTgt(application, "built-in/admin")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(tgt, "TGT-"))
+
+	// a TGT can be used more than once
+	for i := 0; i < 2; i++ {
+		ticketGrantingTicket, err := GetCasTgt(application, tgt)
+		assert.Nil(t, err)
+		assert.Equal(t, "built-in/admin", ticketGrantingTicket.UserId)
+	}
+
+	ticketGrantingTicket, err := GetCasTgt(otherApplication, tgt)
+	assert.Nil(t, err)
+	assert.Nil(t, ticketGrantingTicket)
+
+	err = DeleteCasTgt(tgt)
+	assert.Nil(t, err)
+
+	ticketGrantingTicket, err = GetCasTgt(application, tgt)
+	assert.Nil(t, err)
+	assert.Nil(t, ticketGrantingTicket)
+}
+
+func TestCasTicketTypes(t *testing.T) {
+	defer setTicketStore(setTicketStore(&mapTicketStore{}))
+
+	application := &Application{Owner: "admin", Name: "app-built-in", Organization: "built-in"}
+	token := &CasAuthenticationSuccess{User: "admin"}
+
+	tgt, err := GenerateCasTgt(application, "built-in/admin")
+	assert.Nil(t, err)
+	pgt, err := StoreCasTokenForPgt(token, "https://service.example.com", "built-in/admin")
+	assert.Nil(t, err)
+	pt, err := StoreCasTokenForProxyTicket(token, "https://proxied.example.com", "built-in/admin")
+	assert.Nil(t, err)
+
+	// a TGT or a PGT is not a service ticket, and validating it doesn't consume it
+	for _, ticket := range []string{tgt, pgt} {
+		ok, _, _, _, err := GetCasTokenByTicket(ticket)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+
+		ok, _, _, _, err = GetCasTokenByServiceTicket(ticket)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+	}
+
+	ticketGrantingTicket, err := GetCasTgt(application, tgt)
+	assert.Nil(t, err)
+	assert.NotNil(t, ticketGrantingTicket)
+
+	// a proxy ticket is not a PGT and not a service ticket for "/validate"
+	ok, _, _, _, err := GetCasTokenByPgt(pt)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, _, _, _, err = GetCasTokenByServiceTicket(pt)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, _, service, _, err := GetCasTokenByTicket(pt)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "https://proxied.example.com", service)
+
+	ok, _, service, _, err = GetCasTokenByPgt(pgt)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "https://service.example.com", service)
+}
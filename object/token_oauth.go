@@ -525,6 +525,14 @@ func GetPasswordToken(application *Application, username string, password string
 		}, nil
 	}
 
+	err = CheckLdapServiceAccount(user, "en")
+	if err != nil {
+		return nil, &TokenError{
+			Error:            InvalidGrant,
+			ErrorDescription: err.Error(),
+		}, nil
+	}
+
 	if user.IsForbidden {
 		return nil, &TokenError{
 			Error:            InvalidGrant,
@@ -26,6 +26,7 @@ import (
 	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/xorm-io/builder"
 	"github.com/xorm-io/core"
+	"github.com/xorm-io/xorm"
 )
 
 const (
@@ -35,6 +36,10 @@ const (
 
 const UserEnforcerId = "built-in/user-enforcer-built-in"
 
+// UserTypeLdapServiceAccount is the type of the bind-only accounts that LDAP clients use to search one organization,
+// they can't sign in or modify entries
+const UserTypeLdapServiceAccount = "ldap-service-account"
+
 var userEnforcer *UserGroupEnforcer
 
 func InitUserManager() {
@@ -59,6 +64,7 @@ type User struct {
 	Password          string   `xorm:"varchar(150)" json:"password"`
 	PasswordSalt      string   `xorm:"varchar(100)" json:"passwordSalt"`
 	PasswordType      string   `xorm:"varchar(100)" json:"passwordType"`
+	NtHash            string   `xorm:"varchar(100)" json:"ntHash"`
 	DisplayName       string   `xorm:"varchar(100)" json:"displayName"`
 	FirstName         string   `xorm:"varchar(100)" json:"firstName"`
 	LastName          string   `xorm:"varchar(100)" json:"lastName"`
@@ -281,13 +287,37 @@ func GetGlobalUsers() ([]*User, error) {
 	return users, nil
 }
 
-func GetGlobalUsersWithFilter(cond builder.Cond) ([]*User, error) {
-	users := []*User{}
-	session := ormer.Engine.Desc("created_time")
+// getUserPageSession returns the session of a page of the users matching cond, limit -1 means no limit. The pages are
+// ordered by the primary key and start after the user afterId instead of an offset, so that the users added or deleted
+// between the pages don't shift the pages
+func getUserPageSession(cond builder.Cond, afterId string, limit int) *xorm.Session {
+	if afterId == "" && limit == -1 {
+		session := ormer.Engine.Desc("created_time")
+		if cond != nil {
+			session = session.Where(cond)
+		}
+		return session
+	}
+
+	pageCond := builder.NewCond()
 	if cond != nil {
-		session = session.Where(cond)
+		pageCond = pageCond.And(cond)
 	}
-	err := session.Find(&users)
+	if afterId != "" {
+		owner, name := util.GetOwnerAndNameFromIdNoCheck(afterId)
+		pageCond = pageCond.And(builder.Or(builder.Gt{"owner": owner}, builder.And(builder.Eq{"owner": owner}, builder.Gt{"name": name})))
+	}
+
+	session := ormer.Engine.Asc("owner", "name").Where(pageCond)
+	if limit != -1 {
+		session = session.Limit(limit)
+	}
+	return session
+}
+
+func GetGlobalUsersWithFilter(cond builder.Cond, afterId string, limit int) ([]*User, error) {
+	users := []*User{}
+	err := getUserPageSession(cond, afterId, limit).Find(&users)
 	if err != nil {
 		return nil, err
 	}
@@ -330,13 +360,9 @@ func GetUsers(owner string) ([]*User, error) {
 	return users, nil
 }
 
-func GetUsersWithFilter(owner string, cond builder.Cond) ([]*User, error) {
+func GetUsersWithFilter(owner string, cond builder.Cond, afterId string, limit int) ([]*User, error) {
 	users := []*User{}
-	session := ormer.Engine.Desc("created_time")
-	if cond != nil {
-		session = session.Where(cond)
-	}
-	err := session.Find(&users, &User{Owner: owner})
+	err := getUserPageSession(cond, afterId, limit).Find(&users, &User{Owner: owner})
 	if err != nil {
 		return nil, err
 	}
@@ -344,13 +370,9 @@ func GetUsersWithFilter(owner string, cond builder.Cond) ([]*User, error) {
 	return users, nil
 }
 
-func GetUsersByTagWithFilter(owner string, tag string, cond builder.Cond) ([]*User, error) {
+func GetUsersByTagWithFilter(owner string, tag string, cond builder.Cond, afterId string, limit int) ([]*User, error) {
 	users := []*User{}
-	session := ormer.Engine.Desc("created_time")
-	if cond != nil {
-		session = session.Where(cond)
-	}
-	err := session.Find(&users, &User{Owner: owner, Tag: tag})
+	err := getUserPageSession(cond, afterId, limit).Find(&users, &User{Owner: owner, Tag: tag})
 	if err != nil {
 		return nil, err
 	}
@@ -601,6 +623,9 @@ func GetMaskedUser(user *User, isAdminOrSelf bool, errs ...error) (*User, error)
 	if user.Password != "" {
 		user.Password = "***"
 	}
+	if user.NtHash != "" {
+		user.NtHash = "***"
+	}
 
 	if !isAdminOrSelf {
 		if user.AccessSecret != "" {
@@ -660,6 +685,12 @@ func getLastUser(owner string) (*User, error) {
 }
 
 func UpdateUser(id string, user *User, columns []string, isAdmin bool) (bool, error) {
+	return UpdateUserWithPassword(id, user, columns, isAdmin, "")
+}
+
+// UpdateUserWithPassword updates the user like UpdateUser, the plain password is the new password of the user
+// if it's changed, for the LDAP servers with provisioning
+func UpdateUserWithPassword(id string, user *User, columns []string, isAdmin bool, password string) (bool, error) {
 	var err error
 	owner, name := util.GetOwnerAndNameFromIdNoCheck(id)
 	oldUser, err := getUser(owner, name)
@@ -680,6 +711,9 @@ func UpdateUser(id string, user *User, columns []string, isAdmin bool) (bool, er
 	if user.Password == "***" {
 		user.Password = oldUser.Password
 	}
+	if user.NtHash == "***" {
+		user.NtHash = oldUser.NtHash
+	}
 
 	if user.Id != oldUser.Id && user.Id == "" {
 		user.Id = oldUser.Id
@@ -730,6 +764,10 @@ func UpdateUser(id string, user *User, columns []string, isAdmin bool) (bool, er
 		return false, err
 	}
 
+	if affected != 0 {
+		userUpdatedTrigger(oldUser, user.Owner, user.Name, columns, password)
+	}
+
 	return affected != 0, nil
 }
 
@@ -771,6 +809,10 @@ func UpdateUserForAllFields(id string, user *User) (bool, error) {
 		return false, err
 	}
 
+	if user.NtHash == "***" {
+		user.NtHash = oldUser.NtHash
+	}
+
 	if user.Avatar != oldUser.Avatar && user.Avatar != "" {
 		user.PermanentAvatar, err = getPermanentAvatarUrl(user.Owner, user.Name, user.Avatar, false)
 		if err != nil {
@@ -785,6 +827,10 @@ func UpdateUserForAllFields(id string, user *User) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		userUpdatedTrigger(oldUser, user.Owner, user.Name, nil, "")
+	}
+
 	return affected != 0, nil
 }
 
@@ -819,7 +865,10 @@ func AddUser(user *User) (bool, error) {
 		user.Password = organization.DefaultPassword
 	}
 
+	// the plain password is pushed to the LDAP servers with provisioning before it's hashed
+	plainPassword := ""
 	if user.PasswordType == "" || user.PasswordType == "plain" {
+		plainPassword = user.Password
 		user.UpdateUserPassword(organization)
 	}
 
@@ -872,6 +921,10 @@ func AddUser(user *User) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		ProvisionLdapUser(nil, user, plainPassword)
+	}
+
 	return affected != 0, nil
 }
 
@@ -979,7 +1032,16 @@ func DeleteUser(user *User) (bool, error) {
 		user.DeletedTime = util.GetCurrentTime()
 		return UpdateUser(user.GetId(), user, []string{"is_deleted", "deleted_time"}, false)
 	} else {
-		return deleteUser(user)
+		affected, err := deleteUser(user)
+		if err != nil {
+			return false, err
+		}
+
+		if affected {
+			ProvisionLdapUser(user, nil, "")
+			updateRadiusAccountingsOfUser(user, nil)
+		}
+		return affected, nil
 	}
 }
 
@@ -1171,6 +1233,14 @@ func (user *User) IsApplicationAdmin(application *Application) bool {
 	return (user.Owner == application.Organization && user.IsAdmin) || user.IsGlobalAdmin() || (user.IsAdmin && application.IsShared)
 }
 
+func (user *User) IsLdapServiceAccount() bool {
+	if user == nil {
+		return false
+	}
+
+	return user.Type == UserTypeLdapServiceAccount
+}
+
 func (user *User) IsGlobalAdmin() bool {
 	if user == nil {
 		return false
@@ -14,7 +14,11 @@
 
 package object
 
-import "github.com/casdoor/casdoor/cred"
+import (
+	"encoding/hex"
+
+	"github.com/casdoor/casdoor/cred"
+)
 
 func calculateHash(user *User) (string, error) {
 	syncer, err := getDbSyncerForUser(user)
@@ -40,6 +44,8 @@ func (user *User) UpdateUserHash() error {
 }
 
 func (user *User) UpdateUserPassword(organization *Organization) {
+	user.UpdateUserNtHash(organization, user.Password)
+
 	credManager := cred.GetCredManager(organization.PasswordType)
 	if credManager != nil {
 		hashedPassword := credManager.GetHashedPassword(user.Password, user.PasswordSalt, organization.PasswordSalt)
@@ -47,3 +53,67 @@ func (user *User) UpdateUserPassword(organization *Organization) {
 		user.PasswordType = organization.PasswordType
 	}
 }
+
+// UpdateUserNtHash sets the NT hash of the plain password if the organization enables it, the hash is cleared
+// otherwise so that disabling it removes the hashes on the next password change
+func (user *User) UpdateUserNtHash(organization *Organization, password string) {
+	if organization.EnableNtHash && password != "" {
+		user.NtHash = cred.GetNtHash(password)
+	} else {
+		user.NtHash = ""
+	}
+}
+
+// SyncUserNtHash stores the NT hash of a password that has been checked, for the users whose password was set before
+// the organization enabled the NT hash
+func SyncUserNtHash(user *User, password string) error {
+	organization, err := GetOrganizationByUser(user)
+	if err != nil {
+		return err
+	}
+	if organization == nil || !organization.EnableNtHash || password == "" {
+		return nil
+	}
+
+	ntHash := cred.GetNtHash(password)
+	if user.NtHash == ntHash {
+		return nil
+	}
+
+	user.NtHash = ntHash
+	_, err = UpdateUser(user.GetId(), user, []string{"nt_hash"}, false)
+	return err
+}
+
+// GetPlainPassword returns the password of the user if it's stored in plain text, or "" otherwise
+func (user *User) GetPlainPassword() (string, error) {
+	passwordType := user.PasswordType
+	if passwordType == "" {
+		organization, err := GetOrganizationByUser(user)
+		if err != nil {
+			return "", err
+		}
+		if organization != nil {
+			passwordType = organization.PasswordType
+		}
+	}
+
+	if passwordType != "plain" {
+		return "", nil
+	}
+	return user.Password, nil
+}
+
+// GetNtHash returns the NT hash of the user for MS-CHAPv2, it's computed from the password if the password is stored
+// in plain text, or nil if the user has no NT hash
+func (user *User) GetNtHash() ([]byte, error) {
+	if user.NtHash != "" {
+		return hex.DecodeString(user.NtHash)
+	}
+
+	password, err := user.GetPlainPassword()
+	if err != nil || password == "" {
+		return nil, err
+	}
+	return hex.DecodeString(cred.GetNtHash(password))
+}
@@ -21,6 +21,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/beego/beego/logs"
 	"github.com/casdoor/casdoor/conf"
 	"github.com/casdoor/casdoor/i18n"
 	"github.com/casdoor/casdoor/idp"
@@ -99,6 +100,7 @@ func GetUserByFields(organization string, field string) (*User, error) {
 }
 
 func SetUserField(user *User, field string, value string) (bool, error) {
+	oldUser := user
 	bean := make(map[string]interface{})
 	if field == "password" {
 		organization, err := GetOrganizationByUser(user)
@@ -109,6 +111,7 @@ func SetUserField(user *User, field string, value string) (bool, error) {
 		user.UpdateUserPassword(organization)
 		bean[strings.ToLower(field)] = user.Password
 		bean["password_type"] = user.PasswordType
+		bean["nt_hash"] = user.NtHash
 	} else {
 		bean[strings.ToLower(field)] = value
 	}
@@ -137,6 +140,14 @@ func SetUserField(user *User, field string, value string) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		password := ""
+		if field == "password" {
+			password = value
+		}
+		ProvisionLdapUser(oldUser, user, password)
+	}
+
 	return affected != 0, nil
 }
 
@@ -689,3 +700,38 @@ func IsAppUser(userId string) bool {
 	}
 	return false
 }
+
+// userUpdatedTrigger provisions the user to LDAP and updates the RADIUS sessions with the user as stored after an
+// update, since the updated columns may not cover every field of the user. columns is nil if all the fields are
+// updated. The user is only loaded if it's provisioned or if the columns can change its RADIUS sessions.
+func userUpdatedTrigger(oldUser *User, owner string, name string, columns []string, password string) {
+	isProvisioned := false
+	if oldUser.Ldap == "" {
+		var err error
+		isProvisioned, err = hasProvisioningLdaps(owner)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("userUpdatedTrigger() error: %s", err.Error()))
+		}
+	}
+
+	isRadiusChanged := columns == nil || util.InSlice(columns, "groups") || util.InSlice(columns, "is_forbidden") || util.InSlice(columns, "is_deleted")
+	if !isProvisioned && !isRadiusChanged {
+		return
+	}
+
+	user, err := getUser(owner, name)
+	if err != nil {
+		logs.Warning(fmt.Sprintf("userUpdatedTrigger() error: %s", err.Error()))
+		return
+	}
+	if user == nil {
+		return
+	}
+
+	if isProvisioned {
+		ProvisionLdapUser(oldUser, user, password)
+	}
+	if isRadiusChanged {
+		updateRadiusAccountingsOfUser(oldUser, user)
+	}
+}
@@ -0,0 +1,407 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/google/uuid"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+// the "wa" actions of the WS-Federation passive requestor profile
+const (
+	WsFedSignIn         = "wsignin1.0"
+	WsFedSignOut        = "wsignout1.0"
+	WsFedSignOutCleanup = "wsignoutcleanup1.0"
+)
+
+const (
+	WsFedTokenTypeSaml11 = "SAML11"
+	WsFedTokenTypeSaml20 = "SAML20"
+)
+
+const (
+	wsFedNamespace      = "http://docs.oasis-open.org/wsfed/federation/200706"
+	wsTrustNamespace    = "http://schemas.xmlsoap.org/ws/2005/02/trust"
+	wsPolicyNamespace   = "http://schemas.xmlsoap.org/ws/2004/09/policy"
+	wsAddressNamespace  = "http://www.w3.org/2005/08/addressing"
+	wsUtilityNamespace  = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd"
+	wsClaimNamespace    = "http://schemas.xmlsoap.org/ws/2005/05/identity/claims"
+	wsRoleClaimType     = "http://schemas.microsoft.com/ws/2008/06/identity/claims/role"
+	wsAuthNamespace     = "http://docs.oasis-open.org/wsfed/authorization/200706"
+	saml11AssertionType = "urn:oasis:names:tc:SAML:1.0:assertion"
+	saml20AssertionType = "urn:oasis:names:tc:SAML:2.0:assertion"
+)
+
+type wsFedClaim struct {
+	Type   string
+	Values []string
+}
+
+// getWsFedReplyUrl returns the relying party registered for realm (nil if not registered) and the URL to post the token to.
+// A registered relying party only accepts its ACS URLs, otherwise wreply (or the realm) must be a redirect URI of the application.
+func getWsFedReplyUrl(application *Application, realm string, reply string) (*SamlServiceProvider, string, error) {
+	sp := application.GetSamlServiceProvider(realm)
+	if sp != nil {
+		acsUrl, err := sp.getAcsUrl(reply)
+		if err != nil {
+			return nil, "", err
+		}
+		return sp, acsUrl.Url, nil
+	}
+
+	if reply == "" {
+		reply = realm
+	}
+
+	if !application.IsRedirectUriValid(reply) {
+		return nil, "", fmt.Errorf("the WS-Federation reply URL: %s is not allowed by the application: %s", reply, application.GetId())
+	}
+	return nil, reply, nil
+}
+
+// GetWsFedSignOutReplyUrl returns wreply if the user agent can be redirected to it after signing out, otherwise ""
+func GetWsFedSignOutReplyUrl(application *Application, reply string) string {
+	if reply == "" {
+		return ""
+	}
+
+	if application.IsRedirectUriValid(reply) {
+		return reply
+	}
+
+	for _, sp := range application.SamlServiceProviders {
+		if _, err := sp.getAcsUrl(reply); err == nil {
+			return reply
+		}
+	}
+	return ""
+}
+
+// getWsFedClaims returns the claims of the user issued to WS-Federation relying parties
+func getWsFedClaims(user *User, sp *SamlServiceProvider) ([]*wsFedClaim, error) {
+	claims := []*wsFedClaim{
+		{Type: fmt.Sprintf("%s/name", wsClaimNamespace), Values: []string{user.Name}},
+		{Type: fmt.Sprintf("%s/emailaddress", wsClaimNamespace), Values: []string{user.Email}},
+		{Type: fmt.Sprintf("%s/givenname", wsClaimNamespace), Values: []string{user.FirstName}},
+		{Type: fmt.Sprintf("%s/surname", wsClaimNamespace), Values: []string{user.LastName}},
+	}
+
+	err := ExtendUserWithRolesAndPermissions(user)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := &wsFedClaim{Type: wsRoleClaimType}
+	for _, role := range user.Roles {
+		roles.Values = append(roles.Values, role.Name)
+	}
+	claims = append(claims, roles)
+
+	if sp != nil {
+		attributeNames := []string{}
+		for attributeName := range sp.AttributeMapping {
+			attributeNames = append(attributeNames, attributeName)
+		}
+		sort.Strings(attributeNames)
+
+		for _, attributeName := range attributeNames {
+			value, err := getSamlMappedAttributeValue(user, sp.AttributeMapping[attributeName])
+			if err != nil {
+				return nil, err
+			}
+			claims = append(claims, &wsFedClaim{Type: attributeName, Values: []string{value}})
+		}
+	}
+
+	res := []*wsFedClaim{}
+	for _, claim := range claims {
+		values := []string{}
+		for _, value := range claim.Values {
+			if value != "" {
+				values = append(values, value)
+			}
+		}
+		if len(values) != 0 {
+			res = append(res, &wsFedClaim{Type: claim.Type, Values: values})
+		}
+	}
+	return res, nil
+}
+
+// newWsFedSaml11Assertion turns the assertion of NewSamlResponse11 into a bearer assertion for the relying party:
+// it's restricted to the realm and carries the WS-Federation claims
+func newWsFedSaml11Assertion(application *Application, user *User, sp *SamlServiceProvider, realm string, issuer string) (*etree.Element, error) {
+	samlResponse, err := NewSamlResponse11(application, user, "", issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	assertion := samlResponse.FindElement("./Assertion")
+	samlResponse.RemoveChild(assertion)
+
+	// xs:ID values can't start with a digit
+	assertion.CreateAttr("AssertionID", fmt.Sprintf("_%s", uuid.New()))
+
+	conditions := assertion.FindElement("./Conditions")
+	conditions.CreateElement("saml:AudienceRestrictionCondition").CreateElement("saml:Audience").SetText(realm)
+
+	for _, confirmationMethod := range assertion.FindElements(".//ConfirmationMethod") {
+		confirmationMethod.SetText("urn:oasis:names:tc:SAML:1.0:cm:bearer")
+	}
+
+	// the SAML 1.1 schema requires the subject to be inside the authentication statement
+	subject := assertion.FindElement("./Subject")
+	assertion.RemoveChild(subject)
+	assertion.FindElement("./AuthenticationStatement").AddChild(subject)
+
+	attributeStatement := assertion.FindElement("./AttributeStatement")
+	for _, attribute := range attributeStatement.SelectElements("Attribute") {
+		attributeStatement.RemoveChild(attribute)
+	}
+
+	claims, err := getWsFedClaims(user, sp)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, claim := range claims {
+		// SAML 1.1 attributes are identified by a namespace and a name, e.g. ".../claims" and "emailaddress"
+		namespace, name := "", claim.Type
+		if i := strings.LastIndex(claim.Type, "/"); i != -1 {
+			namespace, name = claim.Type[:i], claim.Type[i+1:]
+		}
+
+		attribute := attributeStatement.CreateElement("saml:Attribute")
+		attribute.CreateAttr("AttributeName", name)
+		attribute.CreateAttr("AttributeNamespace", namespace)
+		for _, value := range claim.Values {
+			attribute.CreateElement("saml:AttributeValue").SetText(value)
+		}
+	}
+
+	return assertion, nil
+}
+
+// newWsFedSaml20Assertion returns the assertion of NewSamlResponse restricted to the realm
+func newWsFedSaml20Assertion(application *Application, user *User, sp *SamlServiceProvider, realm string, replyUrl string, issuer string, certificate string) (*etree.Element, error) {
+	samlResponse, err := NewSamlResponse(application, user, issuer, certificate, replyUrl, realm, "", nil, sp)
+	if err != nil {
+		return nil, err
+	}
+
+	assertion := samlResponse.FindElement("./Assertion")
+	samlResponse.RemoveChild(assertion)
+	assertion.CreateAttr("xmlns:saml", saml20AssertionType)
+
+	attributeStatement := assertion.FindElement("./AttributeStatement")
+	claims, err := getWsFedClaims(user, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, claim := range claims {
+		attribute := attributeStatement.CreateElement("saml:Attribute")
+		attribute.CreateAttr("Name", claim.Type)
+		attribute.CreateAttr("NameFormat", "urn:oasis:names:tc:SAML:2.0:attrname-format:uri")
+		for _, value := range claim.Values {
+			attribute.CreateElement("saml:AttributeValue").CreateAttr("xsi:type", "xs:string").Element().SetText(value)
+		}
+	}
+
+	return assertion, nil
+}
+
+// signWsFedElement signs the element with the exclusive canonicalization, so that the signature
+// stays valid after the element is embedded into another document, e.g. the RequestSecurityTokenResponse.
+// The signature is inserted as the child at index, or appended if index is negative.
+func signWsFedElement(element *etree.Element, idAttribute string, index int, cert *Cert, certificate string) error {
+	ctx := dsig.NewDefaultSigningContext(&X509Key{
+		PrivateKey:      cert.PrivateKey,
+		X509Certificate: certificate,
+	})
+	ctx.Hash = crypto.SHA256
+	ctx.IdAttribute = idAttribute
+	ctx.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("")
+
+	sig, err := ctx.ConstructSignature(element, true)
+	if err != nil {
+		return fmt.Errorf("err: Failed to sign the WS-Federation element, %s", err.Error())
+	}
+
+	if index < 0 {
+		element.AddChild(sig)
+	} else {
+		element.InsertChildAt(index, sig)
+	}
+	return nil
+}
+
+// newWsFedRstr wraps the signed assertion into a RequestSecurityTokenResponse for the realm
+func newWsFedRstr(assertion *etree.Element, realm string, tokenType string) *etree.Element {
+	rstr := etree.NewElement("t:RequestSecurityTokenResponse")
+	rstr.CreateAttr("xmlns:t", wsTrustNamespace)
+
+	conditions := assertion.FindElement("./Conditions")
+	lifetime := rstr.CreateElement("t:Lifetime")
+	created := lifetime.CreateElement("wsu:Created")
+	created.CreateAttr("xmlns:wsu", wsUtilityNamespace)
+	created.SetText(conditions.SelectAttrValue("NotBefore", ""))
+	expires := lifetime.CreateElement("wsu:Expires")
+	expires.CreateAttr("xmlns:wsu", wsUtilityNamespace)
+	expires.SetText(conditions.SelectAttrValue("NotOnOrAfter", ""))
+
+	appliesTo := rstr.CreateElement("wsp:AppliesTo")
+	appliesTo.CreateAttr("xmlns:wsp", wsPolicyNamespace)
+	endpointReference := appliesTo.CreateElement("wsa:EndpointReference")
+	endpointReference.CreateAttr("xmlns:wsa", wsAddressNamespace)
+	endpointReference.CreateElement("wsa:Address").SetText(realm)
+
+	rstr.CreateElement("t:RequestedSecurityToken").AddChild(assertion)
+	rstr.CreateElement("t:TokenType").SetText(tokenType)
+	rstr.CreateElement("t:RequestType").SetText(fmt.Sprintf("%s/Issue", wsTrustNamespace))
+	rstr.CreateElement("t:KeyType").SetText("http://schemas.xmlsoap.org/ws/2005/05/identity/NoProofKey")
+	return rstr
+}
+
+// GetWsFedSignInResponse handles a wsignin1.0 request of the relying party whose realm is realm,
+// returns the wresult (a RequestSecurityTokenResponse containing a signed SAML 1.1 or 2.0 assertion)
+// and the reply URL to post it to
+func GetWsFedSignInResponse(application *Application, user *User, realm string, reply string, host string) (string, string, error) {
+	if realm == "" {
+		return "", "", fmt.Errorf("the WS-Federation parameter: wtrealm should not be empty")
+	}
+
+	sp, replyUrl, err := getWsFedReplyUrl(application, realm, reply)
+	if err != nil {
+		return "", "", err
+	}
+
+	cert, certificate, err := getSamlCertificate(application)
+	if err != nil {
+		return "", "", err
+	}
+
+	_, originBackend := getOriginFromHost(host)
+
+	var rstr *etree.Element
+	if sp != nil && sp.WsFedTokenType == WsFedTokenTypeSaml20 {
+		assertion, err := newWsFedSaml20Assertion(application, user, sp, realm, replyUrl, originBackend, certificate)
+		if err != nil {
+			return "", "", err
+		}
+
+		// the signature of a SAML 2.0 assertion follows the issuer
+		err = signWsFedElement(assertion, "ID", 1, cert, certificate)
+		if err != nil {
+			return "", "", err
+		}
+		rstr = newWsFedRstr(assertion, realm, saml20AssertionType)
+	} else {
+		assertion, err := newWsFedSaml11Assertion(application, user, sp, realm, originBackend)
+		if err != nil {
+			return "", "", err
+		}
+
+		err = signWsFedElement(assertion, "AssertionID", -1, cert, certificate)
+		if err != nil {
+			return "", "", err
+		}
+		rstr = newWsFedRstr(assertion, realm, saml11AssertionType)
+	}
+
+	doc := etree.NewDocument()
+	doc.SetRoot(rstr)
+	wresult, err := doc.WriteToString()
+	if err != nil {
+		return "", "", err
+	}
+	return wresult, replyUrl, nil
+}
+
+func getWsFedEndpoint(application *Application, originBackend string) string {
+	return fmt.Sprintf("%s/api/wsfed/%s/%s", originBackend, application.Owner, application.Name)
+}
+
+// GetWsFedMetadata returns the signed federation metadata of the application as a WS-Federation security token service
+func GetWsFedMetadata(application *Application, host string) (string, error) {
+	cert, certificate, err := getSamlCertificate(application)
+	if err != nil {
+		return "", err
+	}
+
+	_, originBackend := getOriginFromHost(host)
+	endpoint := getWsFedEndpoint(application, originBackend)
+
+	entityDescriptor := etree.NewElement("EntityDescriptor")
+	entityDescriptor.CreateAttr("xmlns", "urn:oasis:names:tc:SAML:2.0:metadata")
+	entityDescriptor.CreateAttr("ID", fmt.Sprintf("_%s", uuid.New()))
+	entityDescriptor.CreateAttr("entityID", originBackend)
+
+	roleDescriptor := entityDescriptor.CreateElement("RoleDescriptor")
+	roleDescriptor.CreateAttr("xmlns:xsi", "http://www.w3.org/2001/XMLSchema-instance")
+	roleDescriptor.CreateAttr("xmlns:fed", wsFedNamespace)
+	roleDescriptor.CreateAttr("xsi:type", "fed:SecurityTokenServiceType")
+	roleDescriptor.CreateAttr("protocolSupportEnumeration", wsFedNamespace)
+
+	keyDescriptor := roleDescriptor.CreateElement("KeyDescriptor")
+	keyDescriptor.CreateAttr("use", "signing")
+	keyInfo := keyDescriptor.CreateElement("KeyInfo")
+	keyInfo.CreateAttr("xmlns", "http://www.w3.org/2000/09/xmldsig#")
+	keyInfo.CreateElement("X509Data").CreateElement("X509Certificate").SetText(certificate)
+
+	claimTypesOffered := roleDescriptor.CreateElement("fed:ClaimTypesOffered")
+	claimTypes := []string{
+		fmt.Sprintf("%s/name", wsClaimNamespace),
+		fmt.Sprintf("%s/emailaddress", wsClaimNamespace),
+		fmt.Sprintf("%s/givenname", wsClaimNamespace),
+		fmt.Sprintf("%s/surname", wsClaimNamespace),
+		wsRoleClaimType,
+	}
+	for _, claimType := range claimTypes {
+		claimTypeElement := claimTypesOffered.CreateElement("auth:ClaimType")
+		claimTypeElement.CreateAttr("xmlns:auth", wsAuthNamespace)
+		claimTypeElement.CreateAttr("Uri", claimType)
+		claimTypeElement.CreateAttr("Optional", "true")
+	}
+
+	for _, tag := range []string{"fed:SecurityTokenServiceEndpoint", "fed:PassiveRequestorEndpoint"} {
+		endpointReference := roleDescriptor.CreateElement(tag).CreateElement("wsa:EndpointReference")
+		endpointReference.CreateAttr("xmlns:wsa", wsAddressNamespace)
+		endpointReference.CreateElement("wsa:Address").SetText(endpoint)
+	}
+
+	// the signature is the first child of the entity descriptor
+	err = signWsFedElement(entityDescriptor, "ID", 0, cert, certificate)
+	if err != nil {
+		return "", err
+	}
+
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+	doc.SetRoot(entityDescriptor)
+	return doc.WriteToString()
+}
+
+// GetWsFedRedirectAddress returns the login page that continues the WS-Federation sign-in after the user signs in
+func GetWsFedRedirectAddress(application *Application, query string, host string) string {
+	originFrontend, _ := getOriginFromHost(host)
+	return fmt.Sprintf("%s/login/wsfed/authorize/%s/%s?%s", originFrontend, application.Owner, application.Name, query)
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWsFedReplyUrl(t *testing.T) {
+	application := &Application{
+		Owner:        "admin",
+		Name:         "app-wsfed",
+		RedirectUris: []string{"https://rp.example.com/"},
+		SamlServiceProviders: []*SamlServiceProvider{
+			{
+				EntityId: "urn:sharepoint:example",
+				AcsUrls:  []*SamlAcsUrl{{Url: "https://sharepoint.example.com/_trust/", IsDefault: true}},
+			},
+		},
+	}
+
+	sp, replyUrl, err := getWsFedReplyUrl(application, "urn:sharepoint:example", "")
+	assert.Nil(t, err)
+	assert.NotNil(t, sp)
+	assert.Equal(t, "https://sharepoint.example.com/_trust/", replyUrl)
+
+	_, _, err = getWsFedReplyUrl(application, "urn:sharepoint:example", "https://evil.example.com/")
+	assert.NotNil(t, err)
+
+	sp, replyUrl, err = getWsFedReplyUrl(application, "https://rp.example.com/", "")
+	assert.Nil(t, err)
+	assert.Nil(t, sp)
+	assert.Equal(t, "https://rp.example.com/", replyUrl)
+
+	_, _, err = getWsFedReplyUrl(application, "https://rp.example.com/", "https://evil.example.com/")
+	assert.NotNil(t, err)
+
+	assert.Equal(t, "https://sharepoint.example.com/_trust/", GetWsFedSignOutReplyUrl(application, "https://sharepoint.example.com/_trust/"))
+	assert.Equal(t, "", GetWsFedSignOutReplyUrl(application, "https://evil.example.com/"))
+}
+
+func TestWsFedRstrSignature(t *testing.T) {
+	certificate, privateKey, err := generateRsaKeys(2048, 256, 1, "Casdoor Cert", "Casdoor Organization")
+	assert.Nil(t, err)
+
+	block, _ := pem.Decode([]byte(certificate))
+	cert := &Cert{Certificate: certificate, PrivateKey: privateKey}
+
+	assertion := etree.NewElement("saml:Assertion")
+	assertion.CreateAttr("xmlns:saml", "urn:oasis:names:tc:SAML:1.0:assertion")
+	assertion.CreateAttr("AssertionID", "_assertion")
+	conditions := assertion.CreateElement("saml:Conditions")
+	conditions.CreateAttr("NotBefore", "2025-01-01T00:00:00Z")
+	conditions.CreateAttr("NotOnOrAfter", "2025-01-02T00:00:00Z")
+
+	err = signWsFedElement(assertion, "AssertionID", -1, cert, base64.StdEncoding.EncodeToString(block.Bytes))
+	assert.Nil(t, err)
+
+	rstr := newWsFedRstr(assertion, "urn:rp", saml11AssertionType)
+	doc := etree.NewDocument()
+	doc.SetRoot(rstr)
+	wresult, err := doc.WriteToString()
+	assert.Nil(t, err)
+
+	// the relying party verifies the assertion inside the RequestSecurityTokenResponse
+	parsed := etree.NewDocument()
+	err = parsed.ReadFromString(wresult)
+	assert.Nil(t, err)
+	assert.Equal(t, "urn:rp", parsed.FindElement("//AppliesTo/EndpointReference/Address").Text())
+	assert.Equal(t, "2025-01-02T00:00:00Z", parsed.FindElement("//Lifetime/Expires").Text())
+
+	x509Cert, err := x509.ParseCertificate(block.Bytes)
+	assert.Nil(t, err)
+
+	ctx := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: []*x509.Certificate{x509Cert}})
+	ctx.IdAttribute = "AssertionID"
+	_, err = ctx.Validate(parsed.FindElement("//RequestedSecurityToken/Assertion"))
+	assert.Nil(t, err)
+}
@@ -0,0 +1,480 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package radius
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"log"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+	"layeh.com/radius"
+	"layeh.com/radius/rfc2865"
+	"layeh.com/radius/rfc2869"
+)
+
+// EAP codes and method types, see RFC 3748
+const (
+	eapCodeRequest  = 1
+	eapCodeResponse = 2
+	eapCodeSuccess  = 3
+	eapCodeFailure  = 4
+
+	eapTypeIdentity = 1
+	eapTypeNak      = 3
+	eapTypeGtc      = 6
+	eapTypeMsChapV2 = 26
+)
+
+// the op codes of EAP-MSCHAPv2, see draft-kamath-pppext-eap-mschapv2
+const (
+	msChapV2OpCodeChallenge = 1
+	msChapV2OpCodeResponse  = 2
+	msChapV2OpCodeSuccess   = 3
+)
+
+const eapServerName = "casdoor"
+
+type eapPacket struct {
+	Code       byte
+	Identifier byte
+	Type       byte
+	Data       []byte
+}
+
+func parseEapPacket(b []byte) (*eapPacket, error) {
+	if len(b) < 4 {
+		return nil, fmt.Errorf("the EAP packet is too short")
+	}
+
+	length := int(binary.BigEndian.Uint16(b[2:4]))
+	if length < 4 || length > len(b) {
+		return nil, fmt.Errorf("the EAP packet length: %d is invalid", length)
+	}
+
+	p := &eapPacket{Code: b[0], Identifier: b[1]}
+	if p.Code == eapCodeRequest || p.Code == eapCodeResponse {
+		if length < 5 {
+			return nil, fmt.Errorf("the EAP packet has no type")
+		}
+		p.Type = b[4]
+		p.Data = b[5:length]
+	}
+	return p, nil
+}
+
+func (p *eapPacket) encode() []byte {
+	if p.Code == eapCodeSuccess || p.Code == eapCodeFailure {
+		return []byte{p.Code, p.Identifier, 0, 4}
+	}
+
+	b := make([]byte, 5, 5+len(p.Data))
+	b[0] = p.Code
+	b[1] = p.Identifier
+	b[4] = p.Type
+	b = append(b, p.Data...)
+	binary.BigEndian.PutUint16(b[2:4], uint16(len(b)))
+	return b
+}
+
+// eapSession is the state of an EAP conversation, it's kept in the StateMap between the Access-Challenges
+type eapSession struct {
+	Username      string
+	Methods       []byte
+	Method        byte
+	Identifier    byte
+	AuthChallenge []byte
+	UserId        string
+	IsVerified    bool
+	MfaType       string
+	Reply         *msChapReply
+}
+
+// getEapMethods returns the EAP methods of the user in the order of preference, MS-CHAPv2 needs the NT hash and
+// can't be followed by the OTP, so the users with MFA use GTC for the password and then the OTP
+func getEapMethods(user *object.User) ([]byte, error) {
+	if user.IsMfaEnabled() {
+		return []byte{eapTypeGtc}, nil
+	}
+
+	ntHash, err := user.GetNtHash()
+	if err != nil {
+		return nil, err
+	}
+	if ntHash == nil {
+		return []byte{eapTypeGtc}, nil
+	}
+	return []byte{eapTypeMsChapV2, eapTypeGtc}, nil
+}
+
+// newMsChapV2Data returns the data of an EAP-MSCHAPv2 packet, the MS-Length covers the whole data
+func newMsChapV2Data(opCode byte, identifier byte, value []byte) []byte {
+	data := make([]byte, 4, 4+len(value))
+	data[0] = opCode
+	data[1] = identifier
+	data = append(data, value...)
+	binary.BigEndian.PutUint16(data[2:4], uint16(len(data)))
+	return data
+}
+
+// getMessageAuthenticator returns the HMAC-MD5 of the packet with a zero Message-Authenticator, see RFC 3579, 3.2
+func getMessageAuthenticator(p *radius.Packet) ([]byte, error) {
+	attributes := make(radius.Attributes, 0, len(p.Attributes))
+	for _, avp := range p.Attributes {
+		if avp.Type == rfc2869.MessageAuthenticator_Type {
+			avp = &radius.AVP{Type: avp.Type, Attribute: make(radius.Attribute, md5.Size)}
+		}
+		attributes = append(attributes, avp)
+	}
+
+	q := *p
+	q.Attributes = attributes
+	b, err := q.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(md5.New, p.Secret)
+	mac.Write(b)
+	return mac.Sum(nil), nil
+}
+
+func isMessageAuthenticatorValid(p *radius.Packet) bool {
+	value := rfc2869.MessageAuthenticator_Get(p)
+	if len(value) != md5.Size {
+		return false
+	}
+
+	expected, err := getMessageAuthenticator(p)
+	return err == nil && hmac.Equal(value, expected)
+}
+
+// setMessageAuthenticator adds the Message-Authenticator to a response, the response has the authenticator of the
+// request until it's encoded, which is the one the HMAC is computed with
+func setMessageAuthenticator(p *radius.Packet) error {
+	err := rfc2869.MessageAuthenticator_Set(p, make([]byte, md5.Size))
+	if err != nil {
+		return err
+	}
+
+	value, err := getMessageAuthenticator(p)
+	if err != nil {
+		return err
+	}
+	return rfc2869.MessageAuthenticator_Set(p, value)
+}
+
+func writeEapResponse(w radius.ResponseWriter, r *radius.Request, code radius.Code, eap *eapPacket, state string, addAttributes func(p *radius.Packet) error) {
+	response := r.Response(code)
+
+	var err error
+	if state != "" {
+		err = rfc2865.State_SetString(response, state)
+	}
+	if err == nil && addAttributes != nil {
+		err = addAttributes(response)
+	}
+	if err == nil {
+		err = rfc2869.EAPMessage_Set(response, eap.encode())
+	}
+	if err == nil {
+		err = setMessageAuthenticator(response)
+	}
+	if err != nil {
+		log.Printf("writeEapResponse() failed, err = %v", err)
+		response = r.Response(radius.CodeAccessReject)
+	}
+
+	w.Write(response)
+}
+
+func rejectEap(w radius.ResponseWriter, r *radius.Request, identifier byte) {
+	writeEapResponse(w, r, radius.CodeAccessReject, &eapPacket{Code: eapCodeFailure, Identifier: identifier}, "", nil)
+}
+
+func acceptEap(w radius.ResponseWriter, r *radius.Request, radiusClient *object.RadiusClient, user *object.User, identifier byte, reply *msChapReply) {
+	writeEapResponse(w, r, radius.CodeAccessAccept, &eapPacket{Code: eapCodeSuccess, Identifier: identifier}, "", func(p *radius.Packet) error {
+		return addAcceptAttributes(p, radiusClient, user, reply)
+	})
+}
+
+// challengeEap sends the next EAP request of the session in an Access-Challenge with a new State
+func challengeEap(w radius.ResponseWriter, r *radius.Request, session *eapSession, data []byte) {
+	state, err := putState(&AccessStateContent{Eap: session})
+	if err != nil {
+		log.Printf("challengeEap() failed, err = %v", err)
+		rejectEap(w, r, session.Identifier)
+		return
+	}
+
+	eap := &eapPacket{Code: eapCodeRequest, Identifier: session.Identifier, Type: session.Method, Data: data}
+	writeEapResponse(w, r, radius.CodeAccessChallenge, eap, state, nil)
+}
+
+func getEapSession(state string) *eapSession {
+	stateContent, err := StateMap.Take(state)
+	if err != nil {
+		log.Printf("getEapSession() failed, err = %v", err)
+	}
+	if stateContent == nil {
+		return nil
+	}
+	return stateContent.Eap
+}
+
+// handleEapRequest handles the Access-Requests with an EAP-Message. The NAS relays the EAP conversation: the identity
+// of the peer starts the session, then EAP-MSCHAPv2 or EAP-GTC runs in Access-Challenges. The TLS tunnel of PEAP or
+// EAP-TTLS isn't terminated here, these methods can be the inner methods of a tunnel terminated by the NAS or a proxy.
+func handleEapRequest(w radius.ResponseWriter, r *radius.Request, radiusClient *object.RadiusClient, organization string) {
+	// the requests without a valid Message-Authenticator are silently discarded, see RFC 3579, 3.2
+	if !isMessageAuthenticatorValid(r.Packet) {
+		log.Printf("handleEapRequest() the Message-Authenticator is invalid, the request is discarded")
+		return
+	}
+
+	eap, err := parseEapPacket(rfc2869.EAPMessage_Get(r.Packet))
+	if err != nil || eap.Code != eapCodeResponse {
+		log.Printf("handleEapRequest() the EAP-Message is invalid, err = %v", err)
+		return
+	}
+
+	state := rfc2865.State_GetString(r.Packet)
+	if state == "" {
+		if eap.Type != eapTypeIdentity {
+			rejectEap(w, r, eap.Identifier)
+			return
+		}
+
+		username := rfc2865.UserName_GetString(r.Packet)
+		if username == "" {
+			username = string(eap.Data)
+		}
+		startEapSession(w, r, organization, username, eap.Identifier)
+		return
+	}
+
+	session := getEapSession(state)
+	if session == nil || eap.Identifier != session.Identifier {
+		rejectEap(w, r, eap.Identifier)
+		return
+	}
+
+	if eap.Type == eapTypeNak && !session.IsVerified {
+		for _, method := range eap.Data {
+			if method != session.Method && bytes.IndexByte(session.Methods, method) >= 0 {
+				startEapMethod(w, r, session, method)
+				return
+			}
+		}
+		rejectEap(w, r, eap.Identifier)
+		return
+	}
+
+	if eap.Type != session.Method {
+		rejectEap(w, r, eap.Identifier)
+		return
+	}
+
+	switch session.Method {
+	case eapTypeMsChapV2:
+		handleEapMsChapV2(w, r, radiusClient, organization, session, eap)
+	case eapTypeGtc:
+		handleEapGtc(w, r, radiusClient, organization, session, eap)
+	default:
+		rejectEap(w, r, eap.Identifier)
+	}
+}
+
+func startEapSession(w radius.ResponseWriter, r *radius.Request, organization string, username string, identifier byte) {
+	user, err := object.GetUserByFields(organization, username)
+	if err != nil || user == nil || user.IsDeleted || user.IsForbidden {
+		log.Printf("startEapSession() the user: %s is not allowed, err = %v", util.GetId(organization, username), err)
+		rejectEap(w, r, identifier)
+		return
+	}
+
+	methods, err := getEapMethods(user)
+	if err != nil {
+		log.Printf("startEapSession() failed, err = %v", err)
+		rejectEap(w, r, identifier)
+		return
+	}
+
+	session := &eapSession{
+		Username:   username,
+		Methods:    methods,
+		Identifier: identifier,
+	}
+	startEapMethod(w, r, session, methods[0])
+}
+
+func startEapMethod(w radius.ResponseWriter, r *radius.Request, session *eapSession, method byte) {
+	session.Method = method
+	session.Identifier++
+
+	var data []byte
+	switch method {
+	case eapTypeMsChapV2:
+		session.AuthChallenge = make([]byte, 16)
+		if _, err := rand.Read(session.AuthChallenge); err != nil {
+			rejectEap(w, r, session.Identifier)
+			return
+		}
+
+		value := append([]byte{byte(len(session.AuthChallenge))}, session.AuthChallenge...)
+		value = append(value, eapServerName...)
+		data = newMsChapV2Data(msChapV2OpCodeChallenge, session.Identifier, value)
+	case eapTypeGtc:
+		data = []byte("Password: ")
+	}
+
+	challengeEap(w, r, session, data)
+}
+
+// handleEapMsChapV2 checks the response of the peer and sends the authenticator response, the Access-Accept with the
+// MPPE keys is sent when the peer acknowledges it
+func handleEapMsChapV2(w radius.ResponseWriter, r *radius.Request, radiusClient *object.RadiusClient, organization string, session *eapSession, eap *eapPacket) {
+	if len(eap.Data) == 0 {
+		rejectEap(w, r, eap.Identifier)
+		return
+	}
+
+	opCode := eap.Data[0]
+	if session.IsVerified {
+		user, err := object.GetUser(session.UserId)
+		if err == nil && user == nil {
+			err = fmt.Errorf("the user: %s doesn't exist", session.UserId)
+		}
+		if err != nil || opCode != msChapV2OpCodeSuccess {
+			log.Printf("handleEapMsChapV2() failed, err = %v", err)
+			rejectEap(w, r, eap.Identifier)
+			return
+		}
+
+		acceptEap(w, r, radiusClient, user, eap.Identifier, session.Reply)
+		return
+	}
+
+	// the response is the peer challenge, 8 reserved bytes, the NT response and the flags, followed by the name
+	if opCode != msChapV2OpCodeResponse || len(eap.Data) < 54 || eap.Data[4] != 49 {
+		rejectEap(w, r, eap.Identifier)
+		return
+	}
+
+	peerChallenge := eap.Data[5:21]
+	ntResponse := eap.Data[29:53]
+	name := getMsChapUsername(string(eap.Data[54:]))
+
+	var reply *msChapReply
+	user, err := object.CheckUserCredential(organization, session.Username, "en", func(user *object.User) (bool, error) {
+		var err error
+		reply, err = verifyMsChap2(user, session.AuthChallenge, peerChallenge, ntResponse, []byte(name))
+		return reply != nil, err
+	})
+	if err == nil {
+		err = checkAccessUser(radiusClient, user)
+	}
+	if err != nil {
+		log.Printf("handleEapMsChapV2() failed, err = %v", err)
+		rejectEap(w, r, eap.Identifier)
+		return
+	}
+
+	session.UserId = user.GetId()
+	session.IsVerified = true
+	session.Reply = &msChapReply{SendKey: reply.SendKey, RecvKey: reply.RecvKey}
+	session.Identifier++
+
+	message := append(reply.Success, " M=OK"...)
+	challengeEap(w, r, session, newMsChapV2Data(msChapV2OpCodeSuccess, eap.Data[1], message))
+}
+
+// challengeEapMfa sends the prompt of the MFA type in the next EAP-GTC request, the code of an SMS or email MFA is sent
+// to the user first
+func challengeEapMfa(w radius.ResponseWriter, r *radius.Request, radiusClient *object.RadiusClient, user *object.User, session *eapSession, mfaType string) {
+	prompt, err := startRadiusMfa(r, radiusClient, user, mfaType)
+	if err != nil {
+		log.Printf("challengeEapMfa() failed, err = %v", err)
+		rejectEap(w, r, session.Identifier)
+		return
+	}
+
+	session.UserId = user.GetId()
+	session.IsVerified = true
+	session.MfaType = mfaType
+	session.Identifier++
+	challengeEap(w, r, session, []byte(prompt+": "))
+}
+
+// handleEapGtc checks the password of the peer, and then the OTP if the user enables MFA
+func handleEapGtc(w radius.ResponseWriter, r *radius.Request, radiusClient *object.RadiusClient, organization string, session *eapSession, eap *eapPacket) {
+	response := string(eap.Data)
+
+	if session.IsVerified {
+		user, err := object.GetUser(session.UserId)
+		if err == nil && user == nil {
+			err = fmt.Errorf("the user: %s doesn't exist", session.UserId)
+		}
+		if err == nil {
+			if mfaType := getChosenRadiusMfaType(user, session.MfaType, response); mfaType != "" {
+				challengeEapMfa(w, r, radiusClient, user, session, mfaType)
+				return
+			}
+
+			err = verifyRadiusOtp(user, session.MfaType, response)
+		}
+		if err != nil {
+			log.Printf("handleEapGtc() failed, err = %v", err)
+			rejectEap(w, r, eap.Identifier)
+			return
+		}
+
+		acceptEap(w, r, radiusClient, user, eap.Identifier, nil)
+		return
+	}
+
+	user, err := object.CheckUserPassword(organization, session.Username, response, "en")
+	if err == nil {
+		err = checkAccessUser(radiusClient, user)
+	}
+	var mfaTypes []string
+	if err == nil && user.IsMfaEnabled() {
+		mfaTypes = getRadiusMfaTypes(user)
+		if len(mfaTypes) == 0 {
+			err = fmt.Errorf("the user: %s has no MFA for RADIUS", user.GetId())
+		}
+	}
+	if err != nil {
+		log.Printf("handleEapGtc() failed, err = %v", err)
+		rejectEap(w, r, eap.Identifier)
+		return
+	}
+
+	if err = object.SyncUserNtHash(user, response); err != nil {
+		log.Printf("handleEapGtc() failed to sync the NT hash, err = %v", err)
+	}
+
+	if len(mfaTypes) != 0 {
+		challengeEapMfa(w, r, radiusClient, user, session, mfaTypes[0])
+		return
+	}
+
+	acceptEap(w, r, radiusClient, user, eap.Identifier, nil)
+}
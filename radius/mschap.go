@@ -0,0 +1,210 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package radius
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/casdoor/casdoor/object"
+	"layeh.com/radius"
+	"layeh.com/radius/rfc2759"
+	"layeh.com/radius/rfc2865"
+	"layeh.com/radius/rfc3079"
+	"layeh.com/radius/vendors/microsoft"
+)
+
+// the magic constants of GenerateAuthenticatorResponse in RFC 2759, 8.7
+var (
+	msChapMagic1 = []byte("Magic server to client signing constant")
+	msChapMagic2 = []byte("Pad to make it do more than one iteration")
+)
+
+// msChapReply is the result of a successful MS-CHAPv2 authentication that is added to the Access-Accept, the MPPE
+// keys are encrypted with the authenticator of the request that is answered, so they are kept in clear here
+type msChapReply struct {
+	Success []byte
+	SendKey []byte
+	RecvKey []byte
+}
+
+func (reply *msChapReply) addTo(p *radius.Packet) error {
+	if len(reply.Success) != 0 {
+		if err := microsoft.MSCHAP2Success_Add(p, reply.Success); err != nil {
+			return err
+		}
+	}
+	if err := microsoft.MSMPPERecvKey_Add(p, reply.RecvKey); err != nil {
+		return err
+	}
+	if err := microsoft.MSMPPESendKey_Add(p, reply.SendKey); err != nil {
+		return err
+	}
+	if err := microsoft.MSMPPEEncryptionPolicy_Add(p, microsoft.MSMPPEEncryptionPolicy_Value_EncryptionAllowed); err != nil {
+		return err
+	}
+	return microsoft.MSMPPEEncryptionTypes_Add(p, microsoft.MSMPPEEncryptionTypes_Value_RC440or128BitAllowed)
+}
+
+// getMsChapUsername returns the username without the Windows domain, e.g. "alice" for "EXAMPLE\alice", the domain
+// is not part of the challenge hash
+func getMsChapUsername(username string) string {
+	if i := strings.LastIndex(username, "\\"); i >= 0 {
+		return username[i+1:]
+	}
+	return username
+}
+
+func getNtResponse(authChallenge, peerChallenge, username, ntHash []byte) []byte {
+	challenge := rfc2759.ChallengeHash(peerChallenge, authChallenge, username)
+	return rfc2759.ChallengeResponse(challenge, ntHash)
+}
+
+// getAuthenticatorResponse is GenerateAuthenticatorResponse of RFC 2759 with the NT hash instead of the password
+func getAuthenticatorResponse(authChallenge, peerChallenge, ntResponse, username, ntHash []byte) string {
+	hash := sha1.New()
+	hash.Write(rfc2759.NTPasswordHash(ntHash))
+	hash.Write(ntResponse)
+	hash.Write(msChapMagic1)
+	digest := hash.Sum(nil)
+
+	hash = sha1.New()
+	hash.Write(digest)
+	hash.Write(rfc2759.ChallengeHash(peerChallenge, authChallenge, username))
+	hash.Write(msChapMagic2)
+	return "S=" + strings.ToUpper(hex.EncodeToString(hash.Sum(nil)))
+}
+
+// getMppeKeys returns the send and receive keys of the server, see RFC 3079, 3.4
+func getMppeKeys(ntHash, ntResponse []byte) ([]byte, []byte, error) {
+	masterKey := rfc3079.GetMasterKey(rfc2759.NTPasswordHash(ntHash), ntResponse)
+	sendKey, err := rfc3079.GetAsymmetricStartKey(masterKey, rfc3079.KeyLength128Bit, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recvKey, err := rfc3079.GetAsymmetricStartKey(masterKey, rfc3079.KeyLength128Bit, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sendKey, recvKey, nil
+}
+
+// verifyMsChap2 checks the NT response of the peer with the NT hash of the user, and returns the authenticator
+// response and the MPPE keys
+func verifyMsChap2(user *object.User, authChallenge, peerChallenge, ntResponse, username []byte) (*msChapReply, error) {
+	ntHash, err := user.GetNtHash()
+	if err != nil {
+		return nil, err
+	}
+	if ntHash == nil {
+		return nil, fmt.Errorf("the user: %s has no NT hash for MS-CHAPv2, the organization should enable the NT hash and the user should reset the password", user.GetId())
+	}
+
+	if !bytes.Equal(getNtResponse(authChallenge, peerChallenge, username, ntHash), ntResponse) {
+		return nil, nil
+	}
+
+	sendKey, recvKey, err := getMppeKeys(ntHash, ntResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &msChapReply{
+		Success: []byte(getAuthenticatorResponse(authChallenge, peerChallenge, ntResponse, username, ntHash)),
+		SendKey: sendKey,
+		RecvKey: recvKey,
+	}, nil
+}
+
+// checkMsChap2Response checks the MS-CHAP2-Response of the request, see RFC 2548, 2.3.2
+func checkMsChap2Response(r *radius.Request, organization string, username string) (*object.User, *msChapReply, error) {
+	authChallenge := microsoft.MSCHAPChallenge_Get(r.Packet)
+	response := microsoft.MSCHAP2Response_Get(r.Packet)
+	if len(authChallenge) != 16 || len(response) != 50 {
+		return nil, nil, fmt.Errorf("the MS-CHAP-Challenge or MS-CHAP2-Response is invalid")
+	}
+
+	ident := response[0]
+	peerChallenge := response[2:18]
+	ntResponse := response[26:50]
+
+	var reply *msChapReply
+	user, err := object.CheckUserCredential(organization, username, "en", func(user *object.User) (bool, error) {
+		var err error
+		reply, err = verifyMsChap2(user, authChallenge, peerChallenge, ntResponse, []byte(getMsChapUsername(username)))
+		return reply != nil, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reply.Success = append([]byte{ident}, reply.Success...)
+	return user, reply, nil
+}
+
+// getMsChapError returns the MS-CHAP-Error of a failed MS-CHAPv2 authentication, 691 is the error of an incorrect
+// password, see RFC 2759, 6
+func getMsChapError(r *radius.Request) []byte {
+	response := microsoft.MSCHAP2Response_Get(r.Packet)
+	if len(response) != 50 {
+		return nil
+	}
+
+	challenge := make([]byte, 16)
+	_, _ = rand.Read(challenge)
+	message := fmt.Sprintf("E=691 R=0 C=%s V=3 M=Authentication failed", strings.ToUpper(hex.EncodeToString(challenge)))
+	return append([]byte{response[0]}, message...)
+}
+
+// verifyChap checks the CHAP response, which is MD5(ident + password + challenge), see RFC 1994, 4.1
+func verifyChap(password string, chapPassword, challenge []byte) bool {
+	hash := md5.New()
+	hash.Write(chapPassword[:1])
+	hash.Write([]byte(password))
+	hash.Write(challenge)
+	return bytes.Equal(hash.Sum(nil), chapPassword[1:])
+}
+
+// checkChapPassword checks the CHAP-Password of the request, the challenge is the CHAP-Challenge or the request
+// authenticator, see RFC 2865, 2.2. CHAP needs the password in plain text.
+func checkChapPassword(r *radius.Request, organization string, username string) (*object.User, error) {
+	chapPassword := rfc2865.CHAPPassword_Get(r.Packet)
+	if len(chapPassword) != 17 {
+		return nil, fmt.Errorf("the CHAP-Password is invalid")
+	}
+
+	challenge := rfc2865.CHAPChallenge_Get(r.Packet)
+	if len(challenge) == 0 {
+		challenge = r.Authenticator[:]
+	}
+
+	return object.CheckUserCredential(organization, username, "en", func(user *object.User) (bool, error) {
+		password, err := user.GetPlainPassword()
+		if err != nil {
+			return false, err
+		}
+		if password == "" {
+			return false, fmt.Errorf("the user: %s has no password in plain text for CHAP", user.GetId())
+		}
+
+		return verifyChap(password, chapPassword, challenge), nil
+	})
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package radius
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/casdoor/casdoor/cred"
+	"github.com/stretchr/testify/assert"
+	"layeh.com/radius"
+	"layeh.com/radius/rfc3079"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestMsChap2(t *testing.T) {
+	// the test vectors of RFC 2759, 9.2
+	authChallenge := decodeHex(t, "5B5D7C7D7B3F2F3E3C2C602132262628")
+	peerChallenge := decodeHex(t, "21402324255E262A28295F2B3A337C7E")
+	username := []byte(getMsChapUsername("EXAMPLE\\User"))
+	ntHash := decodeHex(t, cred.GetNtHash("clientPass"))
+
+	ntResponse := getNtResponse(authChallenge, peerChallenge, username, ntHash)
+	assert.Equal(t, decodeHex(t, "82309ECD8D708B5EA08FAA3981CD83544233114A3D85D6DF"), ntResponse)
+	assert.Equal(t, "S=407A5589115FD0D6209F510FE9C04566932CDA56", getAuthenticatorResponse(authChallenge, peerChallenge, ntResponse, username, ntHash))
+
+	sendKey, recvKey, err := getMppeKeys(ntHash, ntResponse)
+	assert.Nil(t, err)
+	expectedSendKey, _ := rfc3079.MakeKey(ntResponse, []byte("clientPass"), true)
+	expectedRecvKey, _ := rfc3079.MakeKey(ntResponse, []byte("clientPass"), false)
+	assert.Equal(t, expectedSendKey, sendKey)
+	assert.Equal(t, expectedRecvKey, recvKey)
+}
+
+func TestVerifyChap(t *testing.T) {
+	challenge := decodeHex(t, "0102030405060708090a0b0c0d0e0f10")
+	// MD5(0x01 + "123" + challenge)
+	chapPassword := append([]byte{1}, decodeHex(t, "8cc964bb07d971ffaf97898397dd6fe6")...)
+	assert.True(t, verifyChap("123", chapPassword, challenge))
+	assert.False(t, verifyChap("1234", chapPassword, challenge))
+}
+
+func TestEapPacket(t *testing.T) {
+	p := &eapPacket{Code: eapCodeRequest, Identifier: 7, Type: eapTypeGtc, Data: []byte("Password: ")}
+	b := p.encode()
+	assert.Equal(t, []byte{eapCodeRequest, 7, 0, 15, eapTypeGtc}, b[:5])
+
+	parsed, err := parseEapPacket(b)
+	assert.Nil(t, err)
+	assert.Equal(t, p, parsed)
+
+	parsed, err = parseEapPacket((&eapPacket{Code: eapCodeSuccess, Identifier: 8}).encode())
+	assert.Nil(t, err)
+	assert.Equal(t, &eapPacket{Code: eapCodeSuccess, Identifier: 8}, parsed)
+
+	_, err = parseEapPacket([]byte{eapCodeResponse, 1, 0, 9, eapTypeIdentity})
+	assert.NotNil(t, err)
+}
+
+func TestMessageAuthenticator(t *testing.T) {
+	request := radius.New(radius.CodeAccessRequest, []byte("secret"))
+	assert.False(t, isMessageAuthenticatorValid(request))
+
+	assert.Nil(t, setMessageAuthenticator(request))
+	assert.True(t, isMessageAuthenticatorValid(request))
+
+	request.Secret = []byte("other")
+	assert.False(t, isMessageAuthenticatorValid(request))
+}
@@ -15,10 +15,11 @@
 package radius
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net"
 	"strings"
-	"time"
 
 	"github.com/casdoor/casdoor/conf"
 	"github.com/casdoor/casdoor/object"
@@ -26,22 +27,15 @@ import (
 	"layeh.com/radius"
 	"layeh.com/radius/rfc2865"
 	"layeh.com/radius/rfc2866"
+	"layeh.com/radius/rfc2869"
+	"layeh.com/radius/vendors/microsoft"
 )
 
-var StateMap map[string]AccessStateContent
-
-const StateExpiredTime = time.Second * 120
-
-type AccessStateContent struct {
-	ExpiredAt time.Time
-}
-
 func StartRadiusServer() {
-	secret := conf.GetConfigString("radiusSecret")
 	server := radius.PacketServer{
 		Addr:         "0.0.0.0:" + conf.GetConfigString("radiusServerPort"),
 		Handler:      radius.HandlerFunc(handlerRadius),
-		SecretSource: radius.StaticSecretSource([]byte(secret)),
+		SecretSource: radiusSecretSource{},
 	}
 	log.Printf("Starting Radius server on %s", server.Addr)
 	if err := server.ListenAndServe(); err != nil {
@@ -49,6 +43,52 @@ func StartRadiusServer() {
 	}
 }
 
+// radiusSecretSource returns the secret of the RADIUS client of the source address, the radiusSecret config is the
+// secret of all the addresses until a RADIUS client is registered, then the unknown addresses are rejected
+type radiusSecretSource struct{}
+
+func (radiusSecretSource) RADIUSSecret(ctx context.Context, remoteAddr net.Addr) ([]byte, error) {
+	radiusClient, isRegistered, err := getRadiusClient(remoteAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if radiusClient != nil {
+		return []byte(radiusClient.Secret), nil
+	}
+	if isRegistered {
+		return nil, fmt.Errorf("the address: %s is not a registered RADIUS client", remoteAddr.String())
+	}
+	return []byte(conf.GetConfigString("radiusSecret")), nil
+}
+
+func getRadiusClient(remoteAddr net.Addr) (*object.RadiusClient, bool, error) {
+	udpAddr, ok := remoteAddr.(*net.UDPAddr)
+	if !ok {
+		return nil, false, nil
+	}
+
+	return object.GetRadiusClientByIp(udpAddr.IP)
+}
+
+// getRadiusOrganization returns the organization of the request, a registered RADIUS client can only authenticate
+// the users of its organization, the other requests use the Class attribute and then the radiusDefaultOrganization
+// config
+func getRadiusOrganization(r *radius.Request, radiusClient *object.RadiusClient) string {
+	if radiusClient != nil && radiusClient.GetOrganization() != "" {
+		return radiusClient.GetOrganization()
+	}
+
+	organization := rfc2865.Class_GetString(r.Packet)
+	if organization == "" {
+		organization = conf.GetConfigString("radiusDefaultOrganization")
+		if organization == "" {
+			organization = "built-in"
+		}
+	}
+	return organization
+}
+
 func handlerRadius(w radius.ResponseWriter, r *radius.Request) {
 	switch r.Code {
 	case radius.CodeAccessRequest:
@@ -61,103 +101,271 @@ func handlerRadius(w radius.ResponseWriter, r *radius.Request) {
 }
 
 func handleAccessRequest(w radius.ResponseWriter, r *radius.Request) {
+	radiusClient, _, err := getRadiusClient(r.RemoteAddr)
+	if err != nil {
+		log.Printf("handleAccessRequest() failed to get the RADIUS client, err = %v", err)
+		w.Write(r.Response(radius.CodeAccessReject))
+		return
+	}
+
+	organization := getRadiusOrganization(r, radiusClient)
+	if _, err = rfc2869.EAPMessage_Lookup(r.Packet); err == nil {
+		handleEapRequest(w, r, radiusClient, organization)
+		return
+	}
+
 	username := rfc2865.UserName_GetString(r.Packet)
 	password := rfc2865.UserPassword_GetString(r.Packet)
-	organization := rfc2865.Class_GetString(r.Packet)
 	state := rfc2865.State_GetString(r.Packet)
-	log.Printf("handleAccessRequest() username=%v, org=%v, password=%v", username, organization, password)
+	log.Printf("handleAccessRequest() username=%v, org=%v, state=%v", username, organization, state)
 
-	if organization == "" {
-		organization = conf.GetConfigString("radiusDefaultOrganization")
-		if organization == "" {
-			organization = "built-in"
-		}
+	if state != "" {
+		handleAccessChallengeResponse(w, r, radiusClient, state, password)
+		return
 	}
 
 	var user *object.User
-	var err error
-
-	if state == "" {
-		user, err = object.CheckUserPassword(organization, username, password, "en")
+	var reply *msChapReply
+	if len(rfc2865.CHAPPassword_Get(r.Packet)) != 0 {
+		user, err = checkChapPassword(r, organization, username)
+	} else if len(microsoft.MSCHAP2Response_Get(r.Packet)) != 0 {
+		user, reply, err = checkMsChap2Response(r, organization, username)
 	} else {
-		user, err = object.GetUser(fmt.Sprintf("%s/%s", organization, username))
+		user, err = object.CheckUserPassword(organization, username, password, "en")
+		if err == nil {
+			if syncErr := object.SyncUserNtHash(user, password); syncErr != nil {
+				log.Printf("handleAccessRequest() failed to sync the NT hash, err = %v", syncErr)
+			}
+		}
 	}
 
+	if err == nil {
+		err = checkAccessUser(radiusClient, user)
+	}
 	if err != nil {
-		w.Write(r.Response(radius.CodeAccessReject))
+		log.Printf("handleAccessRequest() failed, err = %v", err)
+		response := r.Response(radius.CodeAccessReject)
+		if msChapError := getMsChapError(r); msChapError != nil {
+			_ = microsoft.MSCHAPError_Add(response, msChapError)
+		}
+		w.Write(response)
 		return
 	}
 
 	if user.IsMfaEnabled() {
-		mfaProp := user.GetMfaProps(object.TotpType, false)
-		if mfaProp == nil {
+		mfaTypes := getRadiusMfaTypes(user)
+		if len(mfaTypes) == 0 {
 			w.Write(r.Response(radius.CodeAccessReject))
 			return
 		}
 
-		if StateMap == nil {
-			StateMap = map[string]AccessStateContent{}
-		}
+		challengeRadiusMfa(w, r, radiusClient, user, mfaTypes[0], reply)
+		return
+	}
 
-		if state != "" {
-			stateContent, ok := StateMap[state]
-			if !ok {
-				w.Write(r.Response(radius.CodeAccessReject))
-				return
-			}
+	writeAccessAccept(w, r, radiusClient, user, reply)
+}
 
-			delete(StateMap, state)
-			if stateContent.ExpiredAt.Before(time.Now()) {
-				w.Write(r.Response(radius.CodeAccessReject))
-				return
-			}
+// challengeRadiusMfa sends an Access-Challenge for the OTP of the MFA type, the code of an SMS or email MFA is sent to
+// the user first
+func challengeRadiusMfa(w radius.ResponseWriter, r *radius.Request, radiusClient *object.RadiusClient, user *object.User, mfaType string, reply *msChapReply) {
+	prompt, err := startRadiusMfa(r, radiusClient, user, mfaType)
+	var state string
+	if err == nil {
+		state, err = putState(&AccessStateContent{
+			UserId:  user.GetId(),
+			MfaType: mfaType,
+			Reply:   reply,
+		})
+	}
 
-			mfaUtil := object.GetMfaUtil(mfaProp.MfaType, mfaProp)
-			if mfaUtil.Verify(password) != nil {
-				w.Write(r.Response(radius.CodeAccessReject))
-				return
-			}
+	response := r.Response(radius.CodeAccessChallenge)
+	if err == nil {
+		err = rfc2865.State_SetString(response, state)
+	}
+	if err == nil {
+		err = rfc2865.ReplyMessage_SetString(response, prompt)
+	}
+	if err != nil {
+		log.Printf("challengeRadiusMfa() failed, err = %v", err)
+		w.Write(r.Response(radius.CodeAccessReject))
+		return
+	}
 
-			w.Write(r.Response(radius.CodeAccessAccept))
+	w.Write(response)
+}
+
+// handleAccessChallengeResponse checks the OTP of the user that has been authenticated by the previous request, the
+// user can also answer with the name of another MFA type to get a new challenge for it
+func handleAccessChallengeResponse(w radius.ResponseWriter, r *radius.Request, radiusClient *object.RadiusClient, state string, passcode string) {
+	stateContent, err := StateMap.Take(state)
+	if err != nil {
+		log.Printf("handleAccessChallengeResponse() failed to get the state, err = %v", err)
+	}
+	if stateContent == nil || stateContent.UserId == "" {
+		w.Write(r.Response(radius.CodeAccessReject))
+		return
+	}
+
+	user, err := object.GetUser(stateContent.UserId)
+	if err == nil && (user == nil || user.IsForbidden || user.IsDeleted) {
+		err = fmt.Errorf("the user: %s is not allowed", stateContent.UserId)
+	}
+	if err == nil {
+		if mfaType := getChosenRadiusMfaType(user, stateContent.MfaType, passcode); mfaType != "" {
+			challengeRadiusMfa(w, r, radiusClient, user, mfaType, stateContent.Reply)
 			return
 		}
 
-		responseState := util.GenerateId()
-		StateMap[responseState] = AccessStateContent{
-			time.Now().Add(StateExpiredTime),
+		err = verifyRadiusOtp(user, stateContent.MfaType, passcode)
+	}
+	if err != nil {
+		log.Printf("handleAccessChallengeResponse() failed, err = %v", err)
+		w.Write(r.Response(radius.CodeAccessReject))
+		return
+	}
+
+	writeAccessAccept(w, r, radiusClient, user, stateContent.Reply)
+}
+
+// addAcceptAttributes adds the MS-CHAPv2 reply and the reply attributes of the RADIUS client for the user to the
+// Access-Accept
+func addAcceptAttributes(p *radius.Packet, radiusClient *object.RadiusClient, user *object.User, reply *msChapReply) error {
+	if reply != nil {
+		if err := reply.addTo(p); err != nil {
+			return err
 		}
+	}
+
+	if radiusClient == nil {
+		return nil
+	}
+	return radiusClient.AddRadiusReplyAttributes(p, user)
+}
+
+func writeAccessAccept(w radius.ResponseWriter, r *radius.Request, radiusClient *object.RadiusClient, user *object.User, reply *msChapReply) {
+	response := r.Response(radius.CodeAccessAccept)
+	if err := addAcceptAttributes(response, radiusClient, user, reply); err != nil {
+		log.Printf("writeAccessAccept() failed, err = %v", err)
+		w.Write(r.Response(radius.CodeAccessReject))
+		return
+	}
+
+	w.Write(response)
+}
 
-		err = rfc2865.State_Set(r.Packet, []byte(responseState))
+// checkAccessUser checks that the authenticated user can access the network through the RADIUS client
+func checkAccessUser(radiusClient *object.RadiusClient, user *object.User) error {
+	err := object.CheckLdapServiceAccount(user, "en")
+	if err != nil {
+		return err
+	}
+
+	if radiusClient != nil {
+		allowed, err := radiusClient.CheckRadiusClientApplications(user)
 		if err != nil {
-			w.Write(r.Response(radius.CodeAccessReject))
-			return
+			return err
+		}
+		if !allowed {
+			return fmt.Errorf("the user: %s is not allowed by the RADIUS client: %s", user.GetId(), radiusClient.GetId())
 		}
 
-		err = rfc2865.ReplyMessage_Set(r.Packet, []byte("please enter OTP"))
+		allowed, err = radiusClient.CheckRadiusClientRoles(user)
 		if err != nil {
-			w.Write(r.Response(radius.CodeAccessReject))
-			return
+			return err
+		}
+		if !allowed {
+			return fmt.Errorf("the user: %s has none of the required roles of the RADIUS client: %s", user.GetId(), radiusClient.GetId())
+		}
+	}
+	return nil
+}
+
+// getRadiusMfaTypes returns the enabled MFA types of the user that can answer an Access-Challenge, the preferred one
+// comes first
+func getRadiusMfaTypes(user *object.User) []string {
+	mfaTypes := []string{}
+	for _, mfaProps := range object.GetAllMfaProps(user, false) {
+		if !mfaProps.Enabled {
+			continue
+		}
+
+		if mfaProps.IsPreferred {
+			mfaTypes = append([]string{mfaProps.MfaType}, mfaTypes...)
+		} else {
+			mfaTypes = append(mfaTypes, mfaProps.MfaType)
+		}
+	}
+	return mfaTypes
+}
+
+// getChosenRadiusMfaType returns the MFA type that the user chooses by answering a challenge with its name, it's
+// empty if the response is an OTP
+func getChosenRadiusMfaType(user *object.User, mfaType string, response string) string {
+	response = strings.ToLower(strings.TrimSpace(response))
+	if response == mfaType || !util.InSlice(getRadiusMfaTypes(user), response) {
+		return ""
+	}
+	return response
+}
+
+// startRadiusMfa sends the verification code of an SMS or email MFA and returns the prompt of the challenge, the
+// prompt lists the other MFA types of the user that can be chosen instead
+func startRadiusMfa(r *radius.Request, radiusClient *object.RadiusClient, user *object.User, mfaType string) (string, error) {
+	prompt := "please enter OTP"
+	if mfaType != object.TotpType {
+		err := object.SendRadiusVerificationCode(radiusClient, user, mfaType, getRemoteIp(r))
+		if err != nil {
+			return "", err
+		}
+
+		prompt = fmt.Sprintf("please enter the verification code sent to %s", user.GetMfaProps(mfaType, true).Secret)
+	}
+
+	otherMfaTypes := []string{}
+	for _, otherMfaType := range getRadiusMfaTypes(user) {
+		if otherMfaType != mfaType {
+			otherMfaTypes = append(otherMfaTypes, fmt.Sprintf("\"%s\"", otherMfaType))
 		}
+	}
+	if len(otherMfaTypes) != 0 {
+		prompt = fmt.Sprintf("%s, or enter %s to use another method", prompt, strings.Join(otherMfaTypes, " or "))
+	}
+	return prompt, nil
+}
 
-		r.Packet.Code = radius.CodeAccessChallenge
-		w.Write(r.Packet)
+func verifyRadiusOtp(user *object.User, mfaType string, passcode string) error {
+	mfaProps := user.GetMfaProps(mfaType, false)
+	if !mfaProps.Enabled {
+		return fmt.Errorf("the user: %s has no %s MFA for RADIUS", user.GetId(), mfaType)
 	}
 
-	w.Write(r.Response(radius.CodeAccessAccept))
+	return object.GetMfaUtil(mfaType, mfaProps).Verify(passcode)
 }
 
 func handleAccountingRequest(w radius.ResponseWriter, r *radius.Request) {
 	statusType := rfc2866.AcctStatusType_Get(r.Packet)
 	username := rfc2865.UserName_GetString(r.Packet)
-	organization := rfc2865.Class_GetString(r.Packet)
 
+	radiusClient, _, err := getRadiusClient(r.RemoteAddr)
+	if err != nil {
+		log.Printf("handleAccountingRequest() failed to get the RADIUS client, err = %v", err)
+	}
+	organization := getRadiusOrganization(r, radiusClient)
+
+	// a registered RADIUS client of an organization only accounts the users of the organization
 	if strings.Contains(username, "/") {
-		organization, username = util.GetOwnerAndNameFromId(username)
+		var usernameOrganization string
+		usernameOrganization, username = util.GetOwnerAndNameFromId(username)
+		if radiusClient != nil && radiusClient.GetOrganization() != "" && usernameOrganization != organization {
+			log.Printf("handleAccountingRequest() failed, err = the user: %s is not in the organization: %s of the RADIUS client: %s", rfc2865.UserName_GetString(r.Packet), organization, radiusClient.GetId())
+			return
+		}
+		organization = usernameOrganization
 	}
 
 	log.Printf("handleAccountingRequest() username=%v, org=%v, statusType=%v", username, organization, statusType)
 	w.Write(r.Response(radius.CodeAccountingResponse))
-	var err error
 	defer func() {
 		if err != nil {
 			log.Printf("handleAccountingRequest() failed, err = %v", err)
@@ -166,15 +374,17 @@ func handleAccountingRequest(w radius.ResponseWriter, r *radius.Request) {
 	switch statusType {
 	case rfc2866.AcctStatusType_Value_Start:
 		// Start an accounting session
-		ra := GetAccountingFromRequest(r)
+		ra := GetAccountingFromRequest(r, organization, radiusClient)
+		ra.UserId = getAccountingUserId(organization, username)
 		err = object.AddRadiusAccounting(ra)
 	case rfc2866.AcctStatusType_Value_InterimUpdate, rfc2866.AcctStatusType_Value_Stop:
 		// Interim update to an accounting session | Stop an accounting session
 		var (
-			newRa = GetAccountingFromRequest(r)
+			newRa = GetAccountingFromRequest(r, organization, radiusClient)
 			oldRa *object.RadiusAccounting
 		)
-		oldRa, err = object.GetRadiusAccountingBySessionId(newRa.AcctSessionId)
+		newRa.UserId = getAccountingUserId(organization, username)
+		oldRa, err = object.GetRadiusAccountingBySessionId(newRa.RadiusClient, newRa.AcctSessionId)
 		if err != nil {
 			return
 		}
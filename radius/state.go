@@ -0,0 +1,75 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package radius
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+const StateExpiredTime = time.Second * 120
+
+// AccessStateContent is the state of an Access-Challenge, for MFA it keeps the authenticated user, the MFA type of the
+// OTP and the MS-CHAPv2 reply of the Access-Accept, for EAP it keeps the EAP session
+type AccessStateContent struct {
+	UserId  string
+	MfaType string
+	Reply   *msChapReply
+	Eap     *eapSession
+}
+
+// StateStore keeps the AccessStateContent of the Access-Challenges until the NAS sends the response. A State can be
+// answered once, and expires after StateExpiredTime if it's never answered.
+type StateStore interface {
+	Put(state string, content *AccessStateContent) error
+	// Take returns the content of the State and removes it, the content is nil if the State doesn't exist or has expired
+	Take(state string) (*AccessStateContent, error)
+}
+
+// StateMap is the ticket store of Casdoor by default, which is Redis if "redisEndpoint" is configured and the
+// database otherwise, so the response of a challenge can be handled by another replica than the challenge
+var StateMap StateStore = ticketStateStore{}
+
+type ticketStateStore struct{}
+
+func getStateTicket(state string) string {
+	return fmt.Sprintf("radius:state:%s", state)
+}
+
+func (ticketStateStore) Put(state string, content *AccessStateContent) error {
+	return object.PutTicket(getStateTicket(state), content, StateExpiredTime)
+}
+
+func (ticketStateStore) Take(state string) (*AccessStateContent, error) {
+	content := &AccessStateContent{}
+	ok, err := object.TakeTicket(getStateTicket(state), content)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return content, nil
+}
+
+// putState stores the content under a new State
+func putState(content *AccessStateContent) (string, error) {
+	state := util.GenerateId()
+	err := StateMap.Put(state, content)
+	if err != nil {
+		return "", err
+	}
+	return state, nil
+}
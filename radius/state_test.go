@@ -0,0 +1,66 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package radius
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessStateContent(t *testing.T) {
+	// the ticket stores keep the JSON encoding of the content, the EAP session must survive it
+	content := &AccessStateContent{
+		UserId:  "built-in/alice",
+		MfaType: object.SmsType,
+		Reply:   &msChapReply{SendKey: []byte{1, 2, 3}, RecvKey: []byte{4, 5, 6}},
+		Eap: &eapSession{
+			Username:      "alice",
+			Methods:       []byte{eapTypeMsChapV2, eapTypeGtc},
+			Method:        eapTypeMsChapV2,
+			Identifier:    2,
+			AuthChallenge: []byte("0123456789abcdef"),
+		},
+	}
+
+	data, err := json.Marshal(content)
+	assert.Nil(t, err)
+
+	var decoded AccessStateContent
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, content, &decoded)
+}
+
+func TestGetRadiusMfaTypes(t *testing.T) {
+	user := &object.User{
+		Owner:            "built-in",
+		Name:             "alice",
+		Email:            "alice@example.com",
+		Phone:            "13800000000",
+		TotpSecret:       "secret",
+		MfaEmailEnabled:  true,
+		PreferredMfaType: object.EmailType,
+	}
+
+	mfaTypes := getRadiusMfaTypes(user)
+	assert.Equal(t, []string{object.EmailType, object.TotpType}, mfaTypes)
+
+	assert.Equal(t, object.TotpType, getChosenRadiusMfaType(user, object.EmailType, " App "))
+	assert.Equal(t, "", getChosenRadiusMfaType(user, object.EmailType, "email"))
+	assert.Equal(t, "", getChosenRadiusMfaType(user, object.EmailType, object.SmsType))
+	assert.Equal(t, "", getChosenRadiusMfaType(user, object.EmailType, "123456"))
+}
@@ -16,6 +16,8 @@ package radius
 
 import (
 	"fmt"
+	"log"
+	"net"
 	"time"
 
 	"github.com/casdoor/casdoor/object"
@@ -26,12 +28,11 @@ import (
 	"layeh.com/radius/rfc2869"
 )
 
-func GetAccountingFromRequest(r *radius.Request) *object.RadiusAccounting {
+func GetAccountingFromRequest(r *radius.Request, organization string, radiusClient *object.RadiusClient) *object.RadiusAccounting {
 	acctInputOctets := int(rfc2866.AcctInputOctets_Get(r.Packet))
 	acctInputGigawords := int(rfc2869.AcctInputGigawords_Get(r.Packet))
 	acctOutputOctets := int(rfc2866.AcctOutputOctets_Get(r.Packet))
 	acctOutputGigawords := int(rfc2869.AcctOutputGigawords_Get(r.Packet))
-	organization := rfc2865.Class_GetString(r.Packet)
 	getAcctStartTime := func(sessionTime int) time.Time {
 		m, _ := time.ParseDuration(fmt.Sprintf("-%ds", sessionTime))
 		return time.Now().Add(m)
@@ -63,5 +64,32 @@ func GetAccountingFromRequest(r *radius.Request) *object.RadiusAccounting {
 		AcctTerminateCause: int64(rfc2866.AcctTerminateCause_Get(r.Packet)),
 		LastUpdate:         time.Now(),
 	}
+
+	if radiusClient != nil {
+		ra.RadiusClient = radiusClient.GetId()
+	}
+	ra.ClientIp = getRemoteIp(r)
 	return ra
 }
+
+// getRemoteIp returns the IP address of the NAS that sends the request
+func getRemoteIp(r *radius.Request) string {
+	if udpAddr, ok := r.RemoteAddr.(*net.UDPAddr); ok {
+		return udpAddr.IP.String()
+	}
+	return ""
+}
+
+// getAccountingUserId returns the id of the user of an accounting session, the User-Name is resolved like the one of
+// the Access-Request, it's empty if the user doesn't exist
+func getAccountingUserId(organization string, username string) string {
+	user, err := object.GetUserByFields(organization, username)
+	if err != nil {
+		log.Printf("getAccountingUserId() failed to get the user, err = %v", err)
+		return ""
+	}
+	if user == nil {
+		return ""
+	}
+	return user.GetId()
+}
@@ -153,7 +153,7 @@ func willLog(subOwner string, subName string, method string, urlPath string, obj
 }
 
 func getUrlPath(urlPath string) string {
-	if strings.HasPrefix(urlPath, "/cas") && (strings.HasSuffix(urlPath, "/serviceValidate") || strings.HasSuffix(urlPath, "/proxy") || strings.HasSuffix(urlPath, "/proxyValidate") || strings.HasSuffix(urlPath, "/validate") || strings.HasSuffix(urlPath, "/p3/serviceValidate") || strings.HasSuffix(urlPath, "/p3/proxyValidate") || strings.HasSuffix(urlPath, "/samlValidate")) {
+	if strings.HasPrefix(urlPath, "/cas") && (strings.HasSuffix(urlPath, "/serviceValidate") || strings.HasSuffix(urlPath, "/proxy") || strings.HasSuffix(urlPath, "/proxyValidate") || strings.HasSuffix(urlPath, "/validate") || strings.HasSuffix(urlPath, "/p3/serviceValidate") || strings.HasSuffix(urlPath, "/p3/proxyValidate") || strings.HasSuffix(urlPath, "/samlValidate") || strings.Contains(urlPath, "/v1/tickets")) {
 		return "/cas"
 	}
 
@@ -173,6 +173,18 @@ func getUrlPath(urlPath string) string {
 		return "/api/saml/redirect"
 	}
 
+	if strings.HasPrefix(urlPath, "/api/saml/idp-initiated") {
+		return "/api/saml/idp-initiated"
+	}
+
+	if strings.HasPrefix(urlPath, "/api/saml/slo") {
+		return "/api/saml/slo"
+	}
+
+	if strings.HasPrefix(urlPath, "/api/wsfed") {
+		return "/api/wsfed"
+	}
+
 	return urlPath
 }
 
@@ -99,7 +99,13 @@ func AutoSigninFilter(ctx *context.Context) {
 	password := ctx.Input.Query("password")
 	if userId != "" && password != "" && ctx.Input.Query("grant_type") == "" {
 		owner, name := util.GetOwnerAndNameFromId(userId)
-		_, err = object.CheckUserPassword(owner, name, password, "en")
+		user, err := object.CheckUserPassword(owner, name, password, "en")
+		if err != nil {
+			responseError(ctx, err.Error())
+			return
+		}
+
+		err = object.CheckLdapServiceAccount(user, "en")
 		if err != nil {
 			responseError(ctx, err.Error())
 			return
@@ -61,6 +61,12 @@ func initAPI() {
 	beego.Router("/api/acs", &controllers.ApiController{}, "POST:HandleSamlLogin")
 	beego.Router("/api/saml/metadata", &controllers.ApiController{}, "GET:GetSamlMeta")
 	beego.Router("/api/saml/redirect/:owner/:application", &controllers.ApiController{}, "*:HandleSamlRedirect")
+	beego.Router("/api/saml/idp-initiated/:owner/:application", &controllers.ApiController{}, "GET:HandleSamlIdpInitiated")
+	beego.Router("/api/saml/artifact-resolve", &controllers.ApiController{}, "POST:HandleSamlArtifactResolve")
+	beego.Router("/api/saml/sp-metadata", &controllers.ApiController{}, "GET:GetSamlSpMetadata")
+	beego.Router("/api/saml/slo/:owner/:provider", &controllers.ApiController{}, "*:HandleSamlSpLogout")
+	beego.Router("/api/wsfed/metadata", &controllers.ApiController{}, "GET:GetWsFedMetadata")
+	beego.Router("/api/wsfed/:owner/:application", &controllers.ApiController{}, "GET,POST:HandleWsFed")
 	beego.Router("/api/webhook", &controllers.ApiController{}, "*:HandleOfficialAccountEvent")
 	beego.Router("/api/get-qrcode", &controllers.ApiController{}, "GET:GetQRCode")
 	beego.Router("/api/get-webhook-event", &controllers.ApiController{}, "GET:GetWebhookEventType")
@@ -108,6 +114,7 @@ func initAPI() {
 	beego.Router("/api/update-application", &controllers.ApiController{}, "POST:UpdateApplication")
 	beego.Router("/api/add-application", &controllers.ApiController{}, "POST:AddApplication")
 	beego.Router("/api/delete-application", &controllers.ApiController{}, "POST:DeleteApplication")
+	beego.Router("/api/import-saml-sp-metadata", &controllers.ApiController{}, "POST:ImportSamlSpMetadata")
 
 	beego.Router("/api/get-providers", &controllers.ApiController{}, "GET:GetProviders")
 	beego.Router("/api/get-provider", &controllers.ApiController{}, "GET:GetProvider")
@@ -250,6 +257,17 @@ func initAPI() {
 	beego.Router("/api/add-webhook", &controllers.ApiController{}, "POST:AddWebhook")
 	beego.Router("/api/delete-webhook", &controllers.ApiController{}, "POST:DeleteWebhook")
 
+	beego.Router("/api/get-radius-clients", &controllers.ApiController{}, "GET:GetRadiusClients")
+	beego.Router("/api/get-radius-client", &controllers.ApiController{}, "GET:GetRadiusClient")
+	beego.Router("/api/update-radius-client", &controllers.ApiController{}, "POST:UpdateRadiusClient")
+	beego.Router("/api/add-radius-client", &controllers.ApiController{}, "POST:AddRadiusClient")
+	beego.Router("/api/delete-radius-client", &controllers.ApiController{}, "POST:DeleteRadiusClient")
+
+	beego.Router("/api/get-radius-accountings", &controllers.ApiController{}, "GET:GetRadiusAccountings")
+	beego.Router("/api/get-radius-accounting", &controllers.ApiController{}, "GET:GetRadiusAccounting")
+	beego.Router("/api/disconnect-radius-accounting", &controllers.ApiController{}, "POST:DisconnectRadiusAccounting")
+	beego.Router("/api/reauthorize-radius-accounting", &controllers.ApiController{}, "POST:ReauthorizeRadiusAccounting")
+
 	beego.Router("/api/set-password", &controllers.ApiController{}, "POST:SetPassword")
 	beego.Router("/api/check-user-password", &controllers.ApiController{}, "POST:CheckUserPassword")
 	beego.Router("/api/get-email-and-phone", &controllers.ApiController{}, "GET:GetEmailAndPhone")
@@ -263,10 +281,12 @@ func initAPI() {
 	beego.Router("/api/get-ldap-users", &controllers.ApiController{}, "GET:GetLdapUsers")
 	beego.Router("/api/get-ldaps", &controllers.ApiController{}, "GET:GetLdaps")
 	beego.Router("/api/get-ldap", &controllers.ApiController{}, "GET:GetLdap")
+	beego.Router("/api/get-ldap-health", &controllers.ApiController{}, "GET:GetLdapHealth")
 	beego.Router("/api/add-ldap", &controllers.ApiController{}, "POST:AddLdap")
 	beego.Router("/api/update-ldap", &controllers.ApiController{}, "POST:UpdateLdap")
 	beego.Router("/api/delete-ldap", &controllers.ApiController{}, "POST:DeleteLdap")
 	beego.Router("/api/sync-ldap-users", &controllers.ApiController{}, "POST:SyncLdapUsers")
+	beego.Router("/api/sync-ldap-groups", &controllers.ApiController{}, "POST:SyncLdapGroups")
 
 	beego.Router("/api/login/oauth/access_token", &controllers.ApiController{}, "POST:GetOAuthToken")
 	beego.Router("/api/login/oauth/refresh_token", &controllers.ApiController{}, "POST:RefreshToken")
@@ -303,6 +323,8 @@ func initAPI() {
 	beego.Router("/cas/:organization/:application/p3/serviceValidate", &controllers.RootController{}, "GET:CasP3ServiceValidate")
 	beego.Router("/cas/:organization/:application/p3/proxyValidate", &controllers.RootController{}, "GET:CasP3ProxyValidate")
 	beego.Router("/cas/:organization/:application/samlValidate", &controllers.RootController{}, "POST:SamlValidate")
+	beego.Router("/cas/:organization/:application/v1/tickets", &controllers.RootController{}, "POST:CasRestLogin")
+	beego.Router("/cas/:organization/:application/v1/tickets/:tgt", &controllers.RootController{}, "POST:CasRestServiceTicket;DELETE:CasRestLogout")
 
 	beego.Router("/scim/*", &controllers.RootController{}, "*:HandleScim")
 
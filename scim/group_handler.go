@@ -0,0 +1,329 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+	"github.com/elimity-com/scim"
+	"github.com/elimity-com/scim/errors"
+	"github.com/scim2/filter-parser/v2"
+)
+
+// GroupResourceHandler handles the Group resources, the SCIM id of a group is its name, which is unique in Casdoor.
+// The members of a group are the users whose groups contain the group.
+type GroupResourceHandler struct{}
+
+func (h GroupResourceHandler) Create(r *http.Request, attrs scim.ResourceAttributes) (scim.Resource, error) {
+	resource := &scim.Resource{Attributes: attrs}
+	err := AddScimGroup(resource)
+	return *resource, err
+}
+
+func (h GroupResourceHandler) Get(r *http.Request, id string) (scim.Resource, error) {
+	resource, err := GetScimGroup(id)
+	if err != nil {
+		return scim.Resource{}, err
+	}
+	if resource == nil {
+		return scim.Resource{}, errors.ScimErrorResourceNotFound(id)
+	}
+	return *resource, nil
+}
+
+func (h GroupResourceHandler) Delete(r *http.Request, id string) error {
+	group, err := object.GetGroupByGroupNameOnly(id)
+	if err != nil {
+		return err
+	}
+	if group == nil {
+		return errors.ScimErrorResourceNotFound(id)
+	}
+
+	// a group with users can't be deleted in Casdoor, the IdPs delete a group with its members
+	_, err = object.DeleteGroupWithMembers(group)
+	return err
+}
+
+func (h GroupResourceHandler) GetAll(r *http.Request, params scim.ListRequestParams) (scim.Page, error) {
+	// Azure AD looks up a group by `displayName eq "..."` before creating it, the other filters aren't supported
+	if params.Filter != nil {
+		displayName, ok := getDisplayNameFilter(params.Filter)
+		if !ok {
+			return scim.Page{}, errors.ScimErrorInvalidFilter
+		}
+
+		resources := make([]scim.Resource, 0)
+		group, err := object.GetGroupByGroupNameOnly(getScimGroupName(displayName))
+		if err != nil {
+			return scim.Page{}, err
+		}
+		if group != nil {
+			resource, err := group2resource(group)
+			if err != nil {
+				return scim.Page{}, err
+			}
+			resources = append(resources, *resource)
+		}
+		return scim.Page{
+			TotalResults: len(resources),
+			Resources:    resources,
+		}, nil
+	}
+
+	count, err := object.GetGroupCount("", "", "")
+	if err != nil {
+		return scim.Page{}, err
+	}
+	if params.Count == 0 {
+		return scim.Page{TotalResults: int(count)}, nil
+	}
+
+	resources := make([]scim.Resource, 0)
+	// startIndex is 1-based index
+	groups, err := object.GetPaginationGroups("", params.StartIndex-1, params.Count, "", "", "", "")
+	if err != nil {
+		return scim.Page{}, err
+	}
+	for _, group := range groups {
+		resource, err := group2resource(group)
+		if err != nil {
+			return scim.Page{}, err
+		}
+		resources = append(resources, *resource)
+	}
+	return scim.Page{
+		TotalResults: int(count),
+		Resources:    resources,
+	}, nil
+}
+
+func (h GroupResourceHandler) Patch(r *http.Request, id string, operations []scim.PatchOperation) (scim.Resource, error) {
+	return UpdateScimGroupByPatchOperation(id, operations)
+}
+
+func (h GroupResourceHandler) Replace(r *http.Request, id string, attrs scim.ResourceAttributes) (scim.Resource, error) {
+	resource := &scim.Resource{Attributes: attrs}
+	err := UpdateScimGroup(id, resource)
+	return *resource, err
+}
+
+// getDisplayNameFilter returns the value of a `displayName eq "..."` filter
+func getDisplayNameFilter(expression filter.Expression) (string, bool) {
+	attrExp, ok := expression.(*filter.AttributeExpression)
+	if !ok || attrExp.Operator != filter.EQ || !strings.EqualFold(attrExp.AttributePath.AttributeName, "displayName") {
+		return "", false
+	}
+
+	displayName, ok := attrExp.CompareValue.(string)
+	return displayName, ok
+}
+
+func GetScimGroup(id string) (*scim.Resource, error) {
+	group, err := object.GetGroupByGroupNameOnly(id)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, nil
+	}
+	return group2resource(group)
+}
+
+func AddScimGroup(r *scim.Resource) error {
+	newGroup, memberIds, err := resource2group(r.Attributes)
+	if err != nil {
+		return err
+	}
+
+	// the IdPs like Okta and Azure AD don't send the organization, it's the one of the members then
+	if newGroup.Owner == "" && len(memberIds) > 0 {
+		user, err := object.GetUserByUserIdOnly(memberIds[0])
+		if err != nil {
+			return err
+		}
+		if user != nil {
+			newGroup.Owner = user.Owner
+			newGroup.ParentId = user.Owner
+		}
+	}
+	if newGroup.Owner == "" {
+		return fmt.Errorf("organization in %s is required", GroupExtensionKey)
+	}
+
+	// Check whether the group exists.
+	oldGroup, err := object.GetGroupByGroupNameOnly(newGroup.Name)
+	if err != nil {
+		return err
+	}
+	if oldGroup != nil {
+		return errors.ScimErrorUniqueness
+	}
+
+	affect, err := object.AddGroupWithMembers(newGroup, memberIds)
+	if err != nil {
+		return err
+	}
+	if !affect {
+		return fmt.Errorf("add new group failed")
+	}
+
+	resource, err := group2resource(newGroup)
+	if err != nil {
+		return err
+	}
+	*r = *resource
+	return nil
+}
+
+func UpdateScimGroup(id string, r *scim.Resource) error {
+	group, err := object.GetGroupByGroupNameOnly(id)
+	if err != nil {
+		return err
+	}
+	if group == nil {
+		return errors.ScimErrorResourceNotFound(id)
+	}
+	newGroup, memberIds, err := resource2group(r.Attributes)
+	if err != nil {
+		return err
+	}
+
+	// the name is the SCIM id of the group, only the display name changes
+	group.DisplayName = newGroup.DisplayName
+	group.UpdatedTime = util.GetCurrentTime()
+	_, err = object.UpdateGroupWithMembers(group, memberIds)
+	if err != nil {
+		return err
+	}
+
+	resource, err := group2resource(group)
+	if err != nil {
+		return err
+	}
+	*r = *resource
+	return nil
+}
+
+// https://datatracker.ietf.org/doc/html/rfc7644#section-3.5.2 Modifying with PATCH
+func UpdateScimGroupByPatchOperation(id string, ops []scim.PatchOperation) (r scim.Resource, err error) {
+	group, err := object.GetGroupByGroupNameOnly(id)
+	if err != nil {
+		return scim.Resource{}, err
+	}
+	if group == nil {
+		return scim.Resource{}, errors.ScimErrorResourceNotFound(id)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid patch op value: %v", r)
+		}
+	}()
+
+	members, err := object.GetGroupUsers(group.GetId())
+	if err != nil {
+		return scim.Resource{}, err
+	}
+	memberIds := []string{}
+	for _, user := range members {
+		memberIds = append(memberIds, user.Id)
+	}
+
+	// the operations are applied to the group and its member ids, which are saved together at last
+	for _, op := range ops {
+		if op.Path == nil {
+			// e.g. {"op": "replace", "value": {"displayName": "group1"}} of Okta
+			for path, value := range ToAnyMap(op.Value) {
+				memberIds = patchScimGroup(group, memberIds, op.Op, path, nil, value)
+			}
+			continue
+		}
+
+		memberIds = patchScimGroup(group, memberIds, op.Op, op.Path.AttributePath.String(), op.Path.ValueExpression, op.Value)
+	}
+
+	group.UpdatedTime = util.GetCurrentTime()
+	_, err = object.UpdateGroupWithMembers(group, memberIds)
+	if err != nil {
+		return scim.Resource{}, err
+	}
+
+	resource, err := group2resource(group)
+	if err != nil {
+		return scim.Resource{}, err
+	}
+	return *resource, nil
+}
+
+// patchScimGroup applies an operation to the group and returns the member ids after the operation
+func patchScimGroup(group *object.Group, memberIds []string, op string, path string, valueExpression filter.Expression, value interface{}) []string {
+	switch path {
+	case "displayName":
+		if op != scim.PatchOperationRemove {
+			group.DisplayName = ToString(value, group.DisplayName)
+		}
+	case "members":
+		values := getScimMemberIds(value)
+		switch op {
+		case scim.PatchOperationAdd:
+			for _, memberId := range values {
+				if !util.InSlice(memberIds, memberId) {
+					memberIds = append(memberIds, memberId)
+				}
+			}
+		case scim.PatchOperationReplace:
+			return values
+		case scim.PatchOperationRemove:
+			// e.g. {"op": "remove", "path": "members[value eq \"id\"]"} of Okta
+			if memberId, ok := getMemberIdFilter(valueExpression); ok {
+				values = []string{memberId}
+			} else if value == nil {
+				return []string{}
+			}
+
+			for _, memberId := range values {
+				memberIds = util.DeleteVal(memberIds, memberId)
+			}
+		}
+	}
+	return memberIds
+}
+
+// getMemberIdFilter returns the value of a `members[value eq "..."]` filter
+func getMemberIdFilter(expression filter.Expression) (string, bool) {
+	attrExp, ok := expression.(*filter.AttributeExpression)
+	if !ok || attrExp.Operator != filter.EQ || !strings.EqualFold(attrExp.AttributePath.AttributeName, "value") {
+		return "", false
+	}
+
+	memberId, ok := attrExp.CompareValue.(string)
+	return memberId, ok
+}
+
+// getScimMemberIds returns the user ids of the members, e.g. [{"value": "id", "display": "alice"}]
+func getScimMemberIds(value interface{}) []string {
+	memberIds := []string{}
+	for _, member := range ToAnyArray(value, AnyArray{}) {
+		memberId := ToString(ToAnyMap(member)["value"], "")
+		if memberId != "" {
+			memberIds = append(memberIds, memberId)
+		}
+	}
+	return memberIds
+}
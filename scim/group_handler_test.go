@@ -0,0 +1,80 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"testing"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/elimity-com/scim"
+	"github.com/scim2/filter-parser/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMemberIdFilter(t *testing.T) {
+	path, err := filter.ParsePath([]byte(`members[value eq "2c9f3e1a"]`))
+	assert.Nil(t, err)
+	memberId, ok := getMemberIdFilter(path.ValueExpression)
+	assert.True(t, ok)
+	assert.Equal(t, "2c9f3e1a", memberId)
+
+	path, err = filter.ParsePath([]byte("members"))
+	assert.Nil(t, err)
+	_, ok = getMemberIdFilter(path.ValueExpression)
+	assert.False(t, ok)
+}
+
+func TestGetDisplayNameFilter(t *testing.T) {
+	expression, err := filter.ParseFilter([]byte(`displayName eq "group1"`))
+	assert.Nil(t, err)
+	displayName, ok := getDisplayNameFilter(expression)
+	assert.True(t, ok)
+	assert.Equal(t, "group1", displayName)
+
+	expression, err = filter.ParseFilter([]byte(`displayName co "group"`))
+	assert.Nil(t, err)
+	_, ok = getDisplayNameFilter(expression)
+	assert.False(t, ok)
+}
+
+func TestGetScimMemberIds(t *testing.T) {
+	value := []interface{}{
+		map[string]interface{}{"value": "2c9f3e1a", "display": "alice"},
+		map[string]interface{}{"display": "bob"},
+	}
+	assert.Equal(t, []string{"2c9f3e1a"}, getScimMemberIds(value))
+	assert.Equal(t, []string{}, getScimMemberIds(nil))
+}
+
+func TestPatchScimGroup(t *testing.T) {
+	group := &object.Group{Owner: "built-in", Name: "group1", DisplayName: "group1"}
+	memberIds := patchScimGroup(group, []string{"1"}, scim.PatchOperationAdd, "members", nil, []interface{}{
+		map[string]interface{}{"value": "1"},
+		map[string]interface{}{"value": "2"},
+	})
+	assert.Equal(t, []string{"1", "2"}, memberIds)
+
+	path, err := filter.ParsePath([]byte(`members[value eq "1"]`))
+	assert.Nil(t, err)
+	memberIds = patchScimGroup(group, memberIds, scim.PatchOperationRemove, "members", path.ValueExpression, nil)
+	assert.Equal(t, []string{"2"}, memberIds)
+
+	memberIds = patchScimGroup(group, memberIds, scim.PatchOperationReplace, "displayName", nil, "group2")
+	assert.Equal(t, []string{"2"}, memberIds)
+	assert.Equal(t, "group2", group.DisplayName)
+
+	memberIds = patchScimGroup(group, memberIds, scim.PatchOperationRemove, "members", nil, nil)
+	assert.Equal(t, []string{}, memberIds)
+}
@@ -64,7 +64,8 @@ Example JSON user resource
 */
 
 const (
-	UserExtensionKey = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	UserExtensionKey  = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	GroupExtensionKey = "urn:ietf:params:scim:schemas:extension:casdoor:2.0:Group"
 )
 
 var (
@@ -95,6 +96,10 @@ var (
 			newStringParams("region", false, false),
 			newStringParams("country", false, false),
 		}),
+		newComplexParams("groups", false, true, []schema.SimpleParams{
+			newStringParams("value", false, false),
+			newStringParams("display", false, false),
+		}),
 	}
 	Server = GetScimServer()
 )
@@ -132,6 +137,20 @@ func GetScimServer() scim.Server {
 		},
 	}
 
+	groupSchema := schema.CoreGroupSchema()
+	groupSchema.Description = optional.NewString("Group in Casdoor")
+
+	groupExtension := schema.Schema{
+		ID:          GroupExtensionKey,
+		Name:        optional.NewString("CasdoorGroup"),
+		Description: optional.NewString("Casdoor Group"),
+		Attributes: []schema.CoreAttribute{
+			schema.SimpleCoreAttribute(schema.SimpleStringParams(schema.StringParams{
+				Name: "organization",
+			})),
+		},
+	}
+
 	resourceTypes := []scim.ResourceType{
 		{
 			ID:          optional.NewString("User"),
@@ -144,6 +163,17 @@ func GetScimServer() scim.Server {
 			},
 			Handler: UserResourceHandler{},
 		},
+		{
+			ID:          optional.NewString("Group"),
+			Name:        "Group",
+			Endpoint:    "/Groups",
+			Description: optional.NewString("Group in Casdoor"),
+			Schema:      groupSchema,
+			SchemaExtensions: []scim.SchemaExtension{
+				{Schema: groupExtension},
+			},
+			Handler: GroupResourceHandler{},
+		},
 	}
 
 	server := scim.Server{
@@ -159,6 +159,8 @@ func UpdateScimUser(id string, r *scim.Resource) error {
 	if err != nil {
 		return err
 	}
+	// the groups are read-only in the user resource, they're managed by the Group resources
+	newUser.Groups = oldUser.Groups
 	_, err = object.UpdateUser(oldUser.GetId(), newUser, nil, true)
 	if err != nil {
 		return err
@@ -17,6 +17,7 @@ package scim
 import (
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/casdoor/casdoor/object"
 	"github.com/casdoor/casdoor/util"
@@ -94,9 +95,13 @@ func buildExternalId(user *object.User) optional.String {
 }
 
 func buildMeta(user *object.User) scim.Meta {
-	createdTime := util.String2Time(user.CreatedTime)
-	updatedTime := util.String2Time(user.UpdatedTime)
-	if user.UpdatedTime == "" {
+	return buildMetaByTime(user.CreatedTime, user.UpdatedTime)
+}
+
+func buildMetaByTime(createdTimeString string, updatedTimeString string) scim.Meta {
+	createdTime := util.String2Time(createdTimeString)
+	updatedTime := util.String2Time(updatedTimeString)
+	if updatedTimeString == "" {
 		updatedTime = createdTime
 	}
 	return scim.Meta{
@@ -188,6 +193,16 @@ func user2resource(user *object.User) *scim.Resource {
 			"country":  user.CountryCode, // e.g. USA
 		},
 	}
+	groups := []scim.ResourceAttributes{}
+	for _, groupId := range user.Groups {
+		// the SCIM id of a group is its name, e.g. "built-in/group1" -> "group1"
+		groupName := groupId[strings.Index(groupId, "/")+1:]
+		groups = append(groups, scim.ResourceAttributes{
+			"value":   groupName,
+			"display": groupName,
+		})
+	}
+	attrs["groups"] = groups
 
 	// Enterprise user schema extension
 	attrs[UserExtensionKey] = scim.ResourceAttributes{
@@ -236,3 +251,66 @@ func resource2user(attrs scim.ResourceAttributes) (user *object.User, err error)
 	}
 	return
 }
+
+func group2resource(group *object.Group) (*scim.Resource, error) {
+	users, err := object.GetGroupUsers(group.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	members := []scim.ResourceAttributes{}
+	for _, user := range users {
+		members = append(members, scim.ResourceAttributes{
+			"value":   user.Id,
+			"display": user.Name,
+		})
+	}
+
+	attrs := make(map[string]interface{})
+	attrs["displayName"] = group.DisplayName
+	attrs["members"] = members
+
+	// Enterprise group schema extension
+	attrs[GroupExtensionKey] = scim.ResourceAttributes{
+		"organization": group.Owner,
+	}
+
+	return &scim.Resource{
+		ID:         group.Name,
+		Attributes: attrs,
+		Meta:       buildMetaByTime(group.CreatedTime, group.UpdatedTime),
+	}, nil
+}
+
+func resource2group(attrs scim.ResourceAttributes) (group *object.Group, memberIds []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("failed to parse attrs: %v", r)
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	displayName := getAttrString(attrs, "displayName")
+	if displayName == "" {
+		return nil, nil, fmt.Errorf("displayName is required")
+	}
+
+	owner := getAttrJsonValue(attrs, GroupExtensionKey, "organization")
+	group = &object.Group{
+		Owner:       owner,
+		Name:        getScimGroupName(displayName),
+		CreatedTime: util.GetCurrentTime(),
+		UpdatedTime: util.GetCurrentTime(),
+		DisplayName: displayName,
+		Type:        "Virtual",
+		ParentId:    owner,
+		IsTopGroup:  true,
+		IsEnabled:   true,
+	}
+	return group, getScimMemberIds(attrs["members"]), nil
+}
+
+// getScimGroupName returns the Casdoor group name of a SCIM display name, the name can't contain "/"
+func getScimGroupName(displayName string) string {
+	return strings.ReplaceAll(displayName, "/", "-")
+}
@@ -0,0 +1,388 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tacacs
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// the packet format of TACACS+, see RFC 8907, 4
+const (
+	versionMajor        = 0xc
+	versionMinorDefault = 0x0
+	versionMinorOne     = 0x1
+
+	packetTypeAuthentication = 0x01
+	packetTypeAuthorization  = 0x02
+	packetTypeAccounting     = 0x03
+
+	flagUnencrypted   = 0x01
+	flagSingleConnect = 0x04
+
+	headerLength = 12
+	// the bodies of the devices are far below this limit, it keeps a client from allocating up to 4 GB
+	maxBodyLength = 1 << 16
+)
+
+// authentication, see RFC 8907, 5
+const (
+	authenActionLogin = 0x01
+
+	authenTypeAscii = 0x01
+	authenTypePap   = 0x02
+
+	authenStatusPass    = 0x01
+	authenStatusFail    = 0x02
+	authenStatusGetData = 0x03
+	authenStatusGetUser = 0x04
+	authenStatusGetPass = 0x05
+	authenStatusError   = 0x07
+
+	authenReplyFlagNoEcho   = 0x01
+	authenContinueFlagAbort = 0x01
+)
+
+// authorization, see RFC 8907, 6
+const (
+	authorStatusPassAdd = 0x01
+	authorStatusFail    = 0x10
+	authorStatusError   = 0x11
+)
+
+// accounting, see RFC 8907, 7
+const (
+	acctFlagStart    = 0x02
+	acctFlagStop     = 0x04
+	acctFlagWatchdog = 0x08
+
+	acctStatusSuccess = 0x01
+	acctStatusError   = 0x02
+)
+
+type header struct {
+	Version   byte
+	Type      byte
+	SeqNo     byte
+	Flags     byte
+	SessionId uint32
+}
+
+type packet struct {
+	header
+	Body []byte
+}
+
+// getPseudoPad returns the pad that the body is XORed with: MD5(session_id, key, version, seq_no) followed by
+// MD5(session_id, key, version, seq_no, previous MD5) until the length of the body, see RFC 8907, 4.5
+func getPseudoPad(h header, secret []byte, length int) []byte {
+	prefix := make([]byte, 4, 6+len(secret))
+	binary.BigEndian.PutUint32(prefix, h.SessionId)
+	prefix = append(prefix, secret...)
+	prefix = append(prefix, h.Version, h.SeqNo)
+
+	pad := make([]byte, 0, length+md5.Size)
+	var sum []byte
+	for len(pad) < length {
+		hash := md5.New()
+		hash.Write(prefix)
+		hash.Write(sum)
+		sum = hash.Sum(nil)
+		pad = append(pad, sum...)
+	}
+	return pad[:length]
+}
+
+// obfuscate XORs the body with the pseudo pad, the same operation reveals the body
+func obfuscate(h header, secret []byte, body []byte) []byte {
+	if len(secret) == 0 || h.Flags&flagUnencrypted != 0 {
+		return body
+	}
+
+	pad := getPseudoPad(h, secret, len(body))
+	res := make([]byte, len(body))
+	for i := range body {
+		res[i] = body[i] ^ pad[i]
+	}
+	return res
+}
+
+func readPacket(r io.Reader, secret []byte) (*packet, error) {
+	b := make([]byte, headerLength)
+	_, err := io.ReadFull(r, b)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &packet{
+		header: header{
+			Version:   b[0],
+			Type:      b[1],
+			SeqNo:     b[2],
+			Flags:     b[3],
+			SessionId: binary.BigEndian.Uint32(b[4:8]),
+		},
+	}
+	if p.Version>>4 != versionMajor {
+		return nil, fmt.Errorf("the major version: %d of the packet is not supported", p.Version>>4)
+	}
+	if len(secret) != 0 && p.Flags&flagUnencrypted != 0 {
+		return nil, errors.New("the packet is not obfuscated, but a secret is configured")
+	}
+
+	length := binary.BigEndian.Uint32(b[8:12])
+	if length > maxBodyLength {
+		return nil, fmt.Errorf("the packet length: %d is too large", length)
+	}
+
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Body = obfuscate(p.header, secret, body)
+	return p, nil
+}
+
+func writePacket(w io.Writer, p *packet, secret []byte) error {
+	b := make([]byte, headerLength, headerLength+len(p.Body))
+	b[0] = p.Version
+	b[1] = p.Type
+	b[2] = p.SeqNo
+	b[3] = p.Flags
+	binary.BigEndian.PutUint32(b[4:8], p.SessionId)
+	binary.BigEndian.PutUint32(b[8:12], uint32(len(p.Body)))
+	b = append(b, obfuscate(p.header, secret, p.Body)...)
+
+	_, err := w.Write(b)
+	return err
+}
+
+// bodyReader reads the fields of a body, the first out-of-range read sets err and the next reads return zero values
+type bodyReader struct {
+	b   []byte
+	err error
+}
+
+func (r *bodyReader) readBytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > len(r.b) {
+		r.err = errors.New("the packet body is truncated")
+		return nil
+	}
+
+	res := r.b[:n]
+	r.b = r.b[n:]
+	return res
+}
+
+func (r *bodyReader) readByte() byte {
+	b := r.readBytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *bodyReader) readUint16() int {
+	b := r.readBytes(2)
+	if b == nil {
+		return 0
+	}
+	return int(binary.BigEndian.Uint16(b))
+}
+
+func appendUint16(b []byte, v int) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+type authenStart struct {
+	Action        byte
+	PrivLvl       byte
+	AuthenType    byte
+	AuthenService byte
+	User          string
+	Port          string
+	RemAddr       string
+	Data          []byte
+}
+
+func parseAuthenStart(b []byte) (*authenStart, error) {
+	r := &bodyReader{b: b}
+	start := &authenStart{
+		Action:        r.readByte(),
+		PrivLvl:       r.readByte(),
+		AuthenType:    r.readByte(),
+		AuthenService: r.readByte(),
+	}
+	userLen, portLen, remAddrLen, dataLen := r.readByte(), r.readByte(), r.readByte(), r.readByte()
+	start.User = string(r.readBytes(int(userLen)))
+	start.Port = string(r.readBytes(int(portLen)))
+	start.RemAddr = string(r.readBytes(int(remAddrLen)))
+	start.Data = r.readBytes(int(dataLen))
+	return start, r.err
+}
+
+type authenContinue struct {
+	UserMsg string
+	Data    []byte
+	Flags   byte
+}
+
+func parseAuthenContinue(b []byte) (*authenContinue, error) {
+	r := &bodyReader{b: b}
+	userMsgLen, dataLen := r.readUint16(), r.readUint16()
+	cont := &authenContinue{Flags: r.readByte()}
+	cont.UserMsg = string(r.readBytes(userMsgLen))
+	cont.Data = r.readBytes(dataLen)
+	return cont, r.err
+}
+
+type authenReply struct {
+	Status    byte
+	Flags     byte
+	ServerMsg string
+	Data      []byte
+}
+
+func (reply *authenReply) encode() []byte {
+	b := []byte{reply.Status, reply.Flags}
+	b = appendUint16(b, len(reply.ServerMsg))
+	b = appendUint16(b, len(reply.Data))
+	b = append(b, reply.ServerMsg...)
+	return append(b, reply.Data...)
+}
+
+// argsRequest is the body of an authorization request, and of an accounting request without the flags
+type argsRequest struct {
+	AuthenMethod  byte
+	PrivLvl       byte
+	AuthenType    byte
+	AuthenService byte
+	User          string
+	Port          string
+	RemAddr       string
+	Args          []string
+}
+
+func (request *argsRequest) read(r *bodyReader) {
+	request.AuthenMethod = r.readByte()
+	request.PrivLvl = r.readByte()
+	request.AuthenType = r.readByte()
+	request.AuthenService = r.readByte()
+	userLen, portLen, remAddrLen, argCnt := r.readByte(), r.readByte(), r.readByte(), r.readByte()
+	argLens := r.readBytes(int(argCnt))
+	request.User = string(r.readBytes(int(userLen)))
+	request.Port = string(r.readBytes(int(portLen)))
+	request.RemAddr = string(r.readBytes(int(remAddrLen)))
+	for _, argLen := range argLens {
+		request.Args = append(request.Args, string(r.readBytes(int(argLen))))
+	}
+}
+
+// getArg returns the values of the argument, the separator is "=" for a mandatory argument and "*" for an optional one
+func (request *argsRequest) getArg(name string) []string {
+	values := []string{}
+	for _, arg := range request.Args {
+		i := strings.IndexAny(arg, "=*")
+		if i >= 0 && arg[:i] == name {
+			values = append(values, arg[i+1:])
+		}
+	}
+	return values
+}
+
+func (request *argsRequest) getFirstArg(name string) string {
+	values := request.getArg(name)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// getCommand returns the command line of the "cmd" and "cmd-arg" arguments of a shell, the "<cr>" that ends the
+// arguments of some devices is ignored
+func (request *argsRequest) getCommand() string {
+	cmd := request.getFirstArg("cmd")
+	if cmd == "" {
+		return ""
+	}
+
+	tokens := []string{cmd}
+	for _, arg := range request.getArg("cmd-arg") {
+		if arg != "<cr>" {
+			tokens = append(tokens, arg)
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+func parseAuthorRequest(b []byte) (*argsRequest, error) {
+	r := &bodyReader{b: b}
+	request := &argsRequest{}
+	request.read(r)
+	return request, r.err
+}
+
+type authorResponse struct {
+	Status    byte
+	Args      []string
+	ServerMsg string
+}
+
+func (response *authorResponse) encode() []byte {
+	b := []byte{response.Status, byte(len(response.Args))}
+	b = appendUint16(b, len(response.ServerMsg))
+	b = appendUint16(b, 0)
+	for _, arg := range response.Args {
+		b = append(b, byte(len(arg)))
+	}
+	b = append(b, response.ServerMsg...)
+	for _, arg := range response.Args {
+		b = append(b, arg...)
+	}
+	return b
+}
+
+type acctRequest struct {
+	Flags byte
+	argsRequest
+}
+
+func parseAcctRequest(b []byte) (*acctRequest, error) {
+	r := &bodyReader{b: b}
+	request := &acctRequest{Flags: r.readByte()}
+	request.read(r)
+	return request, r.err
+}
+
+type acctReply struct {
+	Status    byte
+	ServerMsg string
+}
+
+func (reply *acctReply) encode() []byte {
+	b := appendUint16(nil, len(reply.ServerMsg))
+	b = appendUint16(b, 0)
+	b = append(b, reply.Status)
+	return append(b, reply.ServerMsg...)
+}
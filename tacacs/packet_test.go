@@ -0,0 +1,127 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tacacs
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscate(t *testing.T) {
+	h := header{Version: versionMajor << 4, Type: packetTypeAuthentication, SeqNo: 1, SessionId: 0x12345678}
+	secret := []byte("secret")
+	body := bytes.Repeat([]byte("abcdefgh"), 5)
+
+	// the first 16 bytes of the pad are MD5(session_id, key, version, seq_no)
+	b := []byte{0x12, 0x34, 0x56, 0x78}
+	b = append(b, secret...)
+	b = append(b, h.Version, h.SeqNo)
+	sum := md5.Sum(b)
+	pad := getPseudoPad(h, secret, len(body))
+	assert.Equal(t, sum[:], pad[:md5.Size])
+
+	obfuscated := obfuscate(h, secret, body)
+	assert.NotEqual(t, body, obfuscated)
+	assert.Equal(t, body, obfuscate(h, secret, obfuscated))
+
+	h.Flags = flagUnencrypted
+	assert.Equal(t, body, obfuscate(h, secret, body))
+}
+
+func TestPacket(t *testing.T) {
+	secret := []byte("secret")
+	start := []byte{authenActionLogin, 1, authenTypePap, 1, 5, 4, 0, 3}
+	start = append(start, "alicetty0123"...)
+
+	var buf bytes.Buffer
+	p := &packet{header: header{Version: versionMajor<<4 | versionMinorOne, Type: packetTypeAuthentication, SeqNo: 1, SessionId: 1}, Body: start}
+	assert.Nil(t, writePacket(&buf, p, secret))
+	assert.Equal(t, uint32(len(start)), binary.BigEndian.Uint32(buf.Bytes()[8:12]))
+	assert.NotEqual(t, start, buf.Bytes()[headerLength:])
+
+	decoded, err := readPacket(&buf, secret)
+	assert.Nil(t, err)
+	assert.Equal(t, p, decoded)
+
+	authenStart, err := parseAuthenStart(decoded.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", authenStart.User)
+	assert.Equal(t, "tty0", authenStart.Port)
+	assert.Equal(t, "123", string(authenStart.Data))
+
+	_, err = parseAuthenStart(start[:len(start)-1])
+	assert.NotNil(t, err)
+
+	// the unobfuscated packets are rejected if a secret is configured
+	p.Flags = flagUnencrypted
+	assert.Nil(t, writePacket(&buf, p, nil))
+	_, err = readPacket(&buf, secret)
+	assert.NotNil(t, err)
+}
+
+func TestAuthorRequest(t *testing.T) {
+	args := []string{"service=shell", "cmd=show", "cmd-arg=running-config", "cmd-arg=<cr>", "priv-lvl*15"}
+	b := []byte{6, 15, authenTypeAscii, 1, 5, 4, 11, byte(len(args))}
+	for _, arg := range args {
+		b = append(b, byte(len(arg)))
+	}
+	b = append(b, "alicetty0192.168.0.1"...)
+	for _, arg := range args {
+		b = append(b, arg...)
+	}
+
+	request, err := parseAuthorRequest(b)
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", request.User)
+	assert.Equal(t, "192.168.0.1", request.RemAddr)
+	assert.Equal(t, args, request.Args)
+	assert.Equal(t, "15", request.getFirstArg("priv-lvl"))
+	assert.Equal(t, "show running-config", getAuthorizationAction(request))
+
+	request.Args = []string{"service=shell", "cmd="}
+	assert.Equal(t, "shell", getAuthorizationAction(request))
+
+	allowed, err := checkAuthorization(request, "192.168.0.1")
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+
+	response := (&authorResponse{Status: authorStatusPassAdd, Args: []string{"priv-lvl=15"}}).encode()
+	assert.Equal(t, []byte{authorStatusPassAdd, 1, 0, 0, 0, 0, 11}, response[:7])
+	assert.Equal(t, "priv-lvl=15", string(response[7:]))
+}
+
+func TestAsciiLogin(t *testing.T) {
+	sessions := map[uint32]*authenSession{}
+	start := &packet{header: header{Type: packetTypeAuthentication, SeqNo: 1, SessionId: 1}, Body: []byte{authenActionLogin, 1, authenTypeAscii, 1, 0, 0, 0, 0}}
+	reply, isDone := handleAuthentication(start, sessions, "10.0.0.1")
+	assert.False(t, isDone)
+	assert.Equal(t, byte(authenStatusGetUser), reply[0])
+
+	cont := &packet{header: header{Type: packetTypeAuthentication, SeqNo: 3, SessionId: 1}, Body: append([]byte{0, 5, 0, 0, 0}, "alice"...)}
+	reply, isDone = handleAuthentication(cont, sessions, "10.0.0.1")
+	assert.False(t, isDone)
+	assert.Equal(t, []byte{authenStatusGetPass, authenReplyFlagNoEcho}, reply[:2])
+	assert.Equal(t, "alice", sessions[1].Username)
+
+	// a CONTINUE out of sequence ends the session
+	reply, isDone = handleAuthentication(cont, sessions, "10.0.0.1")
+	assert.True(t, isDone)
+	assert.Equal(t, byte(authenStatusError), reply[0])
+	assert.Empty(t, sessions)
+}
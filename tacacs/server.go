@@ -0,0 +1,471 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tacacs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	// connectionTimeout closes the connections of the devices that stop sending, e.g. in the middle of a login
+	connectionTimeout = 5 * time.Minute
+	// maxAuthenSessions is the limit of the concurrent logins of a connection in the single connection mode
+	maxAuthenSessions = 64
+)
+
+// authenSession is the state of an ASCII login, the device sends the username, the password and the OTP in CONTINUE
+// packets of the same session
+type authenSession struct {
+	Start    *authenStart
+	SeqNo    byte
+	Status   byte
+	Username string
+	UserId   string
+	MfaType  string
+}
+
+func StartTacacsServer() {
+	tacacsServerPort := conf.GetConfigString("tacacsServerPort")
+	if tacacsServerPort == "" || tacacsServerPort == "0" {
+		return
+	}
+	if conf.GetConfigString("tacacsSecret") == "" {
+		log.Printf("StartTacacsServer() failed, err = the tacacsSecret config should not be empty")
+		return
+	}
+
+	listener, err := net.Listen("tcp", "0.0.0.0:"+tacacsServerPort)
+	if err != nil {
+		log.Printf("StartTacacsServer() failed, err = %v", err)
+		return
+	}
+
+	log.Printf("Starting TACACS+ server on %s", listener.Addr())
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Printf("StartTacacsServer() failed to accept, err = %v", err)
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+
+		go handleConn(conn)
+	}
+}
+
+// getTacacsOrganization returns the organization of the username, the username can be "organization/name", otherwise
+// the organization is the tacacsDefaultOrganization config
+func getTacacsOrganization(username string) (string, string) {
+	if strings.Count(username, "/") == 1 {
+		return util.GetOwnerAndNameFromId(username)
+	}
+
+	organization := conf.GetConfigString("tacacsDefaultOrganization")
+	if organization == "" {
+		organization = "built-in"
+	}
+	return organization, username
+}
+
+func getRemoteIp(conn net.Conn) string {
+	if tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		return tcpAddr.IP.String()
+	}
+	return ""
+}
+
+// handleConn handles the packets of a device. The connection is closed after a session is done unless the device
+// asks for the single connection mode, see RFC 8907, 4.3
+func handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	secret := []byte(conf.GetConfigString("tacacsSecret"))
+	nasAddr := getRemoteIp(conn)
+	sessions := map[uint32]*authenSession{}
+	singleConnect := false
+	for i := 0; ; i++ {
+		err := conn.SetReadDeadline(time.Now().Add(connectionTimeout))
+		if err != nil {
+			return
+		}
+
+		request, err := readPacket(conn, secret)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Printf("handleConn() failed to read the packet from: %s, err = %v", nasAddr, err)
+			}
+			return
+		}
+
+		// the single connection mode is negotiated by the first two packets
+		if i == 0 {
+			singleConnect = request.Flags&flagSingleConnect != 0
+		}
+
+		var body []byte
+		var isDone bool
+		switch request.Type {
+		case packetTypeAuthentication:
+			body, isDone = handleAuthentication(request, sessions, nasAddr)
+		case packetTypeAuthorization:
+			body, isDone = handleAuthorization(request, nasAddr), true
+		case packetTypeAccounting:
+			body, isDone = handleAccounting(request, nasAddr), true
+		default:
+			log.Printf("handleConn() the packet type: %d is unknown", request.Type)
+			return
+		}
+
+		if body != nil {
+			response := &packet{header: request.header, Body: body}
+			response.SeqNo++
+			response.Flags &= flagUnencrypted
+			if singleConnect {
+				response.Flags |= flagSingleConnect
+			}
+
+			err = writePacket(conn, response, secret)
+			if err != nil {
+				log.Printf("handleConn() failed to write the packet to: %s, err = %v", nasAddr, err)
+				return
+			}
+		}
+
+		if isDone && !singleConnect {
+			return
+		}
+	}
+}
+
+func newAuthenReply(status byte, serverMsg string) []byte {
+	reply := &authenReply{Status: status, ServerMsg: serverMsg}
+	if status == authenStatusGetPass {
+		reply.Flags = authenReplyFlagNoEcho
+	}
+	return reply.encode()
+}
+
+// handleAuthentication handles the START of a session and the CONTINUEs of an ASCII login, it returns the REPLY and
+// whether the session is done
+func handleAuthentication(request *packet, sessions map[uint32]*authenSession, nasAddr string) ([]byte, bool) {
+	if request.SeqNo == 1 {
+		start, err := parseAuthenStart(request.Body)
+		if err != nil {
+			return newAuthenReply(authenStatusError, err.Error()), true
+		}
+
+		if len(sessions) >= maxAuthenSessions {
+			return newAuthenReply(authenStatusError, "too many authentication sessions"), true
+		}
+
+		session := &authenSession{Start: start, SeqNo: request.SeqNo, Username: start.User}
+		reply := startAuthentication(session, nasAddr)
+		if session.Status == authenStatusPass || session.Status == authenStatusFail || session.Status == authenStatusError {
+			delete(sessions, request.SessionId)
+			return reply, true
+		}
+
+		sessions[request.SessionId] = session
+		return reply, false
+	}
+
+	session, ok := sessions[request.SessionId]
+	if !ok || request.SeqNo != session.SeqNo+2 {
+		delete(sessions, request.SessionId)
+		return newAuthenReply(authenStatusError, "the authentication session is unknown"), true
+	}
+
+	cont, err := parseAuthenContinue(request.Body)
+	if err != nil {
+		delete(sessions, request.SessionId)
+		return newAuthenReply(authenStatusError, err.Error()), true
+	}
+	if cont.Flags&authenContinueFlagAbort != 0 {
+		delete(sessions, request.SessionId)
+		return nil, true
+	}
+
+	session.SeqNo = request.SeqNo
+	reply := continueAuthentication(session, cont.UserMsg, nasAddr)
+	if session.Status == authenStatusPass || session.Status == authenStatusFail || session.Status == authenStatusError {
+		delete(sessions, request.SessionId)
+		return reply, true
+	}
+	return reply, false
+}
+
+func startAuthentication(session *authenSession, nasAddr string) []byte {
+	start := session.Start
+	if start.Action != authenActionLogin {
+		session.Status = authenStatusFail
+		return newAuthenReply(session.Status, "only the login action is supported")
+	}
+
+	switch start.AuthenType {
+	case authenTypePap:
+		// PAP has no CONTINUE for the OTP, the users with MFA use the ASCII login
+		return checkPassword(session, string(start.Data), nasAddr, false)
+	case authenTypeAscii:
+		if session.Username == "" {
+			session.Status = authenStatusGetUser
+			return newAuthenReply(session.Status, "Username: ")
+		}
+
+		session.Status = authenStatusGetPass
+		return newAuthenReply(session.Status, "Password: ")
+	default:
+		session.Status = authenStatusFail
+		return newAuthenReply(session.Status, fmt.Sprintf("the authentication type: %d is not supported", start.AuthenType))
+	}
+}
+
+func continueAuthentication(session *authenSession, userMsg string, nasAddr string) []byte {
+	switch session.Status {
+	case authenStatusGetUser:
+		session.Username = userMsg
+		session.Status = authenStatusGetPass
+		return newAuthenReply(session.Status, "Password: ")
+	case authenStatusGetPass:
+		return checkPassword(session, userMsg, nasAddr, true)
+	case authenStatusGetData:
+		return checkOtp(session, userMsg)
+	default:
+		session.Status = authenStatusError
+		return newAuthenReply(session.Status, "the authentication session is done")
+	}
+}
+
+// checkPassword checks the password of the user, and asks for the OTP if the user enables MFA
+func checkPassword(session *authenSession, password string, nasAddr string, canAskOtp bool) []byte {
+	organization, username := getTacacsOrganization(session.Username)
+	user, err := object.CheckUserPassword(organization, username, password, "en")
+	if err == nil {
+		err = object.CheckLdapServiceAccount(user, "en")
+	}
+	if err == nil && user.IsMfaEnabled() && !canAskOtp {
+		err = fmt.Errorf("the user: %s enables MFA, which needs the ASCII login", user.GetId())
+	}
+	if err != nil {
+		log.Printf("checkPassword() failed, err = %v", err)
+		session.Status = authenStatusFail
+		return newAuthenReply(session.Status, "")
+	}
+
+	if !user.IsMfaEnabled() {
+		session.Status = authenStatusPass
+		return newAuthenReply(session.Status, "")
+	}
+
+	prompt, err := startMfa(user, nasAddr)
+	if err != nil {
+		log.Printf("checkPassword() failed to start MFA, err = %v", err)
+		session.Status = authenStatusFail
+		return newAuthenReply(session.Status, "")
+	}
+
+	session.UserId = user.GetId()
+	session.MfaType = user.PreferredMfaType
+	session.Status = authenStatusGetData
+	return newAuthenReply(session.Status, prompt)
+}
+
+// startMfa sends the verification code of an SMS or email MFA with the providers of the default application of the
+// organization, and returns the prompt of the OTP
+func startMfa(user *object.User, nasAddr string) (string, error) {
+	mfaProps := user.GetPreferredMfaProps(true)
+	if mfaProps == nil || !mfaProps.Enabled {
+		return "", fmt.Errorf("the user: %s has no preferred MFA", user.GetId())
+	}
+	if mfaProps.MfaType == object.TotpType {
+		return "OTP: ", nil
+	}
+
+	application, err := object.GetDefaultApplication(util.GetId("admin", user.Owner))
+	if err != nil {
+		return "", err
+	}
+
+	err = object.SendMfaVerificationCode(application, user, mfaProps.MfaType, nasAddr)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Verification code sent to %s: ", mfaProps.Secret), nil
+}
+
+func checkOtp(session *authenSession, passcode string) []byte {
+	user, err := object.GetUser(session.UserId)
+	if err == nil && (user == nil || user.IsForbidden || user.IsDeleted) {
+		err = fmt.Errorf("the user: %s is not allowed", session.UserId)
+	}
+	if err == nil {
+		mfaProps := user.GetMfaProps(session.MfaType, false)
+		err = object.GetMfaUtil(session.MfaType, mfaProps).Verify(passcode)
+	}
+	if err != nil {
+		log.Printf("checkOtp() failed, err = %v", err)
+		session.Status = authenStatusFail
+		return newAuthenReply(session.Status, "")
+	}
+
+	session.Status = authenStatusPass
+	return newAuthenReply(session.Status, "")
+}
+
+// getAuthorizationAction returns the action of the Casbin request of an authorization request: the command line of a
+// shell command, or the service for the start of a shell or another service
+func getAuthorizationAction(request *argsRequest) string {
+	if command := request.getCommand(); command != "" {
+		return command
+	}
+	return request.getFirstArg("service")
+}
+
+// handleAuthorization authorizes the service or the command of a user by the enforcer of the tacacsEnforcer config,
+// the Casbin request is (user ID, device address, action). All services and commands are denied if no enforcer is
+// configured.
+func handleAuthorization(request *packet, nasAddr string) []byte {
+	authorRequest, err := parseAuthorRequest(request.Body)
+	if err != nil {
+		return (&authorResponse{Status: authorStatusError, ServerMsg: err.Error()}).encode()
+	}
+
+	allowed, err := checkAuthorization(authorRequest, nasAddr)
+	if err != nil {
+		log.Printf("handleAuthorization() failed, err = %v", err)
+		return (&authorResponse{Status: authorStatusError}).encode()
+	}
+	if !allowed {
+		return (&authorResponse{Status: authorStatusFail, ServerMsg: "Command authorization failed"}).encode()
+	}
+	return (&authorResponse{Status: authorStatusPassAdd}).encode()
+}
+
+func checkAuthorization(request *argsRequest, nasAddr string) (bool, error) {
+	enforcerId := conf.GetConfigString("tacacsEnforcer")
+	if enforcerId == "" {
+		return false, nil
+	}
+
+	organization, username := getTacacsOrganization(request.User)
+	user, err := object.GetUserByFields(organization, username)
+	if err != nil {
+		return false, err
+	}
+	if user == nil || user.IsForbidden || user.IsDeleted {
+		return false, nil
+	}
+
+	enforcer, err := object.GetInitializedEnforcer(enforcerId)
+	if err != nil {
+		return false, err
+	}
+	return enforcer.Enforce(user.GetId(), nasAddr, getAuthorizationAction(request))
+}
+
+func parseUnixTime(s string) time.Time {
+	seconds, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+func getAccountingFromRequest(request *acctRequest, nasAddr string) *object.TacacsAccounting {
+	organization, username := getTacacsOrganization(request.User)
+	elapsedTime, _ := strconv.ParseInt(request.getFirstArg("elapsed_time"), 10, 64)
+	ta := &object.TacacsAccounting{
+		Owner:       organization,
+		Name:        "ta_" + util.GenerateId()[:6],
+		CreatedTime: time.Now(),
+
+		Username:      username,
+		PrivLvl:       int(request.PrivLvl),
+		AuthenMethod:  int(request.AuthenMethod),
+		AuthenService: int(request.AuthenService),
+
+		NasAddr: nasAddr,
+		Port:    request.Port,
+		RemAddr: request.RemAddr,
+
+		TaskId:  request.getFirstArg("task_id"),
+		Service: request.getFirstArg("service"),
+		Command: request.getCommand(),
+		Args:    request.Args,
+
+		ElapsedTime:   elapsedTime,
+		LastUpdate:    time.Now(),
+		AcctStartTime: parseUnixTime(request.getFirstArg("start_time")),
+		AcctStopTime:  parseUnixTime(request.getFirstArg("stop_time")),
+	}
+	if ta.AcctStartTime.IsZero() {
+		ta.AcctStartTime = time.Now()
+	}
+	return ta
+}
+
+// handleAccounting stores the START record of a task, and updates it with the WATCHDOG and STOP records. The STOP
+// record of a command usually comes without a START, it's stored as a new record.
+func handleAccounting(request *packet, nasAddr string) []byte {
+	acctRequest, err := parseAcctRequest(request.Body)
+	if err != nil {
+		return (&acctReply{Status: acctStatusError, ServerMsg: err.Error()}).encode()
+	}
+
+	err = updateAccounting(acctRequest, nasAddr)
+	if err != nil {
+		log.Printf("handleAccounting() failed, err = %v", err)
+		return (&acctReply{Status: acctStatusError}).encode()
+	}
+	return (&acctReply{Status: acctStatusSuccess}).encode()
+}
+
+func updateAccounting(request *acctRequest, nasAddr string) error {
+	ta := getAccountingFromRequest(request, nasAddr)
+	isStart := request.Flags&acctFlagStart != 0
+	isStop := request.Flags&acctFlagStop != 0
+	isWatchdog := request.Flags&acctFlagWatchdog != 0
+	if (isStart == isStop && !isWatchdog) || (isStop && isWatchdog) {
+		return fmt.Errorf("the accounting flags: %d are invalid", request.Flags)
+	}
+
+	if isStop && ta.AcctStopTime.IsZero() {
+		ta.AcctStopTime = time.Now()
+	}
+	if (isStart && !isWatchdog) || ta.TaskId == "" {
+		return object.AddTacacsAccounting(ta)
+	}
+
+	oldTa, err := object.GetTacacsAccountingByTaskId(nasAddr, ta.TaskId)
+	if err != nil {
+		return err
+	}
+	if oldTa == nil {
+		return object.AddTacacsAccounting(ta)
+	}
+	return object.InterimUpdateTacacsAccounting(oldTa, ta, isStop)
+}
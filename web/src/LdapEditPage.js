@@ -13,13 +13,14 @@
 // limitations under the License.
 
 import React from "react";
-import {Button, Card, Col, Input, InputNumber, Row, Select, Space, Switch} from "antd";
-import {EyeInvisibleOutlined, EyeTwoTone, HolderOutlined, UsergroupAddOutlined} from "@ant-design/icons";
+import {Button, Card, Col, Input, InputNumber, Row, Select, Space, Switch, Upload} from "antd";
+import {EyeInvisibleOutlined, EyeTwoTone, HolderOutlined, UploadOutlined, UsergroupAddOutlined} from "@ant-design/icons";
 import * as LddpBackend from "./backend/LdapBackend";
 import * as OrganizationBackend from "./backend/OrganizationBackend";
 import * as Setting from "./Setting";
 import i18next from "i18next";
 import * as GroupBackend from "./backend/GroupBackend";
+import LdapAttributeMappingTable from "./table/LdapAttributeMappingTable";
 
 const {Option} = Select;
 
@@ -74,6 +75,23 @@ class LdapEditPage extends React.Component {
       });
   }
 
+  checkLdapHealth() {
+    LddpBackend.getLdapHealth(this.state.ldap.owner, this.state.ldap.id)
+      .then((res) => {
+        if (res.status === "ok") {
+          res.data.forEach(health => {
+            if (health.isHealthy) {
+              Setting.showMessage("success", `${health.address}: ${health.latency} ms`);
+            } else {
+              Setting.showMessage("error", `${health.address}: ${health.error}`);
+            }
+          });
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
   updateLdapField(key, value) {
     this.setState((prevState) => {
       prevState.ldap[key] = value;
@@ -160,6 +178,41 @@ class LdapEditPage extends React.Component {
               }} />
           </Col>
         </Row>
+        <Row style={{marginTop: "20px"}}>
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:Other hosts"), i18next.t("ldap:Other hosts - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <Select virtual={false} mode="tags" style={{width: "100%"}} value={this.state.ldap.hosts ?? []} placeholder={"dc2.example.com, dc3.example.com:636"} onChange={value => {
+              this.updateLdapField("hosts", value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:Host strategy"), i18next.t("ldap:Host strategy - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <Select virtual={false} style={{width: "100%"}} value={this.state.ldap.hostStrategy || "Failover"} onChange={(value => {
+              this.updateLdapField("hostStrategy", value);
+            })}
+            >
+              <Option key={"Failover"} value={"Failover"}>{i18next.t("ldap:Failover")}</Option>
+              <Option key={"RoundRobin"} value={"RoundRobin"}>{i18next.t("ldap:Round robin")}</Option>
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}}>
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:Pool size"), i18next.t("ldap:Pool size - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <InputNumber min={0} max={100} placeholder={"5"} value={this.state.ldap.poolSize} onChange={value => {
+              this.updateLdapField("poolSize", value);
+            }} />
+            <Button style={{marginLeft: "20px"}} onClick={() => this.checkLdapHealth()}>{i18next.t("ldap:Check health")}</Button>
+          </Col>
+        </Row>
         <Row style={{marginTop: "20px"}} >
           <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
             {Setting.getLabel(i18next.t("ldap:Enable SSL"), i18next.t("ldap:Enable SSL - Tooltip"))} :
@@ -243,6 +296,18 @@ class LdapEditPage extends React.Component {
             </Select>
           </Col>
         </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:Attribute mappings"), i18next.t("ldap:Attribute mappings - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <LdapAttributeMappingTable
+              title={i18next.t("ldap:Attribute mappings")}
+              table={this.state.ldap.attributeMappings}
+              onUpdateTable={(value) => {this.updateLdapField("attributeMappings", value);}}
+            />
+          </Col>
+        </Row>
         <Row style={{marginTop: "20px"}} >
           <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
             {Setting.getLabel(i18next.t("ldap:Default group"), i18next.t("ldap:Default group - Tooltip"))} :
@@ -268,6 +333,180 @@ class LdapEditPage extends React.Component {
             </Select>
           </Col>
         </Row>
+        <Row style={{marginTop: "20px"}}>
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:Enable group sync"), i18next.t("ldap:Enable group sync - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <Switch checked={this.state.ldap.enableGroupSync} onChange={checked => {
+              this.updateLdapField("enableGroupSync", checked);
+            }} />
+          </Col>
+        </Row>
+        {
+          !this.state.ldap.enableGroupSync ? null : (
+            <React.Fragment>
+              <Row style={{marginTop: "20px"}}>
+                <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+                  {Setting.getLabel(i18next.t("ldap:Group base DN"), i18next.t("ldap:Group base DN - Tooltip"))} :
+                </Col>
+                <Col span={21}>
+                  <Input value={this.state.ldap.groupBaseDn} placeholder={this.state.ldap.baseDn} onChange={e => {
+                    this.updateLdapField("groupBaseDn", e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: "20px"}}>
+                <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+                  {Setting.getLabel(i18next.t("ldap:Group filter"), i18next.t("ldap:Group filter - Tooltip"))} :
+                </Col>
+                <Col span={21}>
+                  <Input value={this.state.ldap.groupFilter} placeholder={"(|(objectClass=groupOfNames)(objectClass=groupOfUniqueNames)(objectClass=posixGroup)(objectClass=group))"} onChange={e => {
+                    this.updateLdapField("groupFilter", e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+                  {Setting.getLabel(i18next.t("ldap:Group parent"), i18next.t("ldap:Group parent - Tooltip"))} :
+                </Col>
+                <Col span={21}>
+                  <Select virtual={false} style={{width: "100%"}} value={this.state.ldap.groupParent ?? ""} onChange={(value => {
+                    this.updateLdapField("groupParent", value);
+                  })}
+                  >
+                    <Option key={""} value={""}>
+                      <Space>
+                        {i18next.t("general:Organization")}
+                      </Space>
+                    </Option>
+                    {
+                      this.state.groups?.filter((group) => group.ldap !== this.state.ldap.id).map((group) => <Option key={group.name} value={`${group.owner}/${group.name}`}>
+                        <Space>
+                          {group.type === "Physical" ? <UsergroupAddOutlined /> : <HolderOutlined />}
+                          {group.displayName}
+                        </Space>
+                      </Option>)
+                    }
+                  </Select>
+                </Col>
+              </Row>
+            </React.Fragment>
+          )
+        }
+        <Row style={{marginTop: "20px"}}>
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:Enable Kerberos"), i18next.t("ldap:Enable Kerberos - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <Switch checked={this.state.ldap.enableKerberos} onChange={checked => {
+              this.updateLdapField("enableKerberos", checked);
+            }} />
+          </Col>
+        </Row>
+        {
+          !this.state.ldap.enableKerberos ? null : (
+            <React.Fragment>
+              <Row style={{marginTop: "20px"}}>
+                <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+                  {Setting.getLabel(i18next.t("ldap:Keytab"), i18next.t("ldap:Keytab - Tooltip"))} :
+                </Col>
+                <Col span={21}>
+                  <Space>
+                    <Upload maxCount={1} showUploadList={false} beforeUpload={file => {
+                      const reader = new FileReader();
+                      reader.onload = () => {
+                        // the keytab is stored in base64 without the data URL prefix
+                        this.updateLdapField("kerberosKeytab", reader.result.split(",")[1] ?? "");
+                      };
+                      reader.readAsDataURL(file);
+                      return false;
+                    }}>
+                      <Button icon={<UploadOutlined />}>{i18next.t("general:Click to Upload")}</Button>
+                    </Upload>
+                    {this.state.ldap.kerberosKeytab ? i18next.t("ldap:Keytab uploaded") : null}
+                  </Space>
+                </Col>
+              </Row>
+              <Row style={{marginTop: "20px"}}>
+                <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+                  {Setting.getLabel(i18next.t("ldap:Service principal"), i18next.t("ldap:Service principal - Tooltip"))} :
+                </Col>
+                <Col span={21}>
+                  <Input value={this.state.ldap.kerberosPrincipal} placeholder={"HTTP/casdoor.example.com"} onChange={e => {
+                    this.updateLdapField("kerberosPrincipal", e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: "20px"}}>
+                <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+                  {Setting.getLabel(i18next.t("ldap:Principal attribute"), i18next.t("ldap:Principal attribute - Tooltip"))} :
+                </Col>
+                <Col span={21}>
+                  <Select virtual={false} style={{width: "100%"}} value={this.state.ldap.kerberosUserAttribute ?? ""} onChange={value => {
+                    this.updateLdapField("kerberosUserAttribute", value);
+                  }}>
+                    <Option key={""} value={""}>{i18next.t("general:Username")}</Option>
+                    {
+                      ["userPrincipalName", "sAMAccountName", "krbPrincipalName", "uid", "mail"]
+                        .map((item) => <Option key={item} value={item}>{item}</Option>)
+                    }
+                  </Select>
+                </Col>
+              </Row>
+            </React.Fragment>
+          )
+        }
+        <Row style={{marginTop: "20px"}}>
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:Enable provisioning"), i18next.t("ldap:Enable provisioning - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <Switch checked={this.state.ldap.enableProvisioning} onChange={checked => {
+              this.updateLdapField("enableProvisioning", checked);
+            }} />
+          </Col>
+        </Row>
+        {
+          !this.state.ldap.enableProvisioning ? null : (
+            <React.Fragment>
+              <Row style={{marginTop: "20px"}}>
+                <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+                  {Setting.getLabel(i18next.t("ldap:DN template"), i18next.t("ldap:DN template - Tooltip"))} :
+                </Col>
+                <Col span={21}>
+                  <Input value={this.state.ldap.provisioningDnTemplate} placeholder={`cn={Name},${this.state.ldap.baseDn}`} onChange={e => {
+                    this.updateLdapField("provisioningDnTemplate", e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: "20px"}}>
+                <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+                  {Setting.getLabel(i18next.t("ldap:Object classes"), i18next.t("ldap:Object classes - Tooltip"))} :
+                </Col>
+                <Col span={21}>
+                  <Select virtual={false} mode="tags" style={{width: "100%"}} value={this.state.ldap.provisioningObjectClasses ?? []}
+                    placeholder={"top, person, organizationalPerson, inetOrgPerson"} onChange={value => {
+                      this.updateLdapField("provisioningObjectClasses", value);
+                    }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+                  {Setting.getLabel(i18next.t("ldap:Provisioning attributes"), i18next.t("ldap:Provisioning attributes - Tooltip"))} :
+                </Col>
+                <Col span={21}>
+                  <LdapAttributeMappingTable
+                    title={i18next.t("ldap:Provisioning attributes")}
+                    isProvisioning={true}
+                    table={this.state.ldap.provisioningAttributes}
+                    onUpdateTable={(value) => {this.updateLdapField("provisioningAttributes", value);}}
+                  />
+                </Col>
+              </Row>
+            </React.Fragment>
+          )
+        }
         <Row style={{marginTop: "20px"}}>
           <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
             {Setting.getLabel(i18next.t("ldap:Auto Sync"), i18next.t("ldap:Auto Sync - Tooltip"))} :
@@ -280,10 +519,65 @@ class LdapEditPage extends React.Component {
             {this.renderAutoSyncWarn()}
           </Col>
         </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:Sync mode"), i18next.t("ldap:Sync mode - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <Select virtual={false} style={{width: "100%"}} value={this.state.ldap.syncMode || "Full"} onChange={(value => {
+              this.updateLdapField("syncMode", value);
+            })}
+            >
+              <Option key={"Full"} value={"Full"}>{i18next.t("ldap:Full")}</Option>
+              <Option key={"Incremental"} value={"Incremental"}>{i18next.t("ldap:Incremental")}</Option>
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+            {Setting.getLabel(i18next.t("ldap:Deletion policy"), i18next.t("ldap:Deletion policy - Tooltip"))} :
+          </Col>
+          <Col span={21}>
+            <Select virtual={false} style={{width: "100%"}} value={this.state.ldap.deletionPolicy || "None"} onChange={(value => {
+              this.updateLdapField("deletionPolicy", value);
+            })}
+            >
+              <Option key={"None"} value={"None"}>{i18next.t("general:None")}</Option>
+              <Option key={"Disable"} value={"Disable"}>{i18next.t("ldap:Disable")}</Option>
+              <Option key={"Delete"} value={"Delete"}>{i18next.t("general:Delete")}</Option>
+            </Select>
+          </Col>
+        </Row>
+        {
+          !this.state.ldap.syncStats ? null : (
+            <Row style={{marginTop: "20px"}} >
+              <Col style={{lineHeight: "32px", textAlign: "right", paddingRight: "25px"}} span={3}>
+                {Setting.getLabel(i18next.t("ldap:Last sync"), i18next.t("ldap:Last sync - Tooltip"))} :
+              </Col>
+              <Col span={21}>
+                <Input.TextArea disabled autoSize value={this.renderSyncStats(this.state.ldap.syncStats)} />
+              </Col>
+            </Row>
+          )
+        }
       </Card>
     );
   }
 
+  renderSyncStats(stats) {
+    const lines = [
+      `${stats.startTime} - ${stats.endTime}, ${stats.mode}`,
+      `Users: ${stats.fetched} fetched, ${stats.added} added, ${stats.updated} updated, ${stats.failed} failed, ${stats.disabled} disabled, ${stats.deleted} deleted`,
+    ];
+    if (this.state.ldap.enableGroupSync) {
+      lines.push(`Groups: ${stats.groupsAdded} added, ${stats.groupsUpdated} updated, ${stats.groupsRemoved} removed, ${stats.groupsFailed} failed`);
+    }
+    if (stats.error) {
+      lines.push(`Error: ${stats.error}`);
+    }
+    return lines.join("\n");
+  }
+
   submitLdapEdit(exitAfterSave) {
     LddpBackend.updateLdap(this.state.ldap)
       .then((res) => {
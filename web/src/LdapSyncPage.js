@@ -74,6 +74,21 @@ class LdapSyncPage extends React.Component {
       }));
   }
 
+  syncGroups() {
+    LdapBackend.syncGroups(this.state.ldap.owner, this.state.ldap.id)
+      .then((res => {
+        if (res.status === "ok") {
+          const result = res.data;
+          Setting.showMessage("success", `${result.added?.length ?? 0} added, ${result.updated?.length ?? 0} updated, ${result.removed?.length ?? 0} removed, ${result.updatedUsers?.length ?? 0} users updated`);
+          if (result.failed && result.failed.length > 0) {
+            Setting.showMessage("error", `Sync [${result.failed}] failed`);
+          }
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      }));
+  }
+
   getLdap() {
     LdapBackend.getLdap(this.state.organizationName, this.state.ldapId)
       .then((res) => {
@@ -228,6 +243,14 @@ class LdapSyncPage extends React.Component {
                 {i18next.t("general:Sync")}
               </Button>
             </Popconfirm>
+            <Popconfirm placement={"right"} disabled={!this.state.ldap?.enableGroupSync}
+              title={"Please confirm to sync groups"}
+              onConfirm={() => this.syncGroups()}
+            >
+              <Button style={{marginLeft: "10px"}} disabled={!this.state.ldap?.enableGroupSync}>
+                {i18next.t("ldap:Sync groups")}
+              </Button>
+            </Popconfirm>
             <Button style={{marginLeft: "20px"}}
               onClick={() => Setting.goToLink(`/ldap/${this.state.organizationName}/${this.state.ldapId}`)}>
               {i18next.t("general:Edit")} LDAP
@@ -1363,11 +1363,27 @@ class ProviderEditPage extends React.Component {
                   </Button>
                 </Col>
               </Row>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:SP metadata"), i18next.t("provider:SP metadata - Tooltip"))} :
+                </Col>
+                <Col span={21} >
+                  <Input value={`${authConfig.serverUrl}/api/saml/sp-metadata?provider=${this.state.provider.owner}/${encodeURIComponent(this.state.provider.name)}`} readOnly="readonly" />
+                </Col>
+                <Col span={1}>
+                  <Button type="primary" onClick={() => {
+                    copy(`${authConfig.serverUrl}/api/saml/sp-metadata?provider=${this.state.provider.owner}/${encodeURIComponent(this.state.provider.name)}`);
+                    Setting.showMessage("success", i18next.t("general:Copied to clipboard successfully"));
+                  }}>
+                    {i18next.t("provider:Copy")}
+                  </Button>
+                </Col>
+              </Row>
             </React.Fragment>
           ) : null
         }
         {
-          (this.state.provider.type === "Alipay" || this.state.provider.type === "WeChat Pay" || this.state.provider.type === "Casdoor") ? (
+          (this.state.provider.type === "Alipay" || this.state.provider.type === "WeChat Pay" || this.state.provider.type === "Casdoor" || this.state.provider.category === "SAML") ? (
             <Row style={{marginTop: "20px"}} >
               <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
                 {Setting.getLabel(i18next.t("general:Cert"), i18next.t("general:Cert - Tooltip"))} :
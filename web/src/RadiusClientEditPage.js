@@ -0,0 +1,311 @@
+// Copyright 2021 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import React from "react";
+import {Button, Card, Col, Input, InputNumber, Row, Select, Switch} from "antd";
+import * as RadiusClientBackend from "./backend/RadiusClientBackend";
+import * as OrganizationBackend from "./backend/OrganizationBackend";
+import * as ApplicationBackend from "./backend/ApplicationBackend";
+import * as RoleBackend from "./backend/RoleBackend";
+import * as GroupBackend from "./backend/GroupBackend";
+import RadiusReplyAttributeTable from "./table/RadiusReplyAttributeTable";
+import * as Setting from "./Setting";
+import i18next from "i18next";
+
+const {Option} = Select;
+
+class RadiusClientEditPage extends React.Component {
+  constructor(props) {
+    super(props);
+    this.state = {
+      classes: props,
+      owner: props.match.params.organizationName,
+      radiusClientName: props.match.params.radiusClientName,
+      radiusClient: null,
+      organizations: [],
+      applications: [],
+      roles: [],
+      groups: [],
+      mode: props.location.mode !== undefined ? props.location.mode : "edit",
+    };
+  }
+
+  UNSAFE_componentWillMount() {
+    this.getRadiusClient();
+    this.getOrganizations();
+  }
+
+  getRadiusClient() {
+    RadiusClientBackend.getRadiusClient(this.state.owner, this.state.radiusClientName)
+      .then((res) => {
+        if (res.data === null) {
+          this.props.history.push("/404");
+          return;
+        }
+
+        this.setState({
+          radiusClient: res.data,
+        });
+
+        this.getApplications(res.data.organization);
+        this.getRolesAndGroups(res.data.organization);
+      });
+  }
+
+  getOrganizations() {
+    OrganizationBackend.getOrganizations("admin")
+      .then((res) => {
+        this.setState({
+          organizations: res.data || [],
+        });
+      });
+  }
+
+  getApplications(organizationName) {
+    ApplicationBackend.getApplicationsByOrganization("admin", organizationName)
+      .then((res) => {
+        this.setState({
+          applications: res.data || [],
+        });
+      });
+  }
+
+  getRolesAndGroups(organizationName) {
+    RoleBackend.getRoles(organizationName)
+      .then((res) => {
+        this.setState({
+          roles: res.data || [],
+        });
+      });
+
+    GroupBackend.getGroups(organizationName)
+      .then((res) => {
+        this.setState({
+          groups: res.data || [],
+        });
+      });
+  }
+
+  updateRadiusClientField(key, value) {
+    const radiusClient = this.state.radiusClient;
+    radiusClient[key] = value;
+    this.setState({
+      radiusClient: radiusClient,
+    });
+  }
+
+  renderRadiusClient() {
+    return (
+      <Card size="small" title={
+        <div>
+          {this.state.mode === "add" ? i18next.t("radius:New RADIUS Client") : i18next.t("radius:Edit RADIUS Client")}&nbsp;&nbsp;&nbsp;&nbsp;
+          <Button onClick={() => this.submitRadiusClientEdit(false)}>{i18next.t("general:Save")}</Button>
+          <Button style={{marginLeft: "20px"}} type="primary" onClick={() => this.submitRadiusClientEdit(true)}>{i18next.t("general:Save & Exit")}</Button>
+          {this.state.mode === "add" ? <Button style={{marginLeft: "20px"}} onClick={() => this.deleteRadiusClient()}>{i18next.t("general:Cancel")}</Button> : null}
+        </div>
+      } style={(Setting.isMobile()) ? {margin: "5px"} : {}} type="inner">
+        <Row style={{marginTop: "10px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("general:Name"), i18next.t("general:Name - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Input value={this.state.radiusClient.name} onChange={e => {
+              this.updateRadiusClientField("name", e.target.value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("general:Display name"), i18next.t("general:Display name - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Input value={this.state.radiusClient.displayName} onChange={e => {
+              this.updateRadiusClientField("displayName", e.target.value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("radius:Address"), i18next.t("radius:Address - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Input value={this.state.radiusClient.address} placeholder={"10.0.0.1, 10.0.0.0/24"} onChange={e => {
+              this.updateRadiusClientField("address", e.target.value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("radius:Secret"), i18next.t("radius:Secret - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Input.Password value={this.state.radiusClient.secret} onChange={e => {
+              this.updateRadiusClientField("secret", e.target.value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("general:Organization"), i18next.t("radius:Organization - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} style={{width: "100%"}} disabled={this.state.radiusClient.owner !== "admin"} value={this.state.radiusClient.organization} onChange={(value => {
+              this.updateRadiusClientField("organization", value);
+              this.updateRadiusClientField("applications", []);
+              this.getApplications(value);
+              this.getRolesAndGroups(value);
+            })}>
+              {
+                this.state.organizations.map((organization, index) => <Option key={index} value={organization.name}>{organization.name}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("general:Applications"), i18next.t("radius:Applications - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} mode="multiple" style={{width: "100%"}} value={this.state.radiusClient.applications ?? []} onChange={(value => {
+              this.updateRadiusClientField("applications", value);
+            })}>
+              {
+                this.state.applications.map((application, index) => <Option key={index} value={application.name}>{application.name}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("radius:Required roles"), i18next.t("radius:Required roles - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} mode="multiple" style={{width: "100%"}} value={this.state.radiusClient.requiredRoles ?? []} onChange={(value => {
+              this.updateRadiusClientField("requiredRoles", value);
+            })}>
+              {
+                this.state.roles.map((role, index) => <Option key={index} value={`${role.owner}/${role.name}`}>{`${role.owner}/${role.name}`}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("radius:Reply attributes"), i18next.t("radius:Reply attributes - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <RadiusReplyAttributeTable
+              title={i18next.t("radius:Reply attributes")}
+              table={this.state.radiusClient.replyAttributes}
+              roles={this.state.roles}
+              groups={this.state.groups}
+              onUpdateTable={(value) => {this.updateRadiusClientField("replyAttributes", value);}}
+            />
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("radius:Enable dynamic authorization"), i18next.t("radius:Enable dynamic authorization - Tooltip"))} :
+          </Col>
+          <Col span={1} >
+            <Switch checked={this.state.radiusClient.enableDynamicAuthorization} onChange={checked => {
+              this.updateRadiusClientField("enableDynamicAuthorization", checked);
+            }} />
+          </Col>
+        </Row>
+        {
+          !this.state.radiusClient.enableDynamicAuthorization ? null : (
+            <Row style={{marginTop: "20px"}} >
+              <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                {Setting.getLabel(i18next.t("radius:Dynamic authorization port"), i18next.t("radius:Dynamic authorization port - Tooltip"))} :
+              </Col>
+              <Col span={22} >
+                <InputNumber min={0} max={65535} placeholder={3799} value={this.state.radiusClient.dynamicAuthorizationPort} onChange={value => {
+                  this.updateRadiusClientField("dynamicAuthorizationPort", value);
+                }} />
+              </Col>
+            </Row>
+          )
+        }
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("general:Is enabled"), i18next.t("general:Is enabled - Tooltip"))} :
+          </Col>
+          <Col span={1} >
+            <Switch checked={this.state.radiusClient.isEnabled} onChange={checked => {
+              this.updateRadiusClientField("isEnabled", checked);
+            }} />
+          </Col>
+        </Row>
+      </Card>
+    );
+  }
+
+  submitRadiusClientEdit(exitAfterSave) {
+    const radiusClient = Setting.deepCopy(this.state.radiusClient);
+    RadiusClientBackend.updateRadiusClient(this.state.owner, this.state.radiusClientName, radiusClient)
+      .then((res) => {
+        if (res.status === "ok") {
+          Setting.showMessage("success", i18next.t("general:Successfully saved"));
+          this.setState({
+            owner: this.state.radiusClient.owner,
+            radiusClientName: this.state.radiusClient.name,
+          });
+
+          if (exitAfterSave) {
+            this.props.history.push("/radius-clients");
+          } else {
+            this.props.history.push(`/radius-clients/${this.state.radiusClient.owner}/${this.state.radiusClient.name}`);
+          }
+        } else {
+          Setting.showMessage("error", `${i18next.t("general:Failed to save")}: ${res.msg}`);
+          this.updateRadiusClientField("name", this.state.radiusClientName);
+        }
+      })
+      .catch(error => {
+        Setting.showMessage("error", `${i18next.t("general:Failed to connect to server")}: ${error}`);
+      });
+  }
+
+  deleteRadiusClient() {
+    RadiusClientBackend.deleteRadiusClient(this.state.radiusClient)
+      .then((res) => {
+        if (res.status === "ok") {
+          this.props.history.push("/radius-clients");
+        } else {
+          Setting.showMessage("error", `${i18next.t("general:Failed to delete")}: ${res.msg}`);
+        }
+      })
+      .catch(error => {
+        Setting.showMessage("error", `${i18next.t("general:Failed to connect to server")}: ${error}`);
+      });
+  }
+
+  render() {
+    return (
+      <div>
+        {
+          this.state.radiusClient !== null ? this.renderRadiusClient() : null
+        }
+        <div style={{marginTop: "20px", marginLeft: "40px"}}>
+          <Button size="large" onClick={() => this.submitRadiusClientEdit(false)}>{i18next.t("general:Save")}</Button>
+          <Button style={{marginLeft: "20px"}} type="primary" size="large" onClick={() => this.submitRadiusClientEdit(true)}>{i18next.t("general:Save & Exit")}</Button>
+          {this.state.mode === "add" ? <Button style={{marginLeft: "20px"}} size="large" onClick={() => this.deleteRadiusClient()}>{i18next.t("general:Cancel")}</Button> : null}
+        </div>
+      </div>
+    );
+  }
+}
+
+export default RadiusClientEditPage;
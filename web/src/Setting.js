@@ -1215,6 +1215,10 @@ export function isFaceIdEnabled(application) {
   return isSigninMethodEnabled(application, "Face ID");
 }
 
+export function isKerberosEnabled(application) {
+  return isSigninMethodEnabled(application, "Kerberos");
+}
+
 export function getLoginLink(application) {
   let url;
   if (application === null) {
@@ -397,7 +397,7 @@ class UserEditPage extends React.Component {
         </Row>
       );
     } else if (accountItem.name === "User type") {
-      let userTypes = ["normal-user", "paid-user"];
+      let userTypes = ["normal-user", "paid-user", "ldap-service-account"];
       const organization = this.getUserOrganization();
       if (organization && organization.userTypes && organization.userTypes.length > 0) {
         userTypes = organization.userTypes;
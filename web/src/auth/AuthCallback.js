@@ -122,6 +122,9 @@ class AuthCallback extends React.Component {
       provider: providerName,
       code: code,
       samlRequest: samlRequest,
+      relayState: innerParams.get("RelayState") ?? "",
+      signedQuery: Util.getSamlSignedQuery(Util.getQueryParamsFromState(params.get("state"))),
+      signature: innerParams.get("Signature") ?? "",
       // state: innerParams.get("state"),
       state: applicationName,
       redirectUri: redirectUri,
@@ -210,7 +213,9 @@ class AuthCallback extends React.Component {
                 }
                 const SAMLResponse = res.data;
                 const redirectUri = res.data2.redirectUrl;
-                Setting.goToLink(`${redirectUri}${redirectUri.includes("?") ? "&" : "?"}SAMLResponse=${encodeURIComponent(SAMLResponse)}&RelayState=${oAuthParams.relayState}`);
+                // the SAML response is sent as "SAMLart" when the service provider uses the HTTP-Artifact binding
+                const samlParam = res.data2.samlParam ?? "SAMLResponse";
+                Setting.goToLink(`${redirectUri}${redirectUri.includes("?") ? "&" : "?"}${samlParam}=${encodeURIComponent(SAMLResponse)}&RelayState=${oAuthParams.relayState}`);
               }
             }
           };
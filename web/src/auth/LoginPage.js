@@ -76,7 +76,7 @@ class LoginPage extends React.Component {
 
   componentDidMount() {
     if (this.getApplicationObj() === undefined) {
-      if (this.state.type === "login" || this.state.type === "saml") {
+      if (this.state.type === "login" || this.state.type === "saml" || this.state.type === "wsfed") {
         this.getApplication();
       } else if (this.state.type === "code" || this.state.type === "cas") {
         this.getApplicationLogin();
@@ -172,7 +172,7 @@ class LoginPage extends React.Component {
       return null;
     }
 
-    if (this.state.owner === null || this.state.type === "saml") {
+    if (this.state.owner === null || this.state.type === "saml" || this.state.type === "wsfed") {
       ApplicationBackend.getApplication("admin", this.state.applicationName)
         .then((res) => {
           if (res.status === "error") {
@@ -222,6 +222,7 @@ class LoginPage extends React.Component {
       case "WebAuthn": return "webAuthn";
       case "LDAP": return "ldap";
       case "Face ID": return "faceId";
+      case "Kerberos": return "kerberos";
       }
     }
 
@@ -239,6 +240,8 @@ class LoginPage extends React.Component {
       return "LDAP";
     } else if (this.state.loginMethod === "faceId") {
       return "Face ID";
+    } else if (this.state.loginMethod === "kerberos") {
+      return "Kerberos";
     } else {
       return "Password";
     }
@@ -285,12 +288,15 @@ class LoginPage extends React.Component {
     values["signinMethod"] = this.getCurrentLoginMethod();
     const oAuthParams = Util.getOAuthGetParameters();
 
-    values["type"] = oAuthParams?.responseType ?? this.state.type;
+    // WS-Federation signs in with a session, the token is issued by the backend afterwards
+    values["type"] = oAuthParams?.responseType ?? (this.state.type === "wsfed" ? "login" : this.state.type);
 
     if (oAuthParams?.samlRequest) {
       values["samlRequest"] = oAuthParams.samlRequest;
       values["type"] = "saml";
       values["relayState"] = oAuthParams.relayState;
+      values["signedQuery"] = oAuthParams.signedQuery;
+      values["signature"] = oAuthParams.signature;
     }
   }
 
@@ -460,6 +466,10 @@ class LoginPage extends React.Component {
                 Setting.goToLink(this, `/forget/${this.state.applicationName}`);
               }
               Setting.showMessage("success", i18next.t("application:Logged in successfully"));
+              if (this.state.type === "wsfed") {
+                Setting.goToLink(`${Setting.ServerUrl}/api/wsfed/${this.state.owner}/${this.state.applicationName}${window.location.search}`);
+                return;
+              }
               this.props.onLoginSuccess();
             } else if (responseType === "code") {
               this.postCodeLoginAction(res);
@@ -489,7 +499,9 @@ class LoginPage extends React.Component {
               } else {
                 const SAMLResponse = res.data;
                 const redirectUri = res.data2.redirectUrl;
-                Setting.goToLink(`${redirectUri}${redirectUri.includes("?") ? "&" : "?"}SAMLResponse=${encodeURIComponent(SAMLResponse)}&RelayState=${oAuthParams.relayState}`);
+                // the SAML response is sent as "SAMLart" when the service provider uses the HTTP-Artifact binding
+                const samlParam = res.data2.samlParam ?? "SAMLResponse";
+                Setting.goToLink(`${redirectUri}${redirectUri.includes("?") ? "&" : "?"}${samlParam}=${encodeURIComponent(SAMLResponse)}&RelayState=${oAuthParams.relayState}`);
               }
             }
           };
@@ -580,6 +592,11 @@ class LoginPage extends React.Component {
       )
       ;
     } else if (signinItem.name === "Username") {
+      // the user of Kerberos comes from the ticket of the workstation
+      if (this.state.loginMethod === "kerberos") {
+        return null;
+      }
+
       return (
         <div key={resultItemKey}>
           <div dangerouslySetInnerHTML={{__html: ("<style>" + signinItem.customCss?.replaceAll("<style>", "").replaceAll("</style>", "") + "</style>")}} />
@@ -657,6 +674,10 @@ class LoginPage extends React.Component {
         </div>
       );
     } else if (signinItem.name === "Password") {
+      if (this.state.loginMethod === "kerberos") {
+        return null;
+      }
+
       return (
         <div key={resultItemKey}>
           <div dangerouslySetInnerHTML={{__html: ("<style>" + signinItem.customCss?.replaceAll("<style>", "").replaceAll("</style>", "") + "</style>")}} />
@@ -693,6 +714,7 @@ class LoginPage extends React.Component {
             {
               this.state.loginMethod === "webAuthn" ? i18next.t("login:Sign in with WebAuthn") :
                 this.state.loginMethod === "faceId" ? i18next.t("login:Sign in with Face ID") :
+                  this.state.loginMethod === "kerberos" ? i18next.t("login:Sign in with Kerberos") :
                   signinItem.label ? signinItem.label : i18next.t("login:Sign In")
             }
           </Button>
@@ -792,7 +814,7 @@ class LoginPage extends React.Component {
       );
     }
 
-    const showForm = Setting.isPasswordEnabled(application) || Setting.isCodeSigninEnabled(application) || Setting.isWebAuthnEnabled(application) || Setting.isLdapEnabled(application) || Setting.isFaceIdEnabled(application);
+    const showForm = Setting.isPasswordEnabled(application) || Setting.isCodeSigninEnabled(application) || Setting.isWebAuthnEnabled(application) || Setting.isLdapEnabled(application) || Setting.isFaceIdEnabled(application) || Setting.isKerberosEnabled(application);
     if (showForm) {
       let loginWidth = 320;
       if (Setting.getLanguage() === "fr") {
@@ -1106,6 +1128,7 @@ class LoginPage extends React.Component {
       [generateItemKey("WebAuthn", "None"), {label: i18next.t("login:WebAuthn"), key: "webAuthn"}],
       [generateItemKey("LDAP", "None"), {label: i18next.t("login:LDAP"), key: "ldap"}],
       [generateItemKey("Face ID", "None"), {label: i18next.t("login:Face ID"), key: "faceId"}],
+      [generateItemKey("Kerberos", "None"), {label: i18next.t("login:Kerberos"), key: "kerberos"}],
     ]);
 
     application?.signinMethods?.forEach((signinMethod) => {
@@ -111,6 +111,16 @@ export function getCasLoginParameters(owner, name) {
   };
 }
 
+// The HTTP-Redirect binding signs the SAMLRequest, RelayState and SigAlg query parameters as they were encoded by the SP,
+// so they are kept as raw substrings of the query instead of being decoded and encoded again
+export function getSamlSignedQuery(query) {
+  const names = ["SAMLRequest", "RelayState", "SigAlg"];
+  return query.replace(/^\?/, "").split("&")
+    .filter(param => names.includes(param.split("=")[0]))
+    .sort((a, b) => names.indexOf(a.split("=")[0]) - names.indexOf(b.split("=")[0]))
+    .join("&");
+}
+
 export function getOAuthGetParameters(params) {
   const queries = (params !== undefined) ? params : new URLSearchParams(window.location.search);
   const lowercaseQueries = {};
@@ -143,6 +153,8 @@ export function getOAuthGetParameters(params) {
   const codeChallenge = getRefinedValue(queries.get("code_challenge"));
   const samlRequest = getRefinedValue(lowercaseQueries["samlRequest".toLowerCase()]);
   const relayState = getRefinedValue(lowercaseQueries["RelayState".toLowerCase()]);
+  const signedQuery = (params !== undefined) ? "" : getSamlSignedQuery(window.location.search);
+  const signature = getRefinedValue(lowercaseQueries["Signature".toLowerCase()]);
   const noRedirect = getRefinedValue(lowercaseQueries["noRedirect".toLowerCase()]);
 
   if (clientId === "" && samlRequest === "") {
@@ -161,6 +173,8 @@ export function getOAuthGetParameters(params) {
       codeChallenge: codeChallenge,
       samlRequest: samlRequest,
       relayState: relayState,
+      signedQuery: signedQuery,
+      signature: signature,
       noRedirect: noRedirect,
       type: "code",
     };
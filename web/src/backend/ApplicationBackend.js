@@ -98,3 +98,14 @@ export function getSamlMetadata(owner, name, enablePostBinding) {
     },
   }).then(res => res.text());
 }
+
+export function importSamlSpMetadata(form) {
+  return fetch(`${Setting.ServerUrl}/api/import-saml-sp-metadata`, {
+    method: "POST",
+    credentials: "include",
+    body: JSON.stringify(form),
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then(res => res.json());
+}
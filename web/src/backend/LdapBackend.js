@@ -34,6 +34,16 @@ export function getLdap(owner, name) {
   }).then(res => res.json());
 }
 
+export function getLdapHealth(owner, name) {
+  return fetch(`${Setting.ServerUrl}/api/get-ldap-health?id=${owner}/${encodeURIComponent(name)}`, {
+    method: "GET",
+    credentials: "include",
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then(res => res.json());
+}
+
 export function addLdap(body) {
   return fetch(`${Setting.ServerUrl}/api/add-ldap`, {
     method: "POST",
@@ -87,3 +97,13 @@ export function syncUsers(owner, name, body) {
     },
   }).then(res => res.json());
 }
+
+export function syncGroups(owner, name) {
+  return fetch(`${Setting.ServerUrl}/api/sync-ldap-groups?id=${owner}/${encodeURIComponent(name)}`, {
+    method: "POST",
+    credentials: "include",
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then(res => res.json());
+}
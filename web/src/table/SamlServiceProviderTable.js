@@ -0,0 +1,244 @@
+// Copyright 2025 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import React from "react";
+import {DeleteOutlined, DownOutlined, UpOutlined} from "@ant-design/icons";
+import {Button, Col, Input, Row, Select, Table, Tooltip} from "antd";
+import * as Setting from "../Setting";
+import * as ApplicationBackend from "../backend/ApplicationBackend";
+import i18next from "i18next";
+
+const {Option} = Select;
+const {TextArea} = Input;
+
+const samlBindingHttpPost = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST";
+
+class SamlServiceProviderTable extends React.Component {
+  constructor(props) {
+    super(props);
+    this.state = {
+      classes: props,
+      metadata: "",
+    };
+  }
+
+  updateTable(table) {
+    this.props.onUpdateTable(table);
+  }
+
+  updateField(table, index, key, value) {
+    table[index][key] = value;
+    this.updateTable(table);
+  }
+
+  // the ACS URLs are edited as plain URLs, the binding and index of the existing ones are kept
+  updateAcsUrls(table, index, urls) {
+    const acsUrls = table[index].acsUrls ?? [];
+    const newAcsUrls = urls.map((url, i) => {
+      const acsUrl = acsUrls.find(acsUrl => acsUrl.url === url);
+      return acsUrl ?? {url: url, binding: samlBindingHttpPost, index: i, isDefault: false};
+    });
+    this.updateField(table, index, "acsUrls", newAcsUrls);
+  }
+
+  addRow(table) {
+    const row = {name: "", entityId: "", metadataUrl: "", acsUrls: [], signingCert: "", encryptionCert: "", nameIdFormat: "", attributeMapping: {}, wsFedTokenType: ""};
+    if (table === undefined || table === null) {
+      table = [];
+    }
+    table = Setting.addRow(table, row);
+    this.updateTable(table);
+  }
+
+  deleteRow(table, i) {
+    table = Setting.deleteRow(table, i);
+    this.updateTable(table);
+  }
+
+  upRow(table, i) {
+    table = Setting.swapRow(table, i - 1, i);
+    this.updateTable(table);
+  }
+
+  downRow(table, i) {
+    table = Setting.swapRow(table, i, i + 1);
+    this.updateTable(table);
+  }
+
+  // the metadata is either a metadata URL or the metadata XML
+  importMetadata(table) {
+    const metadata = this.state.metadata.trim();
+    const form = metadata.startsWith("<") ? {metadata: metadata} : {metadataUrl: metadata};
+    ApplicationBackend.importSamlSpMetadata(form)
+      .then((res) => {
+        if (res.status === "ok") {
+          if (table === undefined || table === null) {
+            table = [];
+          }
+          table = Setting.addRow(table, res.data);
+          this.updateTable(table);
+          this.setState({metadata: ""});
+          Setting.showMessage("success", i18next.t("general:Successfully added"));
+        } else {
+          Setting.showMessage("error", `${i18next.t("general:Failed to add")}: ${res.msg}`);
+        }
+      })
+      .catch(error => {
+        Setting.showMessage("error", `${i18next.t("general:Failed to connect to server")}: ${error}`);
+      });
+  }
+
+  renderTable(table) {
+    const columns = [
+      {
+        title: i18next.t("general:Name"),
+        dataIndex: "name",
+        key: "name",
+        width: "120px",
+        render: (text, record, index) => {
+          return (
+            <Input value={text} onChange={e => {
+              this.updateField(table, index, "name", e.target.value);
+            }} />
+          );
+        },
+      },
+      {
+        title: i18next.t("application:Entity ID"),
+        dataIndex: "entityId",
+        key: "entityId",
+        width: "200px",
+        render: (text, record, index) => {
+          return (
+            <Input value={text} onChange={e => {
+              this.updateField(table, index, "entityId", e.target.value);
+            }} />
+          );
+        },
+      },
+      {
+        title: i18next.t("application:ACS URLs"),
+        dataIndex: "acsUrls",
+        key: "acsUrls",
+        width: "250px",
+        render: (text, record, index) => {
+          return (
+            <Select virtual={false} mode="tags" style={{width: "100%"}}
+              value={(text ?? []).map(acsUrl => acsUrl.url)}
+              onChange={value => {
+                this.updateAcsUrls(table, index, value);
+              }} />
+          );
+        },
+      },
+      {
+        title: i18next.t("application:NameID format"),
+        dataIndex: "nameIdFormat",
+        key: "nameIdFormat",
+        width: "150px",
+        render: (text, record, index) => {
+          return (
+            <Select virtual={false} style={{width: "100%"}}
+              value={text ?? ""}
+              onChange={value => {
+                this.updateField(table, index, "nameIdFormat", value);
+              }} >
+              <Option key="Default" value="">{i18next.t("general:Default")}</Option>
+              <Option key="EmailAddress" value="urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress">EmailAddress</Option>
+              <Option key="Persistent" value="urn:oasis:names:tc:SAML:2.0:nameid-format:persistent">Persistent</Option>
+              <Option key="Transient" value="urn:oasis:names:tc:SAML:2.0:nameid-format:transient">Transient</Option>
+            </Select>
+          );
+        },
+      },
+      {
+        title: i18next.t("application:Signing cert"),
+        dataIndex: "signingCert",
+        key: "signingCert",
+        width: "200px",
+        render: (text, record, index) => {
+          return (
+            <TextArea autoSize={{minRows: 1, maxRows: 4}} value={text} onChange={e => {
+              this.updateField(table, index, "signingCert", e.target.value);
+            }} />
+          );
+        },
+      },
+      {
+        title: i18next.t("application:Encryption cert"),
+        dataIndex: "encryptionCert",
+        key: "encryptionCert",
+        width: "200px",
+        render: (text, record, index) => {
+          return (
+            <TextArea autoSize={{minRows: 1, maxRows: 4}} value={text} onChange={e => {
+              this.updateField(table, index, "encryptionCert", e.target.value);
+            }} />
+          );
+        },
+      },
+      {
+        title: i18next.t("general:Action"),
+        dataIndex: "action",
+        key: "action",
+        width: "20px",
+        render: (text, record, index) => {
+          return (
+            <div>
+              <Tooltip placement="bottomLeft" title={i18next.t("general:Up")}>
+                <Button style={{marginRight: "5px"}} disabled={index === 0} icon={<UpOutlined />} size="small" onClick={() => this.upRow(table, index)} />
+              </Tooltip>
+              <Tooltip placement="topLeft" title={i18next.t("general:Down")}>
+                <Button style={{marginRight: "5px"}} disabled={index === table.length - 1} icon={<DownOutlined />} size="small" onClick={() => this.downRow(table, index)} />
+              </Tooltip>
+              <Tooltip placement="topLeft" title={i18next.t("general:Delete")}>
+                <Button icon={<DeleteOutlined />} size="small" onClick={() => this.deleteRow(table, index)} />
+              </Tooltip>
+            </div>
+          );
+        },
+      },
+    ];
+
+    return (
+      <Table title={() => (
+        <div style={{display: "flex"}}>
+          <Button style={{marginRight: "5px"}} type="primary" size="small" onClick={() => this.addRow(table)}>{i18next.t("general:Add")}</Button>
+          <Input size="small" style={{marginRight: "5px"}} placeholder={i18next.t("application:Metadata URL or XML")} value={this.state.metadata} onChange={e => {
+            this.setState({metadata: e.target.value});
+          }} />
+          <Button size="small" disabled={this.state.metadata.trim() === ""} onClick={() => this.importMetadata(table)}>{i18next.t("application:Import metadata")}</Button>
+        </div>
+      )}
+      columns={columns} dataSource={table} rowKey="key" size="middle" bordered
+      />
+    );
+  }
+
+  render() {
+    return (
+      <div>
+        <Row style={{marginTop: "20px"}} >
+          <Col span={24}>
+            {
+              this.renderTable(this.props.table)
+            }
+          </Col>
+        </Row>
+      </div>
+    );
+  }
+}
+
+export default SamlServiceProviderTable;
@@ -72,6 +72,7 @@ class SigninMethodTable extends React.Component {
       {name: "WebAuthn", displayName: i18next.t("login:WebAuthn")},
       {name: "LDAP", displayName: i18next.t("login:LDAP")},
       {name: "Face ID", displayName: i18next.t("login:Face ID")},
+      {name: "Kerberos", displayName: i18next.t("login:Kerberos")},
     ];
     const columns = [
       {